@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -9,15 +10,21 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// База часовых поясов встраивается в бинарник: в alpine-образе нет tzdata,
+	// а пользователи выбирают часовой пояс через /timezone.
+	_ "time/tzdata"
 
 	"go-bot/internal/api"
 	"go-bot/internal/bot"
 	"go-bot/internal/config"
 	"go-bot/internal/database"
+	"go-bot/internal/queue"
+	"go-bot/internal/service"
+	"go-bot/internal/services"
+	"go-bot/internal/traffic"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
-	"go-bot/internal/service"
 )
 
 func main() {
@@ -39,6 +46,8 @@ func main() {
 	safeCfg.DBPassword = "***"
 	safeCfg.JWTSecretKey = "***"
 	safeCfg.XUIPassword = "***"
+	safeCfg.WebhookSecret = "***"
+	safeCfg.PaymentProviderToken = "***"
 	slog.Info("Loaded configuration", "config", fmt.Sprintf("%+v", safeCfg))
 
 	// 4. Подключение к базе данных
@@ -63,13 +72,127 @@ func main() {
 	xuiService := service.NewXUIService(cfg, logger)
 	slog.Info("XUI service initialized")
 
-	// 7. Настройка вебхука
-	fullWebhookURL := cfg.BaseURL + api.APIPrefix + api.WebhookPath
-	bot.SetupWebhook(tgBot, fullWebhookURL)
-	slog.Info("Telegram webhook set successfully", "url", fullWebhookURL)
+	// 7. Запись истории трафика клиентов
+	recorderCtx, stopRecorder := context.WithCancel(context.Background())
+	defer stopRecorder()
+	recorderDone := make(chan struct{})
+	if cfg.TrafficPollMinutes > 0 {
+		recorder := traffic.New(db, xuiService, traffic.Options{
+			Panel:             traffic.PanelName(cfg.XUIURL),
+			Interval:          time.Duration(cfg.TrafficPollMinutes) * time.Minute,
+			SnapshotRetention: time.Duration(cfg.TrafficSnapshotRetentionDays) * 24 * time.Hour,
+			HourlyRetention:   time.Duration(cfg.TrafficHourlyRetentionDays) * 24 * time.Hour,
+			Logger:            logger,
+		})
+		go func() {
+			defer close(recorderDone)
+			recorder.Run(recorderCtx)
+		}()
+		slog.Info("Traffic history recording started", "interval_minutes", cfg.TrafficPollMinutes)
+	} else {
+		close(recorderDone)
+	}
+
+	// 8. Тарифы и обработчик обновлений
+	plans, err := service.ParsePlans(cfg.PaymentPlans)
+	if err != nil {
+		slog.Error("Invalid PAYMENT_PLANS", "error", err)
+		return
+	}
+	paymentService := service.NewPaymentService(db, xuiService, plans, cfg.PaymentCurrency, cfg.PaymentProviderToken, logger)
+	slog.Info("Payments configured", "plans", len(plans), "currency", cfg.PaymentCurrency)
+
+	trialService := service.NewTrialService(db, xuiService, service.TrialSettings{
+		InboundID:     cfg.TrialInboundID,
+		Days:          cfg.TrialDays,
+		TrafficGB:     cfg.TrialTrafficGB,
+		MaxTelegramID: cfg.TrialMaxTelegramID,
+		SubURL:        cfg.XUISubURL,
+	}, logger)
+	slog.Info("Trials configured", "enabled", trialService.Enabled(), "inbound_id", cfg.TrialInboundID)
+
+	referralService := service.NewReferralService(db, xuiService, logger)
+	// Один сервис блокировок на бота и API, чтобы у них был общий кэш
+	banService := service.NewBanService(db)
+
+	botHandler := bot.NewHandler(bot.Deps{
+		Bot:      tgBot,
+		DB:       db,
+		XUI:      xuiService,
+		Payments: paymentService,
+		Trials:   trialService,
+		Referral: referralService,
+		Support:  service.NewSupportService(db),
+		Bans:     banService,
+		History:  traffic.NewHistory(db, traffic.PanelName(cfg.XUIURL)),
+		AdminIDs: cfg.AdminTelegramIDs,
+
+		SupportChatID:     cfg.SupportChatID,
+		CommandRateLimit:  cfg.BotCommandRateLimit,
+		CommandRateWindow: time.Duration(cfg.BotCommandRateWindowSecs) * time.Second,
+		InlineRateLimit:   cfg.BotInlineRateLimit,
+		BotUsername:       tgBot.Self.UserName,
+	})
+
+	// Повтор платежей, которые не удалось применить из-за временной ошибки
+	retriesCtx, stopRetries := context.WithCancel(context.Background())
+	defer stopRetries()
+	retriesDone := make(chan struct{})
+	if paymentService.Enabled() && cfg.PaymentRetryMinutes > 0 {
+		go func() {
+			defer close(retriesDone)
+			botHandler.RunPaymentRetries(retriesCtx, time.Duration(cfg.PaymentRetryMinutes)*time.Minute)
+		}()
+		slog.Info("Payment retries started", "interval_minutes", cfg.PaymentRetryMinutes)
+	} else {
+		close(retriesDone)
+	}
+
+	// 9. Очередь обновлений с пулом воркеров
+	webhookService := services.NewWebhookService(botHandler, logger)
+	queueOpts := queue.Options{
+		Workers:   cfg.UpdateWorkers,
+		QueueSize: cfg.UpdateQueueSize,
+		Logger:    logger,
+	}
+	if cfg.UpdateQueuePersist {
+		queueOpts.Store = queue.NewPostgresStore(db)
+	}
+	updateQueue := queue.New(webhookService, queueOpts)
+	if err := updateQueue.Start(context.Background()); err != nil {
+		slog.Error("Failed to start update queue", "error", err)
+		return
+	}
+	slog.Info("Update queue started", "workers", cfg.UpdateWorkers, "persist", cfg.UpdateQueuePersist)
 
-	// 7. Создание и запуск сервера с Graceful Shutdown
-	server := api.NewServer(logger, db, tgBot, cfg, xuiService)
+	// 10. Настройка получения обновлений: webhook или long polling
+	pollingDone := make(chan struct{})
+	pollingCtx, stopPolling := context.WithCancel(context.Background())
+	defer stopPolling()
+
+	if cfg.UpdatesMode == config.UpdatesModePolling {
+		if err := bot.DeleteWebhook(tgBot); err != nil {
+			slog.Error("Failed to delete webhook before polling", "error", err)
+			return
+		}
+		go func() {
+			defer close(pollingDone)
+			bot.RunPolling(pollingCtx, tgBot, cfg.PollingTimeoutSecs, func(ctx context.Context, update tgbotapi.Update) {
+				if err := updateQueue.Enqueue(ctx, update); err != nil && !errors.Is(err, queue.ErrDuplicate) {
+					slog.Error("Failed to enqueue update", "error", err, "update_id", update.UpdateID)
+				}
+			})
+		}()
+		slog.Info("Telegram long polling started", "timeout_seconds", cfg.PollingTimeoutSecs)
+	} else {
+		close(pollingDone)
+		fullWebhookURL := cfg.BaseURL + api.APIPrefix + api.WebhookPath
+		bot.SetupWebhook(tgBot, fullWebhookURL, cfg.WebhookSecret)
+		slog.Info("Telegram webhook set successfully", "url", fullWebhookURL)
+	}
+
+	// 11. Создание и запуск сервера с Graceful Shutdown
+	server := api.NewServer(logger, db, tgBot, cfg, xuiService, paymentService, banService, updateQueue)
 
 	go func() {
 		if err := server.Start(); err != nil && err != http.ErrServerClosed {
@@ -84,13 +207,41 @@ func main() {
 	<-quit
 	slog.Info("Shutting down server...")
 
-	// Даем 5 секунд на завершение всех активных запросов
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSecs)*time.Second)
 	defer cancel()
 
+	// Порядок остановки: сначала перестаем принимать новые обновления (HTTP и polling),
+	// затем дожидаемся, пока воркеры обработают уже принятые.
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("Server forced to shutdown", "error", err)
 	}
 
+	stopPolling()
+	select {
+	case <-pollingDone:
+	case <-ctx.Done():
+		slog.Error("Polling did not stop in time")
+	}
+
+	stopRecorder()
+	select {
+	case <-recorderDone:
+	case <-ctx.Done():
+		slog.Error("Traffic recorder did not stop in time")
+	}
+
+	stopRetries()
+	select {
+	case <-retriesDone:
+	case <-ctx.Done():
+		slog.Error("Payment retries did not stop in time")
+	}
+
+	if err := updateQueue.Shutdown(ctx); err != nil {
+		slog.Error("Update queue was not fully drained", "error", err)
+	} else {
+		slog.Info("Update queue drained")
+	}
+
 	slog.Info("Server exiting")
 }
@@ -0,0 +1,10 @@
+-- Очередь обновлений Telegram (используется при UPDATE_QUEUE_PERSIST=true).
+-- Обработанные обновления хранятся сутки, чтобы отсекать повторную доставку.
+CREATE TABLE IF NOT EXISTS telegram_updates (
+    update_id BIGINT PRIMARY KEY,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_telegram_updates_pending ON telegram_updates(update_id) WHERE processed_at IS NULL;
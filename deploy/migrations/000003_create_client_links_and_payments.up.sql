@@ -0,0 +1,31 @@
+-- Привязка Telegram-пользователей к клиентам 3x-ui
+CREATE TABLE IF NOT EXISTS client_links (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_id BIGINT NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    inbound_id INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_links_telegram_id ON client_links(telegram_id);
+
+-- Платежи через Telegram Payments. UNIQUE на telegram_payment_charge_id
+-- гарантирует, что один платеж продлевает подписку только один раз.
+CREATE TABLE IF NOT EXISTS payments (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_id BIGINT NOT NULL,
+    client_email VARCHAR(255) NOT NULL,
+    plan_id VARCHAR(64) NOT NULL,
+    currency VARCHAR(8) NOT NULL,
+    amount INT NOT NULL,
+    days INT NOT NULL,
+    traffic_gb INT NOT NULL,
+    telegram_payment_charge_id VARCHAR(255) NOT NULL UNIQUE,
+    provider_payment_charge_id VARCHAR(255),
+    status VARCHAR(16) NOT NULL,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    applied_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_telegram_id ON payments(telegram_id);
@@ -0,0 +1,9 @@
+-- Выданные пробные подписки. UNIQUE на telegram_id гарантирует
+-- не более одного пробного периода на Telegram-аккаунт.
+CREATE TABLE IF NOT EXISTS trials (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_id BIGINT NOT NULL UNIQUE,
+    client_email VARCHAR(255) NOT NULL,
+    inbound_id INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
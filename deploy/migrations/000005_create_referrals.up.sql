@@ -0,0 +1,28 @@
+-- Реферальные связи: кто кого пригласил. Пользователя можно пригласить только один раз.
+CREATE TABLE IF NOT EXISTS referrals (
+    id BIGSERIAL PRIMARY KEY,
+    referrer_id BIGINT NOT NULL,
+    referred_id BIGINT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Заполняются при начислении бонуса пригласившему (не более одного раза).
+    rewarded_at TIMESTAMPTZ,
+    reward_event VARCHAR(16),
+    reward_days INT NOT NULL DEFAULT 0,
+    reward_traffic_gb INT NOT NULL DEFAULT 0,
+    reward_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer_id ON referrals(referrer_id);
+
+-- Настройки реферальной программы, всегда одна строка с id = 1.
+CREATE TABLE IF NOT EXISTS referral_settings (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    reward_days INT NOT NULL DEFAULT 7,
+    reward_traffic_gb INT NOT NULL DEFAULT 0,
+    reward_on_trial BOOLEAN NOT NULL DEFAULT FALSE,
+    reward_on_payment BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO referral_settings (id) VALUES (1) ON CONFLICT DO NOTHING;
@@ -0,0 +1,30 @@
+-- Обращения в поддержку. У пользователя не больше одного открытого обращения.
+CREATE TABLE IF NOT EXISTS tickets (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_tickets_telegram_id ON tickets(telegram_id);
+CREATE INDEX IF NOT EXISTS idx_tickets_status ON tickets(status, updated_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tickets_open_per_user ON tickets(telegram_id) WHERE status = 'open';
+
+-- Сообщения обращения. support_message_id - ID копии сообщения в чате поддержки,
+-- по нему ответ оператора (reply) находит обращение.
+CREATE TABLE IF NOT EXISTS ticket_messages (
+    id BIGSERIAL PRIMARY KEY,
+    ticket_id BIGINT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    direction VARCHAR(8) NOT NULL,
+    user_message_id BIGINT NOT NULL DEFAULT 0,
+    support_message_id BIGINT NOT NULL DEFAULT 0,
+    operator_id BIGINT NOT NULL DEFAULT 0,
+    content_type VARCHAR(32) NOT NULL,
+    text TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_messages_ticket_id ON ticket_messages(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_messages_support_message_id ON ticket_messages(support_message_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS traffic_daily;
+DROP TABLE IF EXISTS traffic_hourly;
+DROP TABLE IF EXISTS traffic_snapshots;
@@ -0,0 +1,42 @@
+-- Снимки накопительных счетчиков трафика клиентов 3x-ui. Снимок записывается,
+-- когда счетчики или статус клиента изменились с прошлого опроса.
+-- up_delta/down_delta - трафик с предыдущего снимка с учетом сброса счетчиков.
+CREATE TABLE IF NOT EXISTS traffic_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    panel VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    inbound_id INT NOT NULL,
+    up BIGINT NOT NULL,
+    down BIGINT NOT NULL,
+    total BIGINT NOT NULL,
+    enable BOOLEAN NOT NULL,
+    up_delta BIGINT NOT NULL DEFAULT 0,
+    down_delta BIGINT NOT NULL DEFAULT 0,
+    reset BOOLEAN NOT NULL DEFAULT FALSE,
+    captured_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_traffic_snapshots_client ON traffic_snapshots(panel, email, captured_at DESC);
+CREATE INDEX IF NOT EXISTS idx_traffic_snapshots_captured_at ON traffic_snapshots(captured_at);
+
+-- Почасовой трафик клиента (час в UTC).
+CREATE TABLE IF NOT EXISTS traffic_hourly (
+    panel VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    up BIGINT NOT NULL DEFAULT 0,
+    down BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (panel, email, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_traffic_hourly_bucket ON traffic_hourly(bucket);
+
+-- Суточный трафик клиента (дата в UTC).
+CREATE TABLE IF NOT EXISTS traffic_daily (
+    panel VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    up BIGINT NOT NULL DEFAULT 0,
+    down BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (panel, email, day)
+);
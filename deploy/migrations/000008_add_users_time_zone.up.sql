@@ -0,0 +1,3 @@
+-- Часовой пояс пользователя (IANA, например Europe/Moscow) для дат в сообщениях бота.
+-- Пустая строка - UTC.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT '';
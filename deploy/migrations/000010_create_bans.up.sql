@@ -0,0 +1,11 @@
+-- Заблокированные пользователи и чаты. telegram_id - ID пользователя или чата (у групп отрицательный).
+-- expires_at NULL - бессрочная блокировка. banned_by_type/banned_by - кто заблокировал (см. audit_logs.actor_type).
+CREATE TABLE IF NOT EXISTS bans (
+    id BIGSERIAL PRIMARY KEY,
+    telegram_id BIGINT NOT NULL UNIQUE,
+    reason TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    banned_by_type VARCHAR(16) NOT NULL,
+    banned_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
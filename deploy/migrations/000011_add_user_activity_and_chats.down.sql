@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS chats;
+DROP INDEX IF EXISTS idx_users_is_active;
+ALTER TABLE users DROP COLUMN IF EXISTS blocked_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_active;
@@ -0,0 +1,19 @@
+-- is_active = FALSE - пользователь заблокировал бота или удалил чат с ним (my_chat_member),
+-- таким пользователям не отправляются рассылки и напоминания.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_users_is_active ON users(is_active);
+
+-- Группы и каналы, в которые добавлен бот. status - статус бота в чате
+-- (member, administrator, left, kicked и т.д.), как его присылает Telegram.
+CREATE TABLE IF NOT EXISTS chats (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL UNIQUE,
+    type VARCHAR(16) NOT NULL,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    added_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+DROP INDEX IF EXISTS idx_users_tags;
+ALTER TABLE users DROP COLUMN IF EXISTS tags;
+ALTER TABLE users DROP COLUMN IF EXISTS notes;
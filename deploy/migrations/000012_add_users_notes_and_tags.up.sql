@@ -0,0 +1,5 @@
+-- Заметки и теги администраторов о пользователе (Admin API /api/admin/users).
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]';
+CREATE INDEX IF NOT EXISTS idx_users_tags ON users USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);
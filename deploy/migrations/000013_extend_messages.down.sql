@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_messages_user_id_id;
+DROP INDEX IF EXISTS idx_messages_search_vector;
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE messages DROP COLUMN IF EXISTS operator_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS admin_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS telegram_message_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS chat_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS direction;
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'messages' AND column_name = 'message_text') THEN
+        ALTER TABLE messages RENAME COLUMN message_text TO text;
+    END IF;
+END $$;
@@ -0,0 +1,23 @@
+-- История переписки с пользователями: входящие сообщения пользователей и исходящие ответы
+-- операторов (из чата поддержки или через Admin API).
+-- RENAME COLUMN не поддерживает IF EXISTS, поэтому повторный запуск проверяет колонку сам.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'messages' AND column_name = 'text') THEN
+        ALTER TABLE messages RENAME COLUMN text TO message_text;
+    END IF;
+END $$;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS direction VARCHAR(8) NOT NULL DEFAULT 'in';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS chat_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS telegram_message_id INT NOT NULL DEFAULT 0;
+-- admin_id - администратор Admin API, operator_id - оператор чата поддержки (Telegram ID).
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS admin_id BIGINT REFERENCES admins(id) ON DELETE SET NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS operator_id BIGINT;
+
+-- Полнотекстовый поиск. Конфигурация simple не зависит от языка: в переписке смешаны
+-- русский и английский, а email и ключи не должны искажаться стеммингом.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', coalesce(message_text, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_messages_user_id_id ON messages(user_id, id DESC);
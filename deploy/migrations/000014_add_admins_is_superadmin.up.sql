@@ -0,0 +1,6 @@
+-- Суперадминистраторы управляют учетными записями администраторов через Admin API.
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS is_superadmin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Первый созданный администратор становится суперадминистратором, чтобы в существующих
+-- установках было кому управлять остальными без доступа к серверу.
+UPDATE admins SET is_superadmin = TRUE WHERE id = (SELECT MIN(id) FROM admins);
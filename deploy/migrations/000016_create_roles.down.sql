@@ -0,0 +1,5 @@
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS is_superadmin BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE admins SET is_superadmin = TRUE
+WHERE role_id IN (SELECT id FROM roles WHERE permissions ? 'admins:manage');
+ALTER TABLE admins DROP COLUMN IF EXISTS role_id;
+DROP TABLE IF EXISTS roles;
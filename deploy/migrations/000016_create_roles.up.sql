@@ -0,0 +1,30 @@
+-- Роли администраторов: набор разрешений (см. internal/auth/permissions.go).
+CREATE TABLE IF NOT EXISTS roles (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    permissions JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- superadmin - встроенная роль со всеми разрешениями, ее нельзя изменить через API.
+-- support - для подрядчиков поддержки: просмотр пользователей и клиентов, переписка и обращения,
+-- без изменения пользователей, блокировок и настроек.
+INSERT INTO roles (name, description, permissions) VALUES
+    ('superadmin', 'Все разрешения, включая управление администраторами',
+     '["users:read", "users:write", "messages:read", "messages:send", "tickets:read", "tickets:write", "bans:read", "bans:write", "referrals:read", "referrals:write", "admins:manage"]'),
+    ('admin', 'Все разрешения, кроме управления администраторами',
+     '["users:read", "users:write", "messages:read", "messages:send", "tickets:read", "tickets:write", "bans:read", "bans:write", "referrals:read", "referrals:write"]'),
+    ('support', 'Поддержка: просмотр пользователей, переписка и обращения',
+     '["users:read", "messages:read", "messages:send", "tickets:read", "tickets:write", "bans:read", "referrals:read"]')
+ON CONFLICT (name) DO NOTHING;
+
+-- Роль заменяет флаг is_superadmin: суперадминистраторы получают роль superadmin,
+-- остальные сохраняют прежний доступ с ролью admin.
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS role_id BIGINT REFERENCES roles(id);
+UPDATE admins SET role_id = (
+    SELECT id FROM roles WHERE name = CASE WHEN admins.is_superadmin THEN 'superadmin' ELSE 'admin' END
+) WHERE role_id IS NULL;
+ALTER TABLE admins ALTER COLUMN role_id SET NOT NULL;
+ALTER TABLE admins DROP COLUMN IF EXISTS is_superadmin;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS admin_recovery_codes;
+
+ALTER TABLE admins DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE admins DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE admins DROP COLUMN IF EXISTS totp_secret;
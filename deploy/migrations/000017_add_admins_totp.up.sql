@@ -0,0 +1,17 @@
+-- Двухфакторная аутентификация администраторов по TOTP (RFC 6238). totp_secret заполняется
+-- при подключении и действует только после подтверждения кодом (totp_enabled). totp_last_step -
+-- номер 30-секундного интервала последнего принятого кода, повторно код не принимается.
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Одноразовые коды восстановления на случай потери устройства, хранятся в виде SHA-256.
+CREATE TABLE IF NOT EXISTS admin_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id BIGINT NOT NULL REFERENCES admins(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_recovery_codes_admin_id ON admin_recovery_codes(admin_id);
@@ -0,0 +1,17 @@
+-- API-ключи для обращения к Admin API из других систем без входа по паролю. Ключ принадлежит
+-- администратору и действует с разрешениями permissions, но не шире роли владельца.
+-- Открыто хранится только prefix, сам ключ - в виде SHA-256.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id BIGINT NOT NULL REFERENCES admins(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE,
+    key_hash CHAR(64) NOT NULL,
+    permissions JSONB NOT NULL DEFAULT '[]',
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_admin_id ON api_keys(admin_id);
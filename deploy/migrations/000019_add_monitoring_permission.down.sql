@@ -0,0 +1,2 @@
+UPDATE roles SET permissions = permissions - 'monitoring:read', updated_at = NOW()
+WHERE permissions ? 'monitoring:read';
@@ -0,0 +1,4 @@
+-- Разрешение monitoring:read открывает /debug/vars. Встроенные роли superadmin и admin
+-- получают его, чтобы сохранить доступ к метрикам; support его не получает.
+UPDATE roles SET permissions = permissions || '["monitoring:read"]', updated_at = NOW()
+WHERE name IN ('superadmin', 'admin') AND NOT permissions ? 'monitoring:read';
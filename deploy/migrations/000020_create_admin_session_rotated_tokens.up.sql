@@ -0,0 +1,9 @@
+-- Refresh-токены, замененные при обновлении сессии. Повторное предъявление такого токена
+-- означает, что он украден: сессия отзывается целиком. Записи удаляются вместе с сессией.
+CREATE TABLE IF NOT EXISTS admin_session_rotated_tokens (
+    token_hash CHAR(64) PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES admin_sessions(id) ON DELETE CASCADE,
+    rotated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_session_rotated_tokens_session_id ON admin_session_rotated_tokens(session_id);
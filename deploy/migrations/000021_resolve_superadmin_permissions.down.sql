@@ -0,0 +1,3 @@
+UPDATE roles SET permissions = '["users:read", "users:write", "messages:read", "messages:send", "tickets:read", "tickets:write", "bans:read", "bans:write", "referrals:read", "referrals:write", "admins:manage", "monitoring:read"]',
+    updated_at = NOW()
+WHERE name = 'superadmin';
@@ -0,0 +1,3 @@
+-- Разрешения роли superadmin определяются в коде (auth.RolePermissions): она всегда получает
+-- все разрешения. Сохраненный список больше не используется и очищается, чтобы не устаревать.
+UPDATE roles SET permissions = '[]', updated_at = NOW() WHERE name = 'superadmin';
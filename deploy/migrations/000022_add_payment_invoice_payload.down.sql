@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_payments_status;
+ALTER TABLE payments DROP COLUMN IF EXISTS invoice_payload;
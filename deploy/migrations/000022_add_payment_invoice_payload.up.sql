@@ -0,0 +1,6 @@
+-- Payload счета нужен, чтобы повторно применить платеж, который не удалось применить сразу
+-- из-за временной ошибки базы данных или 3x-ui.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS invoice_payload VARCHAR(128) NOT NULL DEFAULT '';
+
+-- Выборка платежей, ожидающих повторного применения.
+CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status);
@@ -0,0 +1,2 @@
+UPDATE roles SET permissions = permissions - 'payments:read' - 'payments:write', updated_at = NOW()
+WHERE permissions ?| ARRAY['payments:read', 'payments:write'];
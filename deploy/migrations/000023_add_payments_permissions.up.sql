@@ -0,0 +1,8 @@
+-- Разрешения payments:read и payments:write открывают просмотр платежей и их повторное
+-- применение. Встроенная роль admin получает оба, support - только просмотр. Superadmin
+-- получает их автоматически.
+UPDATE roles SET permissions = permissions || '["payments:read", "payments:write"]', updated_at = NOW()
+WHERE name = 'admin' AND NOT permissions ? 'payments:read';
+
+UPDATE roles SET permissions = permissions || '["payments:read"]', updated_at = NOW()
+WHERE name = 'support' AND NOT permissions ? 'payments:read';
@@ -0,0 +1,247 @@
+//go:build integration
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"go-bot/internal/auth"
+	"go-bot/internal/config"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+	"go-bot/internal/services"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminAuth_Integration проходит вход, обновление токенов, получение профиля, смену пароля,
+// подключение двухфакторной аутентификации, API-ключи и выход через роутер из NewServer с реальной базой данных. Нужны переменные DB_* и
+// JWT_SECRET_KEY в deploy/.env и примененные миграции.
+func TestAdminAuth_Integration(t *testing.T) {
+	err := godotenv.Load("../../deploy/.env")
+	require.NoError(t, err, "Failed to load .env file")
+
+	cfg, err := config.Load()
+	require.NoError(t, err, "Failed to load .env config")
+	// Лимит запросов не должен мешать тесту.
+	cfg.RateLimitRequests = 1000
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	db := database.Init(cfg)
+	t.Cleanup(func() { database.Close(db) })
+
+	login := fmt.Sprintf("it-admin-%d", time.Now().UnixNano())
+	oldPassword, newPassword := "old_password_123", "new_password_456"
+	admin, err := services.NewAdminService(db, logger).CreateAdmin(context.Background(), login, oldPassword, "support")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Delete(&database.Admin{}, admin.ID) })
+
+	server := NewServer(logger, db, nil, cfg, nil, nil, service.NewBanService(db), nil)
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var reader *bytes.Reader
+		if body != nil {
+			raw, err := json.Marshal(body)
+			require.NoError(t, err)
+			reader = bytes.NewReader(raw)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, APIPrefix+"/admin"+path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	type tokens struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	loginAs := func(password string) (tokens, int) {
+		t.Helper()
+		w := do(http.MethodPost, "/login", "", map[string]string{"login": login, "password": password})
+		var response tokens
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return response, w.Code
+	}
+
+	// Без токена защищенные маршруты недоступны.
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/profile", "", nil).Code)
+
+	session, code := loginAs(oldPassword)
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, session.Token)
+	require.NotEmpty(t, session.RefreshToken)
+
+	// Обновление выдает новую пару токенов, старые перестают действовать.
+	w := do(http.MethodPost, "/refresh", "", map[string]string{"refresh_token": session.RefreshToken})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var refreshed tokens
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/profile", session.Token, nil).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/profile", refreshed.Token, nil).Code)
+
+	// Повторное предъявление замененного refresh-токена означает кражу: сессия завершается целиком.
+	assert.Equal(t, http.StatusUnauthorized,
+		do(http.MethodPost, "/refresh", "", map[string]string{"refresh_token": session.RefreshToken}).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/profile", refreshed.Token, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized,
+		do(http.MethodPost, "/refresh", "", map[string]string{"refresh_token": refreshed.RefreshToken}).Code)
+
+	// Сессию нельзя продлевать дольше JWT_SESSION_MAX_AGE_HOURS после входа.
+	session, code = loginAs(oldPassword)
+	require.Equal(t, http.StatusOK, code)
+	require.NoError(t, db.Model(&database.AdminSession{}).Where("admin_id = ?", admin.ID).
+		Update("created_at", time.Now().Add(-time.Duration(cfg.JWTSessionMaxAgeHours)*time.Hour)).Error)
+	assert.Equal(t, http.StatusUnauthorized,
+		do(http.MethodPost, "/refresh", "", map[string]string{"refresh_token": session.RefreshToken}).Code)
+
+	session, code = loginAs(oldPassword)
+	require.Equal(t, http.StatusOK, code)
+	token := session.Token
+
+	w = do(http.MethodGet, "/profile", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var profile struct {
+		ID          uint64     `json:"id"`
+		Login       string     `json:"login"`
+		LastLoginAt *time.Time `json:"last_login_at"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+	assert.Equal(t, admin.ID, profile.ID)
+	assert.Equal(t, login, profile.Login)
+	assert.NotNil(t, profile.LastLoginAt)
+
+	w = do(http.MethodPost, "/change-password", token, map[string]string{"old_password": "wrong_password", "new_password": newPassword})
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+
+	w = do(http.MethodPost, "/change-password", token, map[string]string{"old_password": oldPassword, "new_password": newPassword})
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	// Смена пароля завершает все сессии.
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/profile", token, nil).Code)
+
+	_, code = loginAs(oldPassword)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	session, code = loginAs(newPassword)
+	require.Equal(t, http.StatusOK, code)
+
+	// Роль support может смотреть пользователей, но не менять их и не управлять администраторами.
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users?limit=1", session.Token, nil).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/bans", session.Token, map[string]any{"telegram_id": 1}).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admins", session.Token, nil).Code)
+
+	// Подключение двухфакторной аутентификации: секрет действует после подтверждения кодом.
+	w = do(http.MethodPost, "/2fa/setup", session.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var setup struct {
+		Secret string `json:"secret"`
+		QRCode string `json:"qr_code"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+	assert.NotEmpty(t, setup.QRCode)
+	now := time.Now()
+	enableCode, err := auth.TOTPCode(setup.Secret, now)
+	require.NoError(t, err)
+	w = do(http.MethodPost, "/2fa/enable", session.Token, map[string]string{"code": enableCode})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var enabled struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enabled))
+	require.Len(t, enabled.RecoveryCodes, auth.RecoveryCodeCount)
+
+	// Теперь пароль дает только токен второго шага, он не заменяет access-токен.
+	challenge := func() string {
+		t.Helper()
+		w := do(http.MethodPost, "/login", "", map[string]string{"login": login, "password": newPassword})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			TwoFactorRequired bool   `json:"two_factor_required"`
+			ChallengeToken    string `json:"challenge_token"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.True(t, response.TwoFactorRequired)
+		return response.ChallengeToken
+	}
+	verify := func(challengeToken, code string) int {
+		t.Helper()
+		return do(http.MethodPost, "/login/verify", "", map[string]string{"challenge_token": challengeToken, "code": code}).Code
+	}
+	challengeToken := challenge()
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/profile", challengeToken, nil).Code)
+
+	// Уже использованный код не принимается повторно, код следующего интервала принимается.
+	assert.Equal(t, http.StatusUnauthorized, verify(challengeToken, enableCode))
+	nextCode, err := auth.TOTPCode(setup.Secret, now.Add(auth.TOTPPeriod))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, verify(challengeToken, nextCode))
+
+	// Код восстановления одноразовый.
+	assert.Equal(t, http.StatusOK, verify(challenge(), enabled.RecoveryCodes[0]))
+	assert.Equal(t, http.StatusUnauthorized, verify(challenge(), enabled.RecoveryCodes[0]))
+
+	// Неверный пароль при изменении 2FA считается неудачным входом, как неверный код.
+	var before, after database.Admin
+	require.NoError(t, db.First(&before, admin.ID).Error)
+	w = do(http.MethodPost, "/2fa/disable", session.Token, map[string]string{"password": "wrong_password", "code": enabled.RecoveryCodes[1]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	require.NoError(t, db.First(&after, admin.ID).Error)
+	assert.Equal(t, before.FailedLoginAttempts+1, after.FailedLoginAttempts)
+
+	w = do(http.MethodPost, "/2fa/disable", session.Token, map[string]string{"password": newPassword, "code": enabled.RecoveryCodes[1]})
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	_, code = loginAs(newPassword)
+	assert.Equal(t, http.StatusOK, code)
+
+	// API-ключ действует с разрешениями, выданными при создании, но не для учетной записи.
+	w = do(http.MethodPost, "/api-keys", session.Token, map[string]any{
+		"name": "monitoring", "permissions": []string{"users:read"}, "expires_at": time.Now().Add(time.Hour),
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		APIKey struct {
+			ID uint64 `json:"id"`
+		} `json:"api_key"`
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users?limit=1", created.Key, nil).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/tickets", created.Key, nil).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api-keys", created.Key, nil).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/2fa/setup", created.Key, nil).Code)
+	// Выдать ключу разрешение, которого нет у роли, нельзя.
+	w = do(http.MethodPost, "/api-keys", session.Token, map[string]any{
+		"name": "billing", "permissions": []string{"bans:write"}, "expires_at": time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = do(http.MethodDelete, fmt.Sprintf("/api-keys/%d", created.APIKey.ID), session.Token, nil)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/users?limit=1", created.Key, nil).Code)
+
+	// После выхода токен и refresh-токен сессии не действуют.
+	w = do(http.MethodPost, "/logout", session.Token, nil)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/profile", session.Token, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized,
+		do(http.MethodPost, "/refresh", "", map[string]string{"refresh_token": session.RefreshToken}).Code)
+}
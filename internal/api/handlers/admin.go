@@ -12,7 +12,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"gorm.io/gorm"
 )
 
 var validate = validator.New()
@@ -20,16 +19,16 @@ var validate = validator.New()
 // AdminHandler handles admin-related API endpoints.
 type AdminHandler struct {
 	adminService services.AdminServiceInterface
+	sessions     services.SessionServiceInterface
 	logger       *slog.Logger
-	jwtSecret    string
 }
 
 // NewAdminHandler creates a new AdminHandler.
-func NewAdminHandler(adminService services.AdminServiceInterface, logger *slog.Logger, jwtSecret string) *AdminHandler {
+func NewAdminHandler(adminService services.AdminServiceInterface, sessions services.SessionServiceInterface, logger *slog.Logger) *AdminHandler {
 	return &AdminHandler{
 		adminService: adminService,
+		sessions:     sessions,
 		logger:       logger,
-		jwtSecret:    jwtSecret,
 	}
 }
 
@@ -39,7 +38,43 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=8"`
 }
 
-// Login handles admin authentication and returns a JWT.
+// VerifyLoginRequest represents the request body for the second login step of an admin
+// with two-factor authentication.
+type VerifyLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=32"` // TOTP or recovery code
+}
+
+// RefreshRequest represents the request body for refreshing tokens.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=128"`
+}
+
+// LogoutRequest represents the optional request body for logging out.
+type LogoutRequest struct {
+	// All ends all sessions of the admin, e.g. when a device with a token is lost.
+	All bool `json:"all"`
+}
+
+// TokenResponse is returned by login and refresh.
+type TokenResponse struct {
+	Token            string    `json:"token"` // access token for the Authorization header
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"` // access token lifetime in seconds
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// ChallengeResponse is returned by login instead of tokens when the admin has two-factor
+// authentication. The challenge token and a code are exchanged for tokens by VerifyLogin.
+type ChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"` // challenge token lifetime in seconds
+}
+
+// Login handles admin authentication and opens a session with an access and a refresh token.
+// Admins with two-factor authentication get a challenge token instead.
 func (h *AdminHandler) Login(c *gin.Context) error {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -53,46 +88,141 @@ func (h *AdminHandler) Login(c *gin.Context) error {
 	// Authenticate admin
 	admin, err := h.adminService.Authenticate(c.Request.Context(), req.Login, req.Password)
 	if err != nil {
-		if err.Error() == "invalid credentials" {
-			return apierror.New(http.StatusUnauthorized, "invalid credentials")
+		return loginError(err)
+	}
+
+	if admin.TOTPEnabled {
+		challengeToken, err := h.sessions.Challenge(admin)
+		if err != nil {
+			return err // Internal server error
+		}
+		c.JSON(http.StatusOK, ChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresIn:         int(services.ChallengeTTL.Seconds()),
+		})
+		return nil
+	}
+
+	tokens, err := h.sessions.Create(c.Request.Context(), admin, sessionClient(c))
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, newTokenResponse(tokens))
+	return nil
+}
+
+// VerifyLogin completes the login of an admin with two-factor authentication: the challenge
+// token from Login and a TOTP or recovery code are exchanged for an access and a refresh token.
+func (h *AdminHandler) VerifyLogin(c *gin.Context) error {
+	var req VerifyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	adminID, err := h.sessions.VerifyChallenge(req.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidChallenge) {
+			return apierror.New(http.StatusUnauthorized, "invalid or expired challenge token")
 		}
 		return err // Internal server error
 	}
 
-	// Generate JWT
-	token, err := auth.GenerateToken(admin, h.jwtSecret, 24*time.Hour)
+	admin, err := h.adminService.VerifyTOTP(c.Request.Context(), adminID, req.Code)
+	if err != nil {
+		return loginError(err)
+	}
+
+	tokens, err := h.sessions.Create(c.Request.Context(), admin, sessionClient(c))
 	if err != nil {
 		return err // Internal server error
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, newTokenResponse(tokens))
 	return nil
 }
 
-// GetProfile handles retrieving the admin's profile.
-func (h *AdminHandler) GetProfile(c *gin.Context) error {
-	adminID, exists := c.Get("adminID")
-	if !exists {
-		return apierror.New(http.StatusUnauthorized, "missing adminID in context")
+// Refresh exchanges a refresh token for a new access and refresh token. The old refresh token
+// and the access token issued with it stop working.
+func (h *AdminHandler) Refresh(c *gin.Context) error {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
 	}
 
-	id, ok := adminID.(uint)
-	if !ok {
-		return apierror.New(http.StatusInternalServerError, "invalid admin ID type in token")
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	tokens, err := h.sessions.Refresh(c.Request.Context(), req.RefreshToken, sessionClient(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			return apierror.New(http.StatusUnauthorized, "invalid refresh token")
+		case errors.Is(err, services.ErrAccountInactive):
+			return apierror.New(http.StatusForbidden, "account is inactive")
+		}
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, newTokenResponse(tokens))
+	return nil
+}
+
+// Logout ends the current session, or all sessions of the admin with {"all": true}.
+func (h *AdminHandler) Logout(c *gin.Context) error {
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+		}
+	}
+
+	if req.All {
+		err = h.sessions.RevokeAll(c.Request.Context(), principal.AdminID)
+	} else {
+		err = h.sessions.Revoke(c.Request.Context(), principal.TokenID)
+	}
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// GetProfile handles retrieving the admin's profile.
+func (h *AdminHandler) GetProfile(c *gin.Context) error {
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
 	}
 
-	admin, err := h.adminService.GetProfile(c.Request.Context(), id)
+	admin, err := h.adminService.GetProfile(c.Request.Context(), principal.AdminID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, services.ErrAdminNotFound) {
 			return apierror.New(http.StatusNotFound, "admin not found")
 		}
 		return err // Internal server error
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":        admin.ID,
-		"login":     admin.Login,
-		"is_active": admin.IsActive,
+		"id":            admin.ID,
+		"login":         admin.Login,
+		"is_active":     admin.IsActive,
+		"role":          admin.Role,
+		"totp_enabled":  admin.TOTPEnabled,
+		"last_login_at": admin.LastLoginAt,
 	})
 	return nil
 }
@@ -103,7 +233,8 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
-// ChangePassword handles changing the admin's password.
+// ChangePassword handles changing the admin's password. All sessions of the admin are
+// revoked, so the admin has to log in again with the new password.
 func (h *AdminHandler) ChangePassword(c *gin.Context) error {
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -114,23 +245,63 @@ func (h *AdminHandler) ChangePassword(c *gin.Context) error {
 		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
 	}
 
-	adminID, exists := c.Get("adminID")
-	if !exists {
-		return apierror.New(http.StatusUnauthorized, "missing adminID in context")
-	}
-
-	id, ok := adminID.(uint)
-	if !ok {
-		return apierror.New(http.StatusInternalServerError, "invalid admin ID type in token")
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.adminService.ChangePassword(c.Request.Context(), id, req.OldPassword, req.NewPassword); err != nil {
-		if err.Error() == "incorrect old password" {
+	if err := h.adminService.ChangePassword(c.Request.Context(), principal.AdminID, req.OldPassword, req.NewPassword); err != nil {
+		if errors.Is(err, services.ErrIncorrectPassword) {
 			return apierror.New(http.StatusUnauthorized, "incorrect old password")
 		}
+		if errors.Is(err, services.ErrAdminNotFound) {
+			return apierror.New(http.StatusNotFound, "admin not found")
+		}
 		return err // Internal server error
 	}
 
 	c.Status(http.StatusNoContent)
 	return nil
 }
+
+// loginError maps the errors of both login steps to API errors.
+func loginError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return apierror.New(http.StatusUnauthorized, "invalid credentials")
+	case errors.Is(err, services.ErrInvalidTOTPCode):
+		return apierror.New(http.StatusUnauthorized, "invalid two-factor code")
+	case errors.Is(err, services.ErrAccountLocked):
+		return apierror.New(http.StatusLocked, "account is locked, try again later")
+	case errors.Is(err, services.ErrAccountInactive):
+		return apierror.New(http.StatusForbidden, "account is inactive")
+	}
+	return err // Internal server error
+}
+
+// sessionClient describes the client of the request for the session list.
+func sessionClient(c *gin.Context) services.SessionClient {
+	return services.SessionClient{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+func newTokenResponse(tokens *services.TokenPair) TokenResponse {
+	return TokenResponse{
+		Token:            tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(tokens.AccessExpiresIn.Seconds()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
+// currentAdmin returns the authenticated admin set by AuthMiddleware.
+func currentAdmin(c *gin.Context) (*auth.Principal, error) {
+	principal, ok := auth.PrincipalFromContext(c.Request.Context())
+	if !ok {
+		return nil, apierror.New(http.StatusUnauthorized, "authentication required")
+	}
+	return principal, nil
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+	"go-bot/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// generatedPasswordLength is the length of passwords issued by the admin account endpoints.
+const generatedPasswordLength = 20
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// AdminAccountHandler handles endpoints for managing admin accounts and roles.
+type AdminAccountHandler struct {
+	admins services.AdminServiceInterface
+	audit  *service.AuditService
+	logger *slog.Logger
+}
+
+// NewAdminAccountHandler creates a new AdminAccountHandler.
+func NewAdminAccountHandler(admins services.AdminServiceInterface, audit *service.AuditService, logger *slog.Logger) *AdminAccountHandler {
+	return &AdminAccountHandler{
+		admins: admins,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// CreateAdminRequest represents the request body for creating an admin.
+type CreateAdminRequest struct {
+	Login string `json:"login" validate:"required,min=3,max=64"`
+	// Password is generated and returned once when omitted.
+	Password string `json:"password" validate:"omitempty,min=8,max=72"`
+	Role     string `json:"role" validate:"required,max=32"`
+}
+
+// SetRoleRequest represents the request body for changing the role of an admin.
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required,max=32"`
+}
+
+// SaveRoleRequest represents the request body for creating or updating a role.
+type SaveRoleRequest struct {
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"required,dive,required"`
+}
+
+// ListAdmins returns all admin accounts.
+func (h *AdminAccountHandler) ListAdmins(c *gin.Context) error {
+	admins, err := h.admins.ListAdmins(c.Request.Context())
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admins": admins})
+	return nil
+}
+
+// CreateAdmin creates an admin account. A generated password is returned in the response
+// and can't be retrieved again.
+func (h *AdminAccountHandler) CreateAdmin(c *gin.Context) error {
+	var req CreateAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	password := req.Password
+	generated := password == ""
+	if generated {
+		var err error
+		if password, err = auth.GeneratePassword(generatedPasswordLength); err != nil {
+			return err // Internal server error
+		}
+	}
+
+	admin, err := h.admins.CreateAdmin(c.Request.Context(), req.Login, password, req.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrAdminExists) {
+			return apierror.New(http.StatusConflict, "admin with this login already exists")
+		}
+		if errors.Is(err, services.ErrRoleNotFound) {
+			return apierror.New(http.StatusBadRequest, "role not found")
+		}
+		return err // Internal server error
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, "admin.create", strconv.FormatUint(admin.ID, 10),
+		map[string]any{"login": admin.Login, "role": req.Role})
+	h.logger.Info("admin created via admin API", "login", admin.Login, "created_by", contextAdminID(c))
+
+	response := gin.H{"admin": admin}
+	if generated {
+		response["password"] = password
+	}
+	c.JSON(http.StatusCreated, response)
+	return nil
+}
+
+// DeactivateAdmin deactivates an admin account. Admins can't deactivate themselves.
+func (h *AdminAccountHandler) DeactivateAdmin(c *gin.Context) error {
+	id, err := adminIDParam(c)
+	if err != nil {
+		return err
+	}
+	if int64(id) == contextAdminID(c) {
+		return apierror.New(http.StatusBadRequest, "cannot deactivate your own account")
+	}
+
+	admin, err := h.updateAdmin(c, id, "admin.deactivate", h.admins.DeactivateAdmin)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admin": admin})
+	return nil
+}
+
+// ActivateAdmin activates a deactivated admin account.
+func (h *AdminAccountHandler) ActivateAdmin(c *gin.Context) error {
+	id, err := adminIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	admin, err := h.updateAdmin(c, id, "admin.activate", h.admins.ActivateAdmin)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admin": admin})
+	return nil
+}
+
+// UnlockAdmin clears the failed login attempts and the lockout of an admin account.
+func (h *AdminAccountHandler) UnlockAdmin(c *gin.Context) error {
+	id, err := adminIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	admin, err := h.updateAdmin(c, id, "admin.unlock", h.admins.UnlockAdmin)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admin": admin})
+	return nil
+}
+
+// SetRole changes the role of an admin. The sessions of the admin are revoked, so the new
+// permissions apply at the next login. Admins can't change their own role.
+func (h *AdminAccountHandler) SetRole(c *gin.Context) error {
+	id, err := adminIDParam(c)
+	if err != nil {
+		return err
+	}
+	if int64(id) == contextAdminID(c) {
+		return apierror.New(http.StatusBadRequest, "cannot change your own role")
+	}
+
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	admin, err := h.updateAdmin(c, id, "admin.set_role", func(ctx context.Context, adminID uint64) error {
+		return h.admins.SetRole(ctx, adminID, req.Role)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admin": admin})
+	return nil
+}
+
+// ResetPassword replaces the password of an admin account with a generated one.
+// The password is returned once and should be changed by the admin after logging in.
+// Admins change their own password with /change-password, which requires the current one.
+func (h *AdminAccountHandler) ResetPassword(c *gin.Context) error {
+	id, err := adminIDParam(c)
+	if err != nil {
+		return err
+	}
+	if int64(id) == contextAdminID(c) {
+		return apierror.New(http.StatusBadRequest, "cannot reset your own password, use /change-password")
+	}
+
+	password, err := auth.GeneratePassword(generatedPasswordLength)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	admin, err := h.updateAdmin(c, id, "admin.reset_password", func(ctx context.Context, adminID uint64) error {
+		return h.admins.SetPassword(ctx, adminID, password)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"admin":    admin,
+		"password": password,
+	})
+	return nil
+}
+
+// ResetTwoFactor turns off two-factor authentication of an admin who lost the authenticator
+// and the recovery codes. The sessions of the admin are revoked. Admins turn off their own
+// two-factor authentication with /2fa/disable, which requires the password and a code.
+func (h *AdminAccountHandler) ResetTwoFactor(c *gin.Context) error {
+	id, err := adminIDParam(c)
+	if err != nil {
+		return err
+	}
+	if int64(id) == contextAdminID(c) {
+		return apierror.New(http.StatusBadRequest, "cannot reset your own two-factor authentication, use /2fa/disable")
+	}
+
+	admin, err := h.updateAdmin(c, id, "admin.reset_2fa", h.admins.ResetTOTP)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admin": admin})
+	return nil
+}
+
+// updateAdmin applies an update to the admin, records it in the audit log and returns the updated admin.
+func (h *AdminAccountHandler) updateAdmin(c *gin.Context, id uint64, action string, update func(ctx context.Context, adminID uint64) error) (*database.Admin, error) {
+	if err := update(c.Request.Context(), id); err != nil {
+		if errors.Is(err, services.ErrAdminNotFound) {
+			return nil, apierror.New(http.StatusNotFound, "admin not found")
+		}
+		if errors.Is(err, services.ErrRoleNotFound) {
+			return nil, apierror.New(http.StatusBadRequest, "role not found")
+		}
+		return nil, err // Internal server error
+	}
+
+	admin, err := h.admins.GetProfile(c.Request.Context(), id)
+	if err != nil {
+		return nil, err // Internal server error
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, action, strconv.FormatUint(admin.ID, 10), nil)
+	h.logger.Info("admin updated via admin API", "action", action, "admin", admin.Login, "updated_by", contextAdminID(c))
+	return admin, nil
+}
+
+// ListRoles returns all roles and the permissions that can be assigned to them.
+func (h *AdminAccountHandler) ListRoles(c *gin.Context) error {
+	roles, err := h.admins.ListRoles(c.Request.Context())
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"permissions": auth.Permissions,
+	})
+	return nil
+}
+
+// SaveRole creates a role or replaces its description and permissions. Sessions of admins
+// with the role are revoked. The built-in superadmin role can't be changed.
+func (h *AdminAccountHandler) SaveRole(c *gin.Context) error {
+	name := c.Param("name")
+	if !roleNamePattern.MatchString(name) {
+		return apierror.New(http.StatusBadRequest, "role name must be 1-32 lowercase letters, digits, - or _")
+	}
+
+	var req SaveRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	for _, permission := range req.Permissions {
+		if !auth.ValidPermission(permission) {
+			return apierror.New(http.StatusBadRequest, "unknown permission: "+permission)
+		}
+	}
+
+	role := &database.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: database.StringList(slices.Compact(slices.Sorted(slices.Values(req.Permissions)))),
+	}
+	if err := h.admins.SaveRole(c.Request.Context(), role); err != nil {
+		if errors.Is(err, services.ErrBuiltinRole) {
+			return apierror.New(http.StatusBadRequest, "built-in role can't be changed")
+		}
+		return err // Internal server error
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, "role.save", role.Name, map[string]any{"permissions": role.Permissions})
+	c.JSON(http.StatusOK, gin.H{"role": role})
+	return nil
+}
+
+// adminIDParam parses the :id path parameter.
+func adminIDParam(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, apierror.New(http.StatusBadRequest, "invalid admin id")
+	}
+	return id, nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/auth"
+	"go-bot/internal/services"
+	"go-bot/internal/services/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminAccountHandler_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name         string
+		handler      func(*AdminAccountHandler) func(*gin.Context) error
+		id           string
+		roleName     string // :name of a role
+		body         string
+		mockSetup    func(*mocks.AdminServiceInterface)
+		expectedCode int
+	}{
+		{
+			name:         "Create - Login Taken",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.CreateAdmin },
+			body:         `{"login": "operator", "password": "secure_password", "role": "support"}`,
+			expectedCode: http.StatusConflict,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("CreateAdmin", mock.Anything, "operator", "secure_password", "support").
+					Return(nil, services.ErrAdminExists).Once()
+			},
+		},
+		{
+			name:         "Create - Unknown Role",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.CreateAdmin },
+			body:         `{"login": "operator", "password": "secure_password", "role": "owner"}`,
+			expectedCode: http.StatusBadRequest,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("CreateAdmin", mock.Anything, "operator", "secure_password", "owner").
+					Return(nil, services.ErrRoleNotFound).Once()
+			},
+		},
+		{
+			name:         "Create - Short Password",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.CreateAdmin },
+			body:         `{"login": "operator", "password": "123", "role": "support"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Deactivate - Self",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.DeactivateAdmin },
+			id:           "1",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Set Role - Self",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.SetRole },
+			id:           "1",
+			body:         `{"role": "support"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Reset Password - Self",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.ResetPassword },
+			id:           "1",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Save Role - Unknown Permission",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.SaveRole },
+			roleName:     "contractors",
+			body:         `{"permissions": ["users:read", "clients:delete"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Save Role - Invalid Name",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.SaveRole },
+			roleName:     "Support Team",
+			body:         `{"permissions": ["users:read"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Save Role - Built-in",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.SaveRole },
+			roleName:     "superadmin",
+			body:         `{"permissions": ["users:read"]}`,
+			expectedCode: http.StatusBadRequest,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("SaveRole", mock.Anything, mock.AnythingOfType("*database.Role")).Return(services.ErrBuiltinRole).Once()
+			},
+		},
+		{
+			name:         "Activate - Invalid ID",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.ActivateAdmin },
+			id:           "abc",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unlock - Not Found",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.UnlockAdmin },
+			id:           "42",
+			expectedCode: http.StatusNotFound,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("UnlockAdmin", mock.Anything, uint64(42)).Return(services.ErrAdminNotFound).Once()
+			},
+		},
+		{
+			name:         "Reset 2FA - Self",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.ResetTwoFactor },
+			id:           "1",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Reset 2FA - Not Found",
+			handler:      func(h *AdminAccountHandler) func(*gin.Context) error { return h.ResetTwoFactor },
+			id:           "42",
+			expectedCode: http.StatusNotFound,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("ResetTOTP", mock.Anything, uint64(42)).Return(services.ErrAdminNotFound).Once()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := mocks.NewAdminServiceInterface(t)
+			if tc.mockSetup != nil {
+				tc.mockSetup(mockService)
+			}
+			h := NewAdminAccountHandler(mockService, nil, silentLogger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/admins", bytes.NewBufferString(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: tc.id}, {Key: "name", Value: tc.roleName}}
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{AdminID: 1, Login: "root"}))
+
+			apierror.ErrorWrapper(tc.handler(h))(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
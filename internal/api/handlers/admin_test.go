@@ -9,9 +9,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go-bot/internal/api/apierror"
+	"go-bot/internal/auth"
 	"go-bot/internal/database"
+	"go-bot/internal/services"
 	"go-bot/internal/services/mocks"
 
 	"github.com/gin-gonic/gin"
@@ -22,7 +25,6 @@ import (
 func TestAdminHandler_Login(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	jwtSecret := "test-secret-for-jwt-token-generation"
 
 	testCases := []struct {
 		name         string
@@ -46,11 +48,41 @@ func TestAdminHandler_Login(t *testing.T) {
 			payload: gin.H{"login": "admin", "password": "wrongpassword"},
 			mockSetup: func(mockService *mocks.AdminServiceInterface) {
 				mockService.On("Authenticate", mock.Anything, "admin", "wrongpassword").
-					Return(nil, errors.New("invalid credentials")).Once()
+					Return(nil, services.ErrInvalidCredentials).Once()
 			},
 			expectedCode: http.StatusUnauthorized,
 			checkToken:   false,
 		},
+		{
+			name:    "Locked Account",
+			payload: gin.H{"login": "admin", "password": "password"},
+			mockSetup: func(mockService *mocks.AdminServiceInterface) {
+				mockService.On("Authenticate", mock.Anything, "admin", "password").
+					Return(nil, services.ErrAccountLocked).Once()
+			},
+			expectedCode: http.StatusLocked,
+			checkToken:   false,
+		},
+		{
+			name:    "Inactive Account",
+			payload: gin.H{"login": "admin", "password": "password"},
+			mockSetup: func(mockService *mocks.AdminServiceInterface) {
+				mockService.On("Authenticate", mock.Anything, "admin", "password").
+					Return(nil, services.ErrAccountInactive).Once()
+			},
+			expectedCode: http.StatusForbidden,
+			checkToken:   false,
+		},
+		{
+			name:    "Database Error",
+			payload: gin.H{"login": "admin", "password": "password"},
+			mockSetup: func(mockService *mocks.AdminServiceInterface) {
+				mockService.On("Authenticate", mock.Anything, "admin", "password").
+					Return(nil, errors.New("invalid credentials")).Once()
+			},
+			expectedCode: http.StatusInternalServerError,
+			checkToken:   false,
+		},
 		{
 			name:    "Validation Error - Short Password",
 			payload: gin.H{"login": "admin", "password": "123"},
@@ -74,7 +106,18 @@ func TestAdminHandler_Login(t *testing.T) {
 			mockService := new(mocks.AdminServiceInterface)
 			tc.mockSetup(mockService)
 
-			h := NewAdminHandler(mockService, silentLogger, jwtSecret)
+			sessions := mocks.NewSessionServiceInterface(t)
+			if tc.checkToken {
+				sessions.On("Create", mock.Anything, mock.AnythingOfType("*database.Admin"), mock.Anything).
+					Return(&services.TokenPair{
+						AccessToken:      "access-token",
+						AccessExpiresIn:  15 * time.Minute,
+						RefreshToken:     "refresh-token",
+						RefreshExpiresAt: time.Now().Add(720 * time.Hour),
+					}, nil).Once()
+			}
+
+			h := NewAdminHandler(mockService, sessions, silentLogger)
 
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
@@ -95,13 +138,297 @@ func TestAdminHandler_Login(t *testing.T) {
 			assert.Equal(t, tc.expectedCode, w.Code)
 
 			if tc.checkToken {
-				var response map[string]string
+				var response TokenResponse
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.NotEmpty(t, response["token"])
+				assert.Equal(t, "access-token", response.Token)
+				assert.Equal(t, "refresh-token", response.RefreshToken)
+				assert.Equal(t, 900, response.ExpiresIn)
 			}
 
 			mockService.AssertExpectations(t)
 		})
 	}
 }
+
+func TestAdminHandler_LoginTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	admin := &database.Admin{ID: 7, Login: "admin", TOTPEnabled: true}
+	mockService := mocks.NewAdminServiceInterface(t)
+	mockService.On("Authenticate", mock.Anything, "admin", "password").Return(admin, nil).Once()
+	// No session is opened until the code is verified.
+	sessions := mocks.NewSessionServiceInterface(t)
+	sessions.On("Challenge", admin).Return("challenge-token", nil).Once()
+	h := NewAdminHandler(mockService, sessions, silentLogger)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"login": "admin", "password": "password"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	apierror.ErrorWrapper(h.Login)(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ChallengeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.TwoFactorRequired)
+	assert.Equal(t, "challenge-token", response.ChallengeToken)
+	assert.Equal(t, 300, response.ExpiresIn)
+	assert.NotContains(t, w.Body.String(), "refresh_token")
+}
+
+func TestAdminHandler_VerifyLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.AdminServiceInterface, *mocks.SessionServiceInterface)
+		expectedCode int
+	}{
+		{
+			name: "Success",
+			body: `{"challenge_token": "challenge-token", "code": "123456"}`,
+			mockSetup: func(m *mocks.AdminServiceInterface, s *mocks.SessionServiceInterface) {
+				s.On("VerifyChallenge", "challenge-token").Return(uint64(7), nil).Once()
+				m.On("VerifyTOTP", mock.Anything, uint64(7), "123456").
+					Return(&database.Admin{ID: 7, Login: "admin", TOTPEnabled: true}, nil).Once()
+				s.On("Create", mock.Anything, mock.AnythingOfType("*database.Admin"), mock.Anything).
+					Return(&services.TokenPair{AccessToken: "access-token", RefreshToken: "refresh-token"}, nil).Once()
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Expired Challenge",
+			body: `{"challenge_token": "challenge-token", "code": "123456"}`,
+			mockSetup: func(m *mocks.AdminServiceInterface, s *mocks.SessionServiceInterface) {
+				s.On("VerifyChallenge", "challenge-token").Return(uint64(0), services.ErrInvalidChallenge).Once()
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Invalid Code",
+			body: `{"challenge_token": "challenge-token", "code": "000000"}`,
+			mockSetup: func(m *mocks.AdminServiceInterface, s *mocks.SessionServiceInterface) {
+				s.On("VerifyChallenge", "challenge-token").Return(uint64(7), nil).Once()
+				m.On("VerifyTOTP", mock.Anything, uint64(7), "000000").Return(nil, services.ErrInvalidTOTPCode).Once()
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Locked Account",
+			body: `{"challenge_token": "challenge-token", "code": "000000"}`,
+			mockSetup: func(m *mocks.AdminServiceInterface, s *mocks.SessionServiceInterface) {
+				s.On("VerifyChallenge", "challenge-token").Return(uint64(7), nil).Once()
+				m.On("VerifyTOTP", mock.Anything, uint64(7), "000000").Return(nil, services.ErrAccountLocked).Once()
+			},
+			expectedCode: http.StatusLocked,
+		},
+		{
+			name:         "Missing Code",
+			body:         `{"challenge_token": "challenge-token"}`,
+			mockSetup:    func(*mocks.AdminServiceInterface, *mocks.SessionServiceInterface) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := mocks.NewAdminServiceInterface(t)
+			sessions := mocks.NewSessionServiceInterface(t)
+			tc.mockSetup(mockService, sessions)
+			h := NewAdminHandler(mockService, sessions, silentLogger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/login/verify", bytes.NewBufferString(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			apierror.ErrorWrapper(h.VerifyLogin)(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_GetProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		h := NewAdminHandler(mocks.NewAdminServiceInterface(t), mocks.NewSessionServiceInterface(t), silentLogger)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/profile", nil)
+
+		apierror.ErrorWrapper(h.GetProfile)(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		mockService := mocks.NewAdminServiceInterface(t)
+		mockService.On("GetProfile", mock.Anything, uint64(7)).
+			Return(&database.Admin{ID: 7, Login: "admin", IsActive: true}, nil).Once()
+		h := NewAdminHandler(mockService, mocks.NewSessionServiceInterface(t), silentLogger)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/profile", nil)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{AdminID: 7, Login: "admin"}))
+
+		apierror.ErrorWrapper(h.GetProfile)(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "admin", response["login"])
+	})
+}
+
+func TestAdminHandler_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name         string
+		serviceErr   error
+		expectedCode int
+	}{
+		{name: "Success", expectedCode: http.StatusNoContent},
+		{name: "Incorrect Old Password", serviceErr: services.ErrIncorrectPassword, expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := mocks.NewAdminServiceInterface(t)
+			mockService.On("ChangePassword", mock.Anything, uint64(7), "old_password", "new_password").
+				Return(tc.serviceErr).Once()
+			h := NewAdminHandler(mockService, mocks.NewSessionServiceInterface(t), silentLogger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body := bytes.NewBufferString(`{"old_password": "old_password", "new_password": "new_password"}`)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/change-password", body)
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{AdminID: 7, Login: "admin"}))
+
+			apierror.ErrorWrapper(h.ChangePassword)(c)
+
+			// c.Status doesn't write the header to the recorder, so the status is taken from the writer.
+			assert.Equal(t, tc.expectedCode, c.Writer.Status())
+		})
+	}
+}
+
+func TestAdminHandler_Refresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.SessionServiceInterface)
+		expectedCode int
+	}{
+		{
+			name: "Success",
+			body: `{"refresh_token": "refresh-token"}`,
+			mockSetup: func(m *mocks.SessionServiceInterface) {
+				m.On("Refresh", mock.Anything, "refresh-token", mock.Anything).
+					Return(&services.TokenPair{AccessToken: "new-access", RefreshToken: "new-refresh"}, nil).Once()
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Rotated Token",
+			body: `{"refresh_token": "refresh-token"}`,
+			mockSetup: func(m *mocks.SessionServiceInterface) {
+				m.On("Refresh", mock.Anything, "refresh-token", mock.Anything).
+					Return(nil, services.ErrInvalidRefreshToken).Once()
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Inactive Account",
+			body: `{"refresh_token": "refresh-token"}`,
+			mockSetup: func(m *mocks.SessionServiceInterface) {
+				m.On("Refresh", mock.Anything, "refresh-token", mock.Anything).
+					Return(nil, services.ErrAccountInactive).Once()
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Missing Token",
+			body:         `{}`,
+			mockSetup:    func(m *mocks.SessionServiceInterface) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions := mocks.NewSessionServiceInterface(t)
+			tc.mockSetup(sessions)
+			h := NewAdminHandler(mocks.NewAdminServiceInterface(t), sessions, silentLogger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			apierror.ErrorWrapper(h.Refresh)(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name      string
+		body      string
+		mockSetup func(*mocks.SessionServiceInterface)
+	}{
+		{
+			name: "Current Session",
+			mockSetup: func(m *mocks.SessionServiceInterface) {
+				m.On("Revoke", mock.Anything, "token-id").Return(nil).Once()
+			},
+		},
+		{
+			name: "All Sessions",
+			body: `{"all": true}`,
+			mockSetup: func(m *mocks.SessionServiceInterface) {
+				m.On("RevokeAll", mock.Anything, uint64(7)).Return(nil).Once()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions := mocks.NewSessionServiceInterface(t)
+			tc.mockSetup(sessions)
+			h := NewAdminHandler(mocks.NewAdminServiceInterface(t), sessions, silentLogger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/logout", bytes.NewBufferString(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(),
+				&auth.Principal{AdminID: 7, Login: "admin", TokenID: "token-id"}))
+
+			apierror.ErrorWrapper(h.Logout)(c)
+
+			// c.Status doesn't write the header to the recorder, so the status is taken from the writer.
+			assert.Equal(t, http.StatusNoContent, c.Writer.Status())
+		})
+	}
+}
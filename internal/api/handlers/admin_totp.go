@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/services"
+
+	"github.com/gin-gonic/gin"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// totpQRCodeSize is the side of the enrollment QR code in pixels.
+const totpQRCodeSize = 256
+
+// TwoFactorCodeRequest represents the request body for confirming two-factor authentication.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// TwoFactorChangeRequest represents the request body for disabling two-factor authentication
+// or regenerating recovery codes.
+type TwoFactorChangeRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"` // TOTP or recovery code
+}
+
+// SetupTwoFactor generates a TOTP secret for the current admin and returns it with the
+// otpauth:// URI and its QR code for an authenticator app. The secret takes effect after
+// EnableTwoFactor.
+func (h *AdminHandler) SetupTwoFactor(c *gin.Context) error {
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	setup, err := h.adminService.SetupTOTP(c.Request.Context(), principal.AdminID)
+	if err != nil {
+		return twoFactorError(err)
+	}
+
+	png, err := qrcode.Encode(setup.URI, qrcode.Medium, totpQRCodeSize)
+	if err != nil {
+		return fmt.Errorf("could not encode qr code: %w", err) // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":  setup.Secret,
+		"uri":     setup.URI,
+		"qr_code": "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+	return nil
+}
+
+// EnableTwoFactor turns on two-factor authentication for the current admin after checking a code
+// from the authenticator app. Recovery codes are returned once.
+func (h *AdminHandler) EnableTwoFactor(c *gin.Context) error {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	recoveryCodes, err := h.adminService.EnableTOTP(c.Request.Context(), principal.AdminID, req.Code)
+	if err != nil {
+		return twoFactorError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+	return nil
+}
+
+// DisableTwoFactor turns off two-factor authentication for the current admin.
+func (h *AdminHandler) DisableTwoFactor(c *gin.Context) error {
+	req, principalID, err := bindTwoFactorChange(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.DisableTOTP(c.Request.Context(), principalID, req.Password, req.Code); err != nil {
+		return twoFactorError(err)
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current admin. The new codes
+// are returned once.
+func (h *AdminHandler) RegenerateRecoveryCodes(c *gin.Context) error {
+	req, principalID, err := bindTwoFactorChange(c)
+	if err != nil {
+		return err
+	}
+
+	recoveryCodes, err := h.adminService.RegenerateRecoveryCodes(c.Request.Context(), principalID, req.Password, req.Code)
+	if err != nil {
+		return twoFactorError(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+	return nil
+}
+
+// bindTwoFactorChange binds and validates TwoFactorChangeRequest and returns the ID of the current admin.
+func bindTwoFactorChange(c *gin.Context) (*TwoFactorChangeRequest, uint64, error) {
+	var req TwoFactorChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, 0, apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return nil, 0, apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &req, principal.AdminID, nil
+}
+
+// twoFactorError maps the errors of the two-factor settings to API errors.
+func twoFactorError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrTOTPAlreadyEnabled):
+		return apierror.New(http.StatusConflict, "two-factor authentication is already enabled")
+	case errors.Is(err, services.ErrTOTPNotEnabled):
+		return apierror.New(http.StatusConflict, "two-factor authentication is not enabled")
+	case errors.Is(err, services.ErrTOTPNotSetUp):
+		return apierror.New(http.StatusConflict, "two-factor authentication is not set up")
+	case errors.Is(err, services.ErrInvalidTOTPCode):
+		return apierror.New(http.StatusUnauthorized, "invalid two-factor code")
+	case errors.Is(err, services.ErrIncorrectPassword):
+		return apierror.New(http.StatusUnauthorized, "incorrect password")
+	case errors.Is(err, services.ErrAccountLocked):
+		return apierror.New(http.StatusLocked, "account is locked, try again later")
+	case errors.Is(err, services.ErrAdminNotFound):
+		return apierror.New(http.StatusNotFound, "admin not found")
+	}
+	return err // Internal server error
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/auth"
+	"go-bot/internal/services"
+	"go-bot/internal/services/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler_SetupTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	uri := auth.TOTPURI("admin", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	mockService := mocks.NewAdminServiceInterface(t)
+	mockService.On("SetupTOTP", mock.Anything, uint64(7)).
+		Return(&services.TOTPSetup{Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", URI: uri}, nil).Once()
+	h := NewAdminHandler(mockService, mocks.NewSessionServiceInterface(t), silentLogger)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/2fa/setup", nil)
+	c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{AdminID: 7, Login: "admin"}))
+
+	apierror.ErrorWrapper(h.SetupTwoFactor)(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, uri, response["uri"])
+
+	qrCode, ok := strings.CutPrefix(response["qr_code"], "data:image/png;base64,")
+	require.True(t, ok)
+	data, err := base64.StdEncoding.DecodeString(qrCode)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, totpQRCodeSize, img.Bounds().Dx())
+}
+
+func TestAdminHandler_TwoFactorErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name         string
+		handler      func(*AdminHandler) func(*gin.Context) error
+		body         string
+		mockSetup    func(*mocks.AdminServiceInterface)
+		expectedCode int
+	}{
+		{
+			name:         "Setup - Already Enabled",
+			handler:      func(h *AdminHandler) func(*gin.Context) error { return h.SetupTwoFactor },
+			expectedCode: http.StatusConflict,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("SetupTOTP", mock.Anything, uint64(7)).Return(nil, services.ErrTOTPAlreadyEnabled).Once()
+			},
+		},
+		{
+			name:         "Enable - Not Set Up",
+			handler:      func(h *AdminHandler) func(*gin.Context) error { return h.EnableTwoFactor },
+			body:         `{"code": "123456"}`,
+			expectedCode: http.StatusConflict,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("EnableTOTP", mock.Anything, uint64(7), "123456").Return(nil, services.ErrTOTPNotSetUp).Once()
+			},
+		},
+		{
+			name:         "Enable - Invalid Code",
+			handler:      func(h *AdminHandler) func(*gin.Context) error { return h.EnableTwoFactor },
+			body:         `{"code": "000000"}`,
+			expectedCode: http.StatusUnauthorized,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("EnableTOTP", mock.Anything, uint64(7), "000000").Return(nil, services.ErrInvalidTOTPCode).Once()
+			},
+		},
+		{
+			name:         "Enable - Missing Code",
+			handler:      func(h *AdminHandler) func(*gin.Context) error { return h.EnableTwoFactor },
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Disable - Incorrect Password",
+			handler:      func(h *AdminHandler) func(*gin.Context) error { return h.DisableTwoFactor },
+			body:         `{"password": "wrong_password", "code": "123456"}`,
+			expectedCode: http.StatusUnauthorized,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("DisableTOTP", mock.Anything, uint64(7), "wrong_password", "123456").
+					Return(services.ErrIncorrectPassword).Once()
+			},
+		},
+		{
+			name:         "Disable - Locked After Failed Attempts",
+			handler:      func(h *AdminHandler) func(*gin.Context) error { return h.DisableTwoFactor },
+			body:         `{"password": "password", "code": "123456"}`,
+			expectedCode: http.StatusLocked,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("DisableTOTP", mock.Anything, uint64(7), "password", "123456").
+					Return(services.ErrAccountLocked).Once()
+			},
+		},
+		{
+			name:         "Recovery Codes - Not Enabled",
+			handler:      func(h *AdminHandler) func(*gin.Context) error { return h.RegenerateRecoveryCodes },
+			body:         `{"password": "password", "code": "123456"}`,
+			expectedCode: http.StatusConflict,
+			mockSetup: func(m *mocks.AdminServiceInterface) {
+				m.On("RegenerateRecoveryCodes", mock.Anything, uint64(7), "password", "123456").
+					Return(nil, services.ErrTOTPNotEnabled).Once()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := mocks.NewAdminServiceInterface(t)
+			if tc.mockSetup != nil {
+				tc.mockSetup(mockService)
+			}
+			h := NewAdminHandler(mockService, mocks.NewSessionServiceInterface(t), silentLogger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/2fa", bytes.NewBufferString(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{AdminID: 7, Login: "admin"}))
+
+			apierror.ErrorWrapper(tc.handler(h))(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/auth"
+	"go-bot/internal/service"
+	"go-bot/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAPIKeyLifetime limits expires_at of new API keys, so that forgotten keys stop working eventually.
+const maxAPIKeyLifetime = 365 * 24 * time.Hour
+
+// APIKeyHandler handles endpoints for the API keys of the current admin.
+type APIKeyHandler struct {
+	apiKeys services.APIKeyServiceInterface
+	audit   *service.AuditService
+	logger  *slog.Logger
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(apiKeys services.APIKeyServiceInterface, audit *service.AuditService, logger *slog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeys: apiKeys,
+		audit:   audit,
+		logger:  logger,
+	}
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key.
+type CreateAPIKeyRequest struct {
+	Name        string    `json:"name" validate:"required,max=64"`
+	Permissions []string  `json:"permissions" validate:"required,min=1,dive,required"`
+	ExpiresAt   time.Time `json:"expires_at" validate:"required"`
+}
+
+// ListAPIKeys returns the API keys of the current admin without their secrets.
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) error {
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	keys, err := h.apiKeys.List(c.Request.Context(), principal.AdminID)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+	return nil
+}
+
+// CreateAPIKey creates an API key of the current admin. The key can be given only permissions
+// the admin has. It is returned in the response once and can't be retrieved again.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) error {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range req.Permissions {
+		if !auth.ValidPermission(permission) {
+			return apierror.New(http.StatusBadRequest, "unknown permission: "+permission)
+		}
+		if !principal.Can(permission) {
+			return apierror.New(http.StatusForbidden, "cannot grant a permission you don't have: "+permission)
+		}
+	}
+
+	now := time.Now()
+	if !req.ExpiresAt.After(now) {
+		return apierror.New(http.StatusBadRequest, "expires_at must be in the future")
+	}
+	if req.ExpiresAt.After(now.Add(maxAPIKeyLifetime)) {
+		return apierror.New(http.StatusBadRequest, "expires_at must be within 365 days")
+	}
+
+	permissions := slices.Compact(slices.Sorted(slices.Values(req.Permissions)))
+	issued, err := h.apiKeys.Create(c.Request.Context(), principal.AdminID, req.Name, permissions, req.ExpiresAt)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, "api_key.create", issued.Prefix,
+		map[string]any{"name": issued.Name, "permissions": issued.Permissions, "expires_at": issued.ExpiresAt})
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": issued.APIKey,
+		"key":     issued.Key,
+	})
+	return nil
+}
+
+// RevokeAPIKey revokes an API key of the current admin.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return apierror.New(http.StatusBadRequest, "invalid api key id")
+	}
+
+	principal, err := currentAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.apiKeys.Revoke(c.Request.Context(), principal.AdminID, id); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			return apierror.New(http.StatusNotFound, "api key not found")
+		}
+		return err // Internal server error
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, "api_key.revoke", strconv.FormatUint(id, 10), nil)
+	c.Status(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+	"go-bot/internal/services"
+	"go-bot/internal/services/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyHandler_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	expiresAt := func(d time.Duration) string {
+		return time.Now().Add(d).UTC().Format(time.RFC3339)
+	}
+	createBody := func(permissions []string, expires string) string {
+		body, _ := json.Marshal(gin.H{"name": "billing", "permissions": permissions, "expires_at": expires})
+		return string(body)
+	}
+
+	testCases := []struct {
+		name         string
+		handler      func(*APIKeyHandler) func(*gin.Context) error
+		id           string
+		body         string
+		mockSetup    func(*mocks.APIKeyServiceInterface)
+		expectedCode int
+	}{
+		{
+			name:         "Create - Unknown Permission",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.CreateAPIKey },
+			body:         createBody([]string{"users:delete"}, expiresAt(24*time.Hour)),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Create - Permission Not Held",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.CreateAPIKey },
+			body:         createBody([]string{auth.PermUsersRead, auth.PermAdminsManage}, expiresAt(24*time.Hour)),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Create - No Permissions",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.CreateAPIKey },
+			body:         createBody([]string{}, expiresAt(24*time.Hour)),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Create - Expired",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.CreateAPIKey },
+			body:         createBody([]string{auth.PermUsersRead}, expiresAt(-time.Hour)),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Create - Too Long",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.CreateAPIKey },
+			body:         createBody([]string{auth.PermUsersRead}, expiresAt(400*24*time.Hour)),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Revoke - Invalid ID",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.RevokeAPIKey },
+			id:           "abc",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Revoke - Not Found",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.RevokeAPIKey },
+			id:           "42",
+			expectedCode: http.StatusNotFound,
+			mockSetup: func(m *mocks.APIKeyServiceInterface) {
+				m.On("Revoke", mock.Anything, uint64(7), uint64(42)).Return(services.ErrAPIKeyNotFound).Once()
+			},
+		},
+		{
+			name:         "List",
+			handler:      func(h *APIKeyHandler) func(*gin.Context) error { return h.ListAPIKeys },
+			expectedCode: http.StatusOK,
+			mockSetup: func(m *mocks.APIKeyServiceInterface) {
+				m.On("List", mock.Anything, uint64(7)).
+					Return([]database.APIKey{{ID: 1, Name: "billing", Prefix: "abcdefgh", KeyHash: "secret-hash"}}, nil).Once()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiKeys := mocks.NewAPIKeyServiceInterface(t)
+			if tc.mockSetup != nil {
+				tc.mockSetup(apiKeys)
+			}
+			h := NewAPIKeyHandler(apiKeys, nil, silentLogger)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api-keys", bytes.NewBufferString(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(),
+				&auth.Principal{AdminID: 7, Login: "admin", Permissions: []string{auth.PermUsersRead}}))
+			if tc.id != "" {
+				c.Params = gin.Params{{Key: "id", Value: tc.id}}
+			}
+
+			apierror.ErrorWrapper(tc.handler(h))(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.NotContains(t, w.Body.String(), "secret-hash")
+		})
+	}
+}
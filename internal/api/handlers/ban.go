@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BanHandler handles admin endpoints for banned Telegram users and chats.
+type BanHandler struct {
+	bans   *service.BanService
+	audit  *service.AuditService
+	logger *slog.Logger
+}
+
+// NewBanHandler creates a new BanHandler.
+func NewBanHandler(bans *service.BanService, audit *service.AuditService, logger *slog.Logger) *BanHandler {
+	return &BanHandler{
+		bans:   bans,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// ListBansQuery represents the query parameters for listing bans.
+type ListBansQuery struct {
+	IncludeExpired bool `form:"include_expired"`
+	Limit          int  `form:"limit" validate:"gte=0,lte=200"` // 0 means defaultPageSize
+	Offset         int  `form:"offset" validate:"gte=0"`
+}
+
+// BanRequest represents the request body for banning a user or chat.
+type BanRequest struct {
+	TelegramID int64      `json:"telegram_id" validate:"required"`
+	Reason     string     `json:"reason" validate:"max=1000"`
+	ExpiresAt  *time.Time `json:"expires_at"` // omitted means a permanent ban
+}
+
+// ListBans returns bans, newest first. Expired bans are skipped unless include_expired is set.
+func (h *BanHandler) ListBans(c *gin.Context) error {
+	var query ListBansQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid query: "+err.Error())
+	}
+
+	if err := validate.Struct(query); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+
+	bans, total, err := h.bans.List(c.Request.Context(), !query.IncludeExpired, query.Limit, query.Offset)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans":   bans,
+		"total":  total,
+		"limit":  query.Limit,
+		"offset": query.Offset,
+	})
+	return nil
+}
+
+// CreateBan bans a Telegram user or chat. Banning an already banned ID replaces the ban.
+func (h *BanHandler) CreateBan(c *gin.Context) error {
+	var req BanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return apierror.New(http.StatusBadRequest, "expires_at must be in the future")
+	}
+
+	adminID := contextAdminID(c)
+	ban, err := h.bans.Ban(c.Request.Context(), database.Ban{
+		TelegramID:   req.TelegramID,
+		Reason:       req.Reason,
+		ExpiresAt:    req.ExpiresAt,
+		BannedByType: database.AuditActorAdmin,
+		BannedBy:     adminID,
+	})
+	if err != nil {
+		return err // Internal server error
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, "user.ban", strconv.FormatInt(ban.TelegramID, 10),
+		map[string]any{"reason": ban.Reason, "expires_at": ban.ExpiresAt})
+	h.logger.Info("telegram id banned via admin API", "telegram_id", ban.TelegramID, "admin_id", adminID)
+	c.JSON(http.StatusOK, ban)
+	return nil
+}
+
+// DeleteBan lifts the ban of a Telegram user or chat.
+func (h *BanHandler) DeleteBan(c *gin.Context) error {
+	telegramID, err := strconv.ParseInt(c.Param("telegram_id"), 10, 64)
+	if err != nil || telegramID == 0 {
+		return apierror.New(http.StatusBadRequest, "invalid telegram id")
+	}
+
+	if err := h.bans.Unban(c.Request.Context(), telegramID); err != nil {
+		if errors.Is(err, service.ErrBanNotFound) {
+			return apierror.New(http.StatusNotFound, "ban not found")
+		}
+		return err // Internal server error
+	}
+
+	adminID := contextAdminID(c)
+	recordAdminAudit(c, h.audit, h.logger, "user.unban", strconv.FormatInt(telegramID, 10), nil)
+	h.logger.Info("telegram id unbanned via admin API", "telegram_id", telegramID, "admin_id", adminID)
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// recordAdminAudit records an action of the authenticated admin. A failure to write the audit
+// record is logged instead of failing the request, since the action itself is already applied.
+func recordAdminAudit(c *gin.Context, audit *service.AuditService, logger *slog.Logger, action, target string, details any) {
+	if err := audit.Record(c.Request.Context(), database.AuditActorAdmin, contextAdminID(c), action, target, details); err != nil {
+		logger.Error("failed to record audit", "error", err, "action", action)
+	}
+}
+
+// contextAdminID returns the ID of the authenticated admin set by AuthMiddleware, or 0.
+func contextAdminID(c *gin.Context) int64 {
+	principal, ok := auth.PrincipalFromContext(c.Request.Context())
+	if !ok {
+		return 0
+	}
+	return int64(principal.AdminID)
+}
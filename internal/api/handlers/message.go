@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MessageSender sends messages through the bot.
+type MessageSender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// MessageHandler handles admin endpoints for the conversation history with users.
+type MessageHandler struct {
+	messages *service.MessageService
+	users    *service.UserService
+	sender   MessageSender
+	audit    *service.AuditService
+	logger   *slog.Logger
+}
+
+// NewMessageHandler creates a new MessageHandler.
+func NewMessageHandler(messages *service.MessageService, users *service.UserService, sender MessageSender,
+	audit *service.AuditService, logger *slog.Logger) *MessageHandler {
+	return &MessageHandler{
+		messages: messages,
+		users:    users,
+		sender:   sender,
+		audit:    audit,
+		logger:   logger,
+	}
+}
+
+// ListMessagesQuery represents the query parameters for listing messages.
+type ListMessagesQuery struct {
+	Query     string     `form:"q" validate:"max=255"`    // full-text search
+	Types     string     `form:"type" validate:"max=255"` // comma-separated, e.g. text,photo,command
+	Direction string     `form:"direction" validate:"omitempty,oneof=in out"`
+	From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor    string     `form:"cursor" validate:"max=32"`
+	Limit     int        `form:"limit" validate:"gte=0,lte=200"` // 0 means defaultPageSize
+}
+
+// SendMessageRequest represents the request body for sending a message to a user.
+type SendMessageRequest struct {
+	Text string `json:"text" validate:"required,max=4096"`
+}
+
+// ListMessages returns messages of all users, newest first, with their senders.
+func (h *MessageHandler) ListMessages(c *gin.Context) error {
+	return h.listMessages(c, 0)
+}
+
+// ListUserMessages returns the conversation with a user, newest first.
+func (h *MessageHandler) ListUserMessages(c *gin.Context) error {
+	user, err := findUser(c, h.users)
+	if err != nil {
+		return err
+	}
+	return h.listMessages(c, user.ID)
+}
+
+// SendMessage sends a text message to a user through the bot and records it as outgoing.
+func (h *MessageHandler) SendMessage(c *gin.Context) error {
+	user, err := findUser(c, h.users)
+	if err != nil {
+		return err
+	}
+
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return apierror.New(http.StatusBadRequest, "text must not be empty")
+	}
+
+	sent, err := h.sender.Send(tgbotapi.NewMessage(user.TelegramID, req.Text))
+	if err != nil {
+		h.logger.Warn("failed to send message to user", "error", err, "telegram_id", user.TelegramID)
+		return apierror.New(http.StatusBadGateway, "failed to deliver message: "+err.Error())
+	}
+
+	adminID := uint64(contextAdminID(c))
+	message := &database.Message{
+		UserID:            user.ID,
+		ChatID:            user.TelegramID,
+		TelegramMessageID: sent.MessageID,
+		MessageText:       req.Text,
+		MessageType:       "text",
+		AdminID:           &adminID,
+	}
+	if err := h.messages.SaveOutgoing(c.Request.Context(), message); err != nil {
+		// The message is already delivered, so the request succeeds.
+		h.logger.Error("failed to save outgoing message", "error", err, "telegram_id", user.TelegramID)
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, "user.message", strconv.FormatInt(user.TelegramID, 10),
+		map[string]any{"telegram_message_id": sent.MessageID})
+	c.JSON(http.StatusCreated, message)
+	return nil
+}
+
+// listMessages lists messages of a user, or of all users when userID is 0.
+func (h *MessageHandler) listMessages(c *gin.Context, userID uint64) error {
+	var query ListMessagesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid query: "+err.Error())
+	}
+
+	if err := validate.Struct(query); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+
+	var types []string
+	for _, messageType := range strings.Split(query.Types, ",") {
+		if messageType = strings.TrimSpace(messageType); messageType != "" {
+			types = append(types, messageType)
+		}
+	}
+
+	page, err := h.messages.List(c.Request.Context(), service.MessageFilter{
+		UserID:    userID,
+		Query:     query.Query,
+		Types:     types,
+		Direction: query.Direction,
+		From:      query.From,
+		To:        query.To,
+		Cursor:    query.Cursor,
+		Limit:     query.Limit,
+		WithUser:  userID == 0,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return apierror.New(http.StatusBadRequest, "invalid cursor")
+		}
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, page)
+	return nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentHandler handles admin endpoints for subscription payments.
+type PaymentHandler struct {
+	payments *service.PaymentService
+	audit    *service.AuditService
+	logger   *slog.Logger
+}
+
+// NewPaymentHandler creates a new PaymentHandler.
+func NewPaymentHandler(payments *service.PaymentService, audit *service.AuditService, logger *slog.Logger) *PaymentHandler {
+	return &PaymentHandler{
+		payments: payments,
+		audit:    audit,
+		logger:   logger,
+	}
+}
+
+// ListPaymentsQuery represents the query parameters for listing payments.
+type ListPaymentsQuery struct {
+	Status     string `form:"status" validate:"omitempty,oneof=pending applied failed"`
+	TelegramID int64  `form:"telegram_id" validate:"gte=0"`
+	Limit      int    `form:"limit" validate:"gte=0,lte=200"` // 0 means defaultPageSize
+	Offset     int    `form:"offset" validate:"gte=0"`
+}
+
+// ListPayments returns payments, newest first.
+func (h *PaymentHandler) ListPayments(c *gin.Context) error {
+	var query ListPaymentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid query: "+err.Error())
+	}
+
+	if err := validate.Struct(query); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+
+	payments, total, err := h.payments.List(c.Request.Context(), service.PaymentFilter{
+		Status:     query.Status,
+		TelegramID: query.TelegramID,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+	})
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payments": payments,
+		"total":    total,
+		"limit":    query.Limit,
+		"offset":   query.Offset,
+	})
+	return nil
+}
+
+// ApplyPayment extends the client of a pending or failed payment. The payment is returned
+// with the outcome: applied, or pending or failed with the error.
+func (h *PaymentHandler) ApplyPayment(c *gin.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return apierror.New(http.StatusBadRequest, "invalid payment id")
+	}
+
+	payment, err := h.payments.ApplyPayment(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentNotFound):
+			return apierror.New(http.StatusNotFound, "payment not found")
+		case errors.Is(err, service.ErrPaymentApplied):
+			return apierror.New(http.StatusConflict, "payment already applied")
+		}
+		return err // Internal server error
+	}
+
+	recordAdminAudit(c, h.audit, h.logger, "payment.apply", strconv.FormatUint(payment.ID, 10),
+		map[string]any{"status": payment.Status, "error": payment.Error})
+	h.logger.Info("payment applied via admin API", "payment_id", payment.ID, "status", payment.Status, "admin_id", contextAdminID(c))
+
+	c.JSON(http.StatusOK, payment)
+	return nil
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralHandler handles admin endpoints of the referral program.
+type ReferralHandler struct {
+	referrals *service.ReferralService
+	logger    *slog.Logger
+}
+
+// NewReferralHandler creates a new ReferralHandler.
+func NewReferralHandler(referrals *service.ReferralService, logger *slog.Logger) *ReferralHandler {
+	return &ReferralHandler{
+		referrals: referrals,
+		logger:    logger,
+	}
+}
+
+// ReferralSettingsRequest represents the request body for updating referral rewards.
+type ReferralSettingsRequest struct {
+	Enabled         bool `json:"enabled"`
+	RewardDays      int  `json:"reward_days" validate:"gte=0,lte=3650"`
+	RewardTrafficGB int  `json:"reward_traffic_gb" validate:"gte=0,lte=100000"`
+	RewardOnTrial   bool `json:"reward_on_trial"`
+	RewardOnPayment bool `json:"reward_on_payment"`
+}
+
+// GetSettings returns the referral reward settings.
+func (h *ReferralHandler) GetSettings(c *gin.Context) error {
+	settings, err := h.referrals.Settings(c.Request.Context())
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, settings)
+	return nil
+}
+
+// UpdateSettings replaces the referral reward settings.
+func (h *ReferralHandler) UpdateSettings(c *gin.Context) error {
+	var req ReferralSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+
+	settings, err := h.referrals.UpdateSettings(c.Request.Context(), database.ReferralSettings{
+		Enabled:         req.Enabled,
+		RewardDays:      req.RewardDays,
+		RewardTrafficGB: req.RewardTrafficGB,
+		RewardOnTrial:   req.RewardOnTrial,
+		RewardOnPayment: req.RewardOnPayment,
+	})
+	if err != nil {
+		return err // Internal server error
+	}
+
+	h.logger.Info("referral settings updated", "settings", settings)
+	c.JSON(http.StatusOK, settings)
+	return nil
+}
+
+// GetTree returns the referral tree, optionally starting from the user given in the "root" query parameter.
+func (h *ReferralHandler) GetTree(c *gin.Context) error {
+	var rootID int64
+	if root := c.Query("root"); root != "" {
+		id, err := strconv.ParseInt(root, 10, 64)
+		if err != nil || id <= 0 {
+			return apierror.New(http.StatusBadRequest, "invalid root: expected a Telegram user ID")
+		}
+		rootID = id
+	}
+
+	tree, err := h.referrals.Tree(c.Request.Context(), rootID)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tree": tree})
+	return nil
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPageSize is the page size of list endpoints when no limit is given.
+const defaultPageSize = 50
+
+// TicketHandler handles admin endpoints for support tickets.
+type TicketHandler struct {
+	support *service.SupportService
+	logger  *slog.Logger
+}
+
+// NewTicketHandler creates a new TicketHandler.
+func NewTicketHandler(support *service.SupportService, logger *slog.Logger) *TicketHandler {
+	return &TicketHandler{
+		support: support,
+		logger:  logger,
+	}
+}
+
+// ListTicketsQuery represents the query parameters for listing tickets.
+type ListTicketsQuery struct {
+	Status     string `form:"status" validate:"omitempty,oneof=open closed"`
+	TelegramID int64  `form:"telegram_id" validate:"gte=0"`
+	Limit      int    `form:"limit" validate:"gte=0,lte=200"` // 0 means defaultPageSize
+	Offset     int    `form:"offset" validate:"gte=0"`
+}
+
+// ListTickets returns support tickets, most recently updated first.
+func (h *TicketHandler) ListTickets(c *gin.Context) error {
+	var query ListTicketsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid query: "+err.Error())
+	}
+
+	if err := validate.Struct(query); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+
+	tickets, total, err := h.support.List(c.Request.Context(), service.TicketFilter{
+		Status:     query.Status,
+		TelegramID: query.TelegramID,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+	})
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tickets": tickets,
+		"total":   total,
+		"limit":   query.Limit,
+		"offset":  query.Offset,
+	})
+	return nil
+}
+
+// GetTicket returns a ticket with its messages.
+func (h *TicketHandler) GetTicket(c *gin.Context) error {
+	id, err := ticketID(c)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := h.support.Get(c.Request.Context(), id, true)
+	if err != nil {
+		if errors.Is(err, service.ErrTicketNotFound) {
+			return apierror.New(http.StatusNotFound, "ticket not found")
+		}
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, ticket)
+	return nil
+}
+
+// CloseTicket closes a ticket.
+func (h *TicketHandler) CloseTicket(c *gin.Context) error {
+	id, err := ticketID(c)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := h.support.Close(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrTicketNotFound) {
+			return apierror.New(http.StatusNotFound, "ticket not found")
+		}
+		return err // Internal server error
+	}
+
+	h.logger.Info("ticket closed via admin API", "ticket_id", ticket.ID)
+	c.JSON(http.StatusOK, ticket)
+	return nil
+}
+
+// ticketID parses the ticket ID from the path.
+func ticketID(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, apierror.New(http.StatusBadRequest, "invalid ticket id")
+	}
+	return id, nil
+}
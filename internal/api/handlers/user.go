@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+	"go-bot/internal/xui"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientTrafficGetter returns the traffic of 3x-ui clients by email.
+type ClientTrafficGetter interface {
+	GetClientTraffics(ctx context.Context, email string) ([]xui.ClientTraffic, error)
+}
+
+// UserHandler handles admin endpoints for Telegram users of the bot.
+type UserHandler struct {
+	users   *service.UserService
+	links   *service.ClientLinkService
+	clients ClientTrafficGetter
+	bans    *service.BanService
+	audit   *service.AuditService
+	logger  *slog.Logger
+}
+
+// NewUserHandler creates a new UserHandler.
+func NewUserHandler(users *service.UserService, links *service.ClientLinkService, clients ClientTrafficGetter,
+	bans *service.BanService, audit *service.AuditService, logger *slog.Logger) *UserHandler {
+	return &UserHandler{
+		users:   users,
+		links:   links,
+		clients: clients,
+		bans:    bans,
+		audit:   audit,
+		logger:  logger,
+	}
+}
+
+// ListUsersQuery represents the query parameters for listing users.
+type ListUsersQuery struct {
+	Query       string     `form:"q" validate:"max=255"` // username, name or Telegram ID
+	Active      *bool      `form:"active"`               // false - users who blocked the bot
+	Linked      *bool      `form:"linked"`               // has linked 3x-ui clients
+	Tag         string     `form:"tag" validate:"max=32"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Sort        string     `form:"sort" validate:"omitempty,oneof=created_at -created_at updated_at -updated_at telegram_id -telegram_id username -username"`
+	Limit       int        `form:"limit" validate:"gte=0,lte=200"` // 0 means defaultPageSize
+	Offset      int        `form:"offset" validate:"gte=0"`
+}
+
+// UpdateUserRequest represents the request body for updating a user. Omitted fields are left unchanged.
+type UpdateUserRequest struct {
+	Notes  *string   `json:"notes" validate:"omitempty,max=4000"`
+	Tags   *[]string `json:"tags" validate:"omitempty,max=20,dive,max=32"`
+	Banned *bool     `json:"banned"`
+	// BanReason and BanExpiresAt are used when banned is true; omitted expires_at means a permanent ban.
+	BanReason    string     `json:"ban_reason" validate:"max=1000"`
+	BanExpiresAt *time.Time `json:"ban_expires_at"`
+}
+
+// UserClient is a 3x-ui client linked to a user with its current traffic.
+// Traffic is omitted when the client is missing in the panel or the panel is unavailable.
+type UserClient struct {
+	database.ClientLink
+	Traffic *xui.ClientTraffic `json:"traffic,omitempty"`
+}
+
+// ListUsers returns users matching the filters, newest first unless sort is given.
+func (h *UserHandler) ListUsers(c *gin.Context) error {
+	var query ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid query: "+err.Error())
+	}
+
+	if err := validate.Struct(query); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+
+	users, total, err := h.users.List(c.Request.Context(), service.UserFilter{
+		Query:       query.Query,
+		Active:      query.Active,
+		Linked:      query.Linked,
+		Tag:         query.Tag,
+		CreatedFrom: query.CreatedFrom,
+		CreatedTo:   query.CreatedTo,
+		Sort:        query.Sort,
+		Limit:       query.Limit,
+		Offset:      query.Offset,
+	})
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  users,
+		"total":  total,
+		"limit":  query.Limit,
+		"offset": query.Offset,
+	})
+	return nil
+}
+
+// GetUser returns a user with the linked 3x-ui clients and the ban, if any.
+func (h *UserHandler) GetUser(c *gin.Context) error {
+	user, err := findUser(c, h.users)
+	if err != nil {
+		return err
+	}
+
+	clients, err := h.userClients(c.Request.Context(), user.TelegramID)
+	if err != nil {
+		return err // Internal server error
+	}
+	ban, err := h.userBan(c.Request.Context(), user.TelegramID)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":    user,
+		"clients": clients,
+		"ban":     ban,
+	})
+	return nil
+}
+
+// UpdateUser changes the notes and tags of a user and bans or unbans them.
+func (h *UserHandler) UpdateUser(c *gin.Context) error {
+	user, err := findUser(c, h.users)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if req.Banned != nil && *req.Banned && req.BanExpiresAt != nil && !req.BanExpiresAt.After(time.Now()) {
+		return apierror.New(http.StatusBadRequest, "ban_expires_at must be in the future")
+	}
+
+	ctx := c.Request.Context()
+	target := strconv.FormatInt(user.TelegramID, 10)
+
+	update := service.UserUpdate{Notes: req.Notes}
+	if req.Tags != nil {
+		update.Tags = *req.Tags
+	}
+	if update.Notes != nil || update.Tags != nil {
+		user, err = h.users.Update(ctx, user.ID, update)
+		if err != nil {
+			return err // Internal server error
+		}
+		recordAdminAudit(c, h.audit, h.logger, "user.update", target, map[string]any{"notes": req.Notes, "tags": user.Tags})
+	}
+
+	if req.Banned != nil {
+		if *req.Banned {
+			ban, err := h.bans.Ban(ctx, database.Ban{
+				TelegramID:   user.TelegramID,
+				Reason:       req.BanReason,
+				ExpiresAt:    req.BanExpiresAt,
+				BannedByType: database.AuditActorAdmin,
+				BannedBy:     contextAdminID(c),
+			})
+			if err != nil {
+				return err // Internal server error
+			}
+			recordAdminAudit(c, h.audit, h.logger, "user.ban", target, map[string]any{"reason": ban.Reason, "expires_at": ban.ExpiresAt})
+		} else {
+			err := h.bans.Unban(ctx, user.TelegramID)
+			if err != nil && !errors.Is(err, service.ErrBanNotFound) {
+				return err // Internal server error
+			}
+			if err == nil {
+				recordAdminAudit(c, h.audit, h.logger, "user.unban", target, nil)
+			}
+		}
+	}
+
+	ban, err := h.userBan(ctx, user.TelegramID)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	h.logger.Info("user updated via admin API", "telegram_id", user.TelegramID, "admin_id", contextAdminID(c))
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+		"ban":  ban,
+	})
+	return nil
+}
+
+// findUser loads the user from the :id path parameter.
+func findUser(c *gin.Context, users *service.UserService) (*database.User, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return nil, apierror.New(http.StatusBadRequest, "invalid user id")
+	}
+
+	user, err := users.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, apierror.New(http.StatusNotFound, "user not found")
+		}
+		return nil, err // Internal server error
+	}
+	return user, nil
+}
+
+// userClients returns the linked clients of a user. An unavailable panel doesn't fail
+// the request, the clients are returned without traffic.
+func (h *UserHandler) userClients(ctx context.Context, telegramID int64) ([]UserClient, error) {
+	links, err := h.links.ForUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]UserClient, 0, len(links))
+	for _, link := range links {
+		client := UserClient{ClientLink: link}
+		traffics, err := h.clients.GetClientTraffics(ctx, link.Email)
+		if err != nil {
+			h.logger.Error("failed to get client traffic", "error", err, "email", link.Email)
+		}
+		for i := range traffics {
+			if strings.EqualFold(traffics[i].Email, link.Email) {
+				client.Traffic = &traffics[i]
+				break
+			}
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// userBan returns the active ban of a user or nil.
+func (h *UserHandler) userBan(ctx context.Context, telegramID int64) (*database.Ban, error) {
+	ban, err := h.bans.Get(ctx, telegramID)
+	if errors.Is(err, service.ErrBanNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ban.Active(time.Now()) {
+		return nil, nil
+	}
+	return ban, nil
+}
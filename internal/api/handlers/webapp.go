@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/api/middleware"
+	"go-bot/internal/render"
+	"go-bot/internal/service"
+	"go-bot/internal/traffic"
+	"go-bot/internal/webapp"
+	"go-bot/internal/xui"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebAppClients is the part of XUIService used by the Mini App.
+type WebAppClients interface {
+	GetClientTraffics(ctx context.Context, email string) ([]xui.ClientTraffic, error)
+	GetClient(ctx context.Context, email string) (*xui.InboundClient, error)
+}
+
+// WebAppHandler serves the data of the Telegram Mini App for the user who opened it.
+type WebAppHandler struct {
+	links    *service.ClientLinkService
+	clients  WebAppClients
+	history  *traffic.History
+	payments *service.PaymentService
+	subURL   string
+	logger   *slog.Logger
+}
+
+// NewWebAppHandler creates a new WebAppHandler. subURL is the 3x-ui subscription base URL,
+// an empty one omits subscription links.
+func NewWebAppHandler(links *service.ClientLinkService, clients WebAppClients, history *traffic.History,
+	payments *service.PaymentService, subURL string, logger *slog.Logger) *WebAppHandler {
+	return &WebAppHandler{
+		links:    links,
+		clients:  clients,
+		history:  history,
+		payments: payments,
+		subURL:   subURL,
+		logger:   logger,
+	}
+}
+
+// WebAppSubscription is a linked 3x-ui client as shown in the Mini App.
+type WebAppSubscription struct {
+	Email           string `json:"email"`
+	Active          bool   `json:"active"`
+	Up              int64  `json:"up"`
+	Down            int64  `json:"down"`
+	Total           int64  `json:"total"`       // traffic limit in bytes, 0 means unlimited
+	ExpiryTime      int64  `json:"expiry_time"` // Unix ms, 0 means never, negative is a duration from the first connection
+	SubscriptionURL string `json:"subscription_url,omitempty"`
+}
+
+// WebAppUsageQuery represents the query parameters for the usage history.
+type WebAppUsageQuery struct {
+	Email string `form:"email"`                        // empty means the latest linked client
+	Days  int    `form:"days" validate:"gte=0,lte=90"` // 0 means 7
+}
+
+// WebAppUsageDay is the traffic of a client for one day (UTC).
+type WebAppUsageDay struct {
+	Day  string `json:"day"`
+	Up   int64  `json:"up"`
+	Down int64  `json:"down"`
+}
+
+// WebAppPlan is a subscription plan that can be bought with /buy.
+type WebAppPlan struct {
+	ID        string `json:"id"`
+	Days      int    `json:"days"`
+	TrafficGB int    `json:"traffic_gb"`
+	Price     int    `json:"price"`
+}
+
+// Me returns the Telegram user and all subscriptions linked to them.
+func (h *WebAppHandler) Me(c *gin.Context) error {
+	user := webAppUser(c)
+	ctx := c.Request.Context()
+
+	links, err := h.links.ForUser(ctx, user.ID)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	subscriptions := make([]WebAppSubscription, 0, len(links))
+	for _, link := range links {
+		subscription, err := h.subscription(ctx, link.Email)
+		if errors.Is(err, xui.ErrClientNotFound) {
+			// Клиент удален в панели, а привязка осталась.
+			continue
+		}
+		if err != nil {
+			return err // Internal server error
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":          user,
+		"subscriptions": subscriptions,
+	})
+	return nil
+}
+
+// Usage returns the daily traffic of a linked client.
+func (h *WebAppHandler) Usage(c *gin.Context) error {
+	var query WebAppUsageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return apierror.New(http.StatusBadRequest, "invalid query: "+err.Error())
+	}
+	if err := validate.Struct(query); err != nil {
+		return apierror.New(http.StatusBadRequest, "validation failed: "+err.Error())
+	}
+	if query.Days == 0 {
+		query.Days = 7
+	}
+
+	user := webAppUser(c)
+	ctx := c.Request.Context()
+
+	links, err := h.links.ForUser(ctx, user.ID)
+	if err != nil {
+		return err // Internal server error
+	}
+	email := ""
+	for _, link := range links {
+		if query.Email == "" || strings.EqualFold(link.Email, query.Email) {
+			email = link.Email
+			break
+		}
+	}
+	if email == "" {
+		return apierror.New(http.StatusNotFound, "subscription not found")
+	}
+
+	now := time.Now()
+	rows, err := h.history.Daily(ctx, email, now.AddDate(0, 0, -(query.Days-1)), now)
+	if err != nil {
+		return err // Internal server error
+	}
+
+	days := make([]WebAppUsageDay, 0, len(rows))
+	for _, row := range rows {
+		days = append(days, WebAppUsageDay{Day: row.Day.Format(time.DateOnly), Up: row.Up, Down: row.Down})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"email": email,
+		"days":  days,
+	})
+	return nil
+}
+
+// Plans returns the payment options. The list is empty when payments are disabled.
+func (h *WebAppHandler) Plans(c *gin.Context) error {
+	plans := make([]WebAppPlan, 0)
+	if h.payments.Enabled() {
+		for _, plan := range h.payments.Plans() {
+			plans = append(plans, WebAppPlan{ID: plan.ID, Days: plan.Days, TrafficGB: plan.TrafficGB, Price: plan.Price})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"currency": h.payments.Currency(),
+		"plans":    plans,
+	})
+	return nil
+}
+
+// subscription collects the traffic, limits and subscription link of a client.
+func (h *WebAppHandler) subscription(ctx context.Context, email string) (*WebAppSubscription, error) {
+	traffics, err := h.clients.GetClientTraffics(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription *WebAppSubscription
+	for _, t := range traffics {
+		if strings.EqualFold(t.Email, email) {
+			subscription = &WebAppSubscription{
+				Email:      t.Email,
+				Active:     render.ClientActive(t, time.Now()),
+				Up:         t.Up,
+				Down:       t.Down,
+				Total:      t.Total,
+				ExpiryTime: t.ExpiryTime,
+			}
+			break
+		}
+	}
+	if subscription == nil {
+		return nil, xui.ErrClientNotFound
+	}
+
+	if h.subURL != "" {
+		client, err := h.clients.GetClient(ctx, subscription.Email)
+		if err != nil {
+			return nil, err
+		}
+		if client.SubID != "" {
+			subscription.SubscriptionURL = service.SubscriptionURL(h.subURL, client.SubID)
+		}
+	}
+	return subscription, nil
+}
+
+// webAppUser returns the user verified by middleware.WebAppAuth.
+func webAppUser(c *gin.Context) *webapp.User {
+	return c.MustGet(middleware.WebAppUserKey).(*webapp.User)
+}
@@ -1,51 +1,89 @@
 package handlers
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"go-bot/internal/api/apierror"
-	"go-bot/internal/bot"
 	"go-bot/internal/config"
-	"go-bot/internal/service"
+	"go-bot/internal/metrics"
+	"go-bot/internal/queue"
 
 	"github.com/gin-gonic/gin"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"gorm.io/gorm"
 )
 
+// enqueueTimeout limits how long a webhook request waits for room in a full queue.
+const enqueueTimeout = 5 * time.Second
+
+// UpdateEnqueuer accepts Telegram updates for asynchronous processing.
+type UpdateEnqueuer interface {
+	Enqueue(ctx context.Context, update tgbotapi.Update) error
+}
+
 // WebhookHandler handles webhook-related API endpoints.
 type WebhookHandler struct {
 	cfg    *config.Config
 	logger *slog.Logger
-	bot    bot.BotSender
-	db     *gorm.DB
+	queue  UpdateEnqueuer
 }
 
 // NewWebhookHandler creates a new WebhookHandler.
-func NewWebhookHandler(cfg *config.Config, logger *slog.Logger, bot bot.BotSender, db *gorm.DB) *WebhookHandler {
+func NewWebhookHandler(cfg *config.Config, logger *slog.Logger, queue UpdateEnqueuer) *WebhookHandler {
 	return &WebhookHandler{
 		cfg:    cfg,
 		logger: logger,
-		bot:    bot,
-		db:     db,
+		queue:  queue,
 	}
 }
 
+// SecretTokenHeader is the header Telegram uses to send the webhook secret_token.
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
 // HandleWebhook processes incoming webhooks from Telegram.
 func (h *WebhookHandler) HandleWebhook(c *gin.Context) error {
+	if !h.validSecretToken(c.GetHeader(SecretTokenHeader)) {
+		metrics.WebhookRejected.Add(1)
+		h.logger.Warn("Rejected webhook request with invalid secret token", "client_ip", c.ClientIP())
+		return apierror.New(http.StatusUnauthorized, "invalid secret token")
+	}
+
 	var update tgbotapi.Update
 	if err := c.ShouldBindJSON(&update); err != nil {
 		h.logger.Error("Failed to bind JSON for webhook update", "error", err)
 		return apierror.New(http.StatusBadRequest, "invalid request body")
 	}
 
-	// Создаем XUI сервис, используя зависимости из хендлера
-	xuiService := service.NewXUIService(h.cfg, h.logger)
+	// Обновление обрабатывается воркерами очереди, а не в контексте HTTP-запроса,
+	// который отменяется сразу после ответа Telegram.
+	ctx, cancel := context.WithTimeout(c.Request.Context(), enqueueTimeout)
+	defer cancel()
 
-	// Асинхронно обрабатываем обновление, чтобы не блокировать ответ Telegram
-	go bot.ProcessUpdate(c.Request.Context(), update, h.bot, h.db, xuiService)
+	if err := h.queue.Enqueue(ctx, update); err != nil {
+		switch {
+		case errors.Is(err, queue.ErrDuplicate):
+			h.logger.Debug("Skipping duplicate update", "update_id", update.UpdateID)
+		case errors.Is(err, queue.ErrQueueFull), errors.Is(err, queue.ErrQueueClosed):
+			// Telegram повторит доставку, получив ошибку.
+			h.logger.Warn("Update queue unavailable", "error", err, "update_id", update.UpdateID)
+			return apierror.New(http.StatusServiceUnavailable, "update queue unavailable")
+		default:
+			return err // Internal server error
+		}
+	}
 
 	c.Status(http.StatusOK)
 	return nil
 }
+
+// validSecretToken compares the received token with the configured secret in constant time.
+func (h *WebhookHandler) validSecretToken(token string) bool {
+	if h.cfg.WebhookSecret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.WebhookSecret)) == 1
+}
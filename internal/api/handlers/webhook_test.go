@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-bot/internal/api/apierror"
+	"go-bot/internal/config"
+	"go-bot/internal/metrics"
+	"go-bot/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubEnqueuer records enqueued updates and returns a preset error.
+type stubEnqueuer struct {
+	err     error
+	updates []tgbotapi.Update
+}
+
+func (s *stubEnqueuer) Enqueue(ctx context.Context, update tgbotapi.Update) error {
+	s.updates = append(s.updates, update)
+	return s.err
+}
+
+func TestWebhookHandler_SecretToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{WebhookSecret: "expected-secret_123"}
+
+	testCases := []struct {
+		name             string
+		secret           string
+		setHeader        bool
+		expectedCode     int
+		expectedRejected int64
+	}{
+		{
+			name:             "Missing Header",
+			setHeader:        false,
+			expectedCode:     http.StatusUnauthorized,
+			expectedRejected: 1,
+		},
+		{
+			name:             "Wrong Secret",
+			secret:           "wrong-secret",
+			setHeader:        true,
+			expectedCode:     http.StatusUnauthorized,
+			expectedRejected: 1,
+		},
+		{
+			name:             "Valid Secret",
+			secret:           "expected-secret_123",
+			setHeader:        true,
+			expectedCode:     http.StatusOK,
+			expectedRejected: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewWebhookHandler(cfg, silentLogger, &stubEnqueuer{})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(`{"update_id": 1}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if tc.setHeader {
+				c.Request.Header.Set(SecretTokenHeader, tc.secret)
+			}
+
+			rejectedBefore := metrics.WebhookRejected.Value()
+			apierror.ErrorWrapper(h.HandleWebhook)(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedRejected, metrics.WebhookRejected.Value()-rejectedBefore)
+		})
+	}
+}
+
+func TestWebhookHandler_EnqueueErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	silentLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{WebhookSecret: "expected-secret_123"}
+
+	testCases := []struct {
+		name         string
+		enqueueErr   error
+		expectedCode int
+	}{
+		{name: "Enqueued", enqueueErr: nil, expectedCode: http.StatusOK},
+		{name: "Duplicate Update", enqueueErr: queue.ErrDuplicate, expectedCode: http.StatusOK},
+		{name: "Queue Full", enqueueErr: queue.ErrQueueFull, expectedCode: http.StatusServiceUnavailable},
+		{name: "Queue Closed", enqueueErr: queue.ErrQueueClosed, expectedCode: http.StatusServiceUnavailable},
+		{name: "Store Failure", enqueueErr: errors.New("db is down"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enqueuer := &stubEnqueuer{err: tc.enqueueErr}
+			h := NewWebhookHandler(cfg, silentLogger, enqueuer)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(`{"update_id": 42}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set(SecretTokenHeader, cfg.WebhookSecret)
+
+			apierror.ErrorWrapper(h.HandleWebhook)(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if assert.Len(t, enqueuer.updates, 1) {
+				assert.Equal(t, 42, enqueuer.updates[0].UpdateID)
+			}
+		})
+	}
+}
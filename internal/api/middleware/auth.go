@@ -1,16 +1,21 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"go-bot/internal/auth"
+	"go-bot/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware проверяет JWT токен и добавляет claims в контекст
-func AuthMiddleware(jwtSecretKey string) gin.HandlerFunc {
+// AuthMiddleware проверяет JWT токен или API-ключ и добавляет администратора (auth.Principal)
+// в контекст запроса. Обработчики получают его через auth.PrincipalFromContext(c.Request.Context()).
+// Токен принимается, только если его сессия (по jti) не отозвана и не заменена обновлением.
+// API-ключ передается так же, в заголовке "Bearer gbk_...".
+func AuthMiddleware(jwtSecretKey string, sessions services.SessionServiceInterface, apiKeys services.APIKeyServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,6 +33,22 @@ func AuthMiddleware(jwtSecretKey string) gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
+		if auth.IsAPIKey(tokenString) {
+			principal, err := apiKeys.Authenticate(c.Request.Context(), tokenString)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidAPIKey) {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+				}
+				c.Abort()
+				return
+			}
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+			c.Next()
+			return
+		}
+
 		claims, err := auth.ValidateToken(tokenString, jwtSecretKey)
 		if err != nil {
 			switch err {
@@ -42,10 +63,46 @@ func AuthMiddleware(jwtSecretKey string) gin.HandlerFunc {
 			return
 		}
 
-		// Добавляем claims в контекст
-		c.Set("admin_id", claims.AdminID)
-		c.Set("admin_login", claims.Login)
-		c.Set("claims", claims)
+		active, err := sessions.IsActive(c.Request.Context(), claims.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			c.Abort()
+			return
+		}
+
+		principal := &auth.Principal{
+			AdminID:     claims.AdminID,
+			Login:       claims.Login,
+			TokenID:     claims.ID,
+			Permissions: claims.Permissions,
+		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+
+		c.Next()
+	}
+}
+
+// RequireSession пропускает только запросы с токеном сессии. Используется после AuthMiddleware
+// на маршрутах учетной записи (пароль, 2FA, API-ключи), чтобы утекший API-ключ нельзя было
+// обменять на новые ключи или использовать для захвата учетной записи владельца.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			c.Abort()
+			return
+		}
+		if principal.APIKeyID != 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "api keys are not accepted here, log in instead"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+	"go-bot/internal/services"
+	"go-bot/internal/services/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret-for-jwt-token-generation"
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	apiKey := "gbk_abcdefgh_" + strings.Repeat("a", 32)
+	token, err := auth.GenerateToken(&database.Admin{ID: 7, Login: "admin"}, "token-id", testSecret, time.Hour)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		header        string
+		mockSetup     func(*mocks.SessionServiceInterface, *mocks.APIKeyServiceInterface)
+		expectedCode  int
+		expectedKeyID uint64
+	}{
+		{name: "missing header", expectedCode: http.StatusUnauthorized},
+		{name: "malformed header", header: "Token " + token, expectedCode: http.StatusUnauthorized},
+		{
+			name:   "session token",
+			header: "Bearer " + token,
+			mockSetup: func(s *mocks.SessionServiceInterface, _ *mocks.APIKeyServiceInterface) {
+				s.On("IsActive", mock.Anything, "token-id").Return(true, nil).Once()
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "revoked session",
+			header: "Bearer " + token,
+			mockSetup: func(s *mocks.SessionServiceInterface, _ *mocks.APIKeyServiceInterface) {
+				s.On("IsActive", mock.Anything, "token-id").Return(false, nil).Once()
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "api key",
+			header: "Bearer " + apiKey,
+			mockSetup: func(_ *mocks.SessionServiceInterface, k *mocks.APIKeyServiceInterface) {
+				k.On("Authenticate", mock.Anything, apiKey).
+					Return(&auth.Principal{AdminID: 7, Login: "admin", APIKeyID: 3}, nil).Once()
+			},
+			expectedCode:  http.StatusOK,
+			expectedKeyID: 3,
+		},
+		{
+			name:   "invalid api key",
+			header: "Bearer " + apiKey,
+			mockSetup: func(_ *mocks.SessionServiceInterface, k *mocks.APIKeyServiceInterface) {
+				k.On("Authenticate", mock.Anything, apiKey).Return(nil, services.ErrInvalidAPIKey).Once()
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions := mocks.NewSessionServiceInterface(t)
+			apiKeys := mocks.NewAPIKeyServiceInterface(t)
+			if tc.mockSetup != nil {
+				tc.mockSetup(sessions, apiKeys)
+			}
+
+			var principal *auth.Principal
+			router := gin.New()
+			router.GET("/", AuthMiddleware(testSecret, sessions, apiKeys), func(c *gin.Context) {
+				principal, _ = auth.PrincipalFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode == http.StatusOK {
+				require.NotNil(t, principal)
+				assert.Equal(t, uint64(7), principal.AdminID)
+				assert.Equal(t, tc.expectedKeyID, principal.APIKeyID)
+			}
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name         string
+		principal    *auth.Principal
+		expectedCode int
+	}{
+		{name: "unauthenticated", expectedCode: http.StatusUnauthorized},
+		{name: "session", principal: &auth.Principal{AdminID: 7, TokenID: "token-id"}, expectedCode: http.StatusOK},
+		{name: "api key", principal: &auth.Principal{AdminID: 7, APIKeyID: 3}, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tc.principal != nil {
+					c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), tc.principal))
+				}
+			})
+			router.GET("/", RequireSession(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
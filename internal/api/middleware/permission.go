@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-bot/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission пропускает только администраторов, у роли которых есть все указанные
+// разрешения. Используется после AuthMiddleware на группах маршрутов. Разрешения берутся
+// из токена: изменение роли отзывает сессии, поэтому старые разрешения не остаются в силе.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !principal.Can(permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "permission required: " + permission})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
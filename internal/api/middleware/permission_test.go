@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-bot/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	support := &auth.Principal{AdminID: 2, Login: "support", Permissions: []string{auth.PermUsersRead, auth.PermTicketsRead}}
+
+	testCases := []struct {
+		name         string
+		principal    *auth.Principal
+		permissions  []string
+		expectedCode int
+	}{
+		{name: "unauthenticated", permissions: []string{auth.PermUsersRead}, expectedCode: http.StatusUnauthorized},
+		{name: "granted", principal: support, permissions: []string{auth.PermUsersRead}, expectedCode: http.StatusOK},
+		{name: "all granted", principal: support, permissions: []string{auth.PermUsersRead, auth.PermTicketsRead}, expectedCode: http.StatusOK},
+		{name: "denied", principal: support, permissions: []string{auth.PermBansWrite}, expectedCode: http.StatusForbidden},
+		{name: "partly granted", principal: support, permissions: []string{auth.PermUsersRead, auth.PermUsersWrite}, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tc.principal != nil {
+					c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), tc.principal))
+				}
+			})
+			router.GET("/", RequirePermission(tc.permissions...), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/webapp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebAppUserKey - ключ контекста с проверенным пользователем Telegram Mini App (*webapp.User).
+const WebAppUserKey = "webapp_user"
+
+// WebAppAuth проверяет initData Telegram Mini App из заголовка "Authorization: tma <initData>"
+// и добавляет пользователя в контекст. maxAge ограничивает возраст initData.
+func WebAppAuth(botToken string, maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		initData, ok := strings.CutPrefix(c.GetHeader("Authorization"), "tma ")
+		if !ok || initData == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "init data required"})
+			c.Abort()
+			return
+		}
+
+		data, err := webapp.Validate(initData, botToken, maxAge, time.Now())
+		if err != nil {
+			if errors.Is(err, webapp.ErrExpired) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "init data expired"})
+			} else {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid init data"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set(WebAppUserKey, &data.User)
+		c.Next()
+	}
+}
+
+// BanChecker - часть service.BanService, нужная WebAppBanCheck.
+type BanChecker interface {
+	Check(ctx context.Context, ids ...int64) (*database.Ban, error)
+}
+
+// WebAppBanCheck отклоняет запросы Mini App от заблокированных пользователей, как бот отбрасывает
+// их обновления. Подключается после WebAppAuth. Если блокировки не удалось загрузить,
+// используются закэшированные, чтобы сбой базы не закрывал Mini App.
+func WebAppBanCheck(bans BanChecker, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet(WebAppUserKey).(*webapp.User)
+		ban, err := bans.Check(c.Request.Context(), user.ID)
+		if err != nil {
+			logger.Error("failed to check bans", "error", err, "telegram_id", user.ID)
+		}
+		if ban != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "user is banned"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
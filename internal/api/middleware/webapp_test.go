@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-bot/internal/database"
+	"go-bot/internal/webapp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubBans возвращает блокировку для ID из banned.
+type stubBans struct {
+	banned map[int64]bool
+	err    error
+}
+
+func (s *stubBans) Check(ctx context.Context, ids ...int64) (*database.Ban, error) {
+	for _, id := range ids {
+		if s.banned[id] {
+			return &database.Ban{TelegramID: id}, s.err
+		}
+	}
+	return nil, s.err
+}
+
+func TestWebAppBanCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name         string
+		bans         *stubBans
+		userID       int64
+		expectedCode int
+	}{
+		{name: "not banned", bans: &stubBans{banned: map[int64]bool{2: true}}, userID: 1, expectedCode: http.StatusOK},
+		{name: "banned", bans: &stubBans{banned: map[int64]bool{2: true}}, userID: 2, expectedCode: http.StatusForbidden},
+		{name: "cached ban on load error", bans: &stubBans{banned: map[int64]bool{2: true}, err: errors.New("db down")}, userID: 2, expectedCode: http.StatusForbidden},
+		{name: "load error without ban", bans: &stubBans{err: errors.New("db down")}, userID: 1, expectedCode: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) { c.Set(WebAppUserKey, &webapp.User{ID: tc.userID}) })
+			router.GET("/", WebAppBanCheck(tc.bans, logger), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go-bot/internal/api/handlers"
+	"go-bot/internal/api/openapi"
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+	"go-bot/internal/webapp"
+)
+
+// Security schemes of the API document.
+const (
+	securityBearer  = "bearer"
+	securityWebApp  = "webAppInitData"
+	securityWebhook = "webhookSecret"
+)
+
+// endpoint describes a route for the OpenAPI document.
+type endpoint struct {
+	method  string
+	path    string // gin syntax, as registered in setupRouter
+	tag     string
+	summary string
+	// security is the security scheme of the route, empty for public routes.
+	security string
+	// permission is the admin permission the route requires.
+	permission string
+	// sessionOnly routes reject API keys.
+	sessionOnly bool
+	query       any                 // struct with form tags
+	params      []openapi.Parameter // query parameters without a struct
+	body        any
+	status      int // success status, 200 if zero
+	response    any // success body, nil for no content
+	errors      map[int]string
+}
+
+// apiDocument builds the OpenAPI document of the routes registered in setupRouter.
+// TestOpenAPI_CoversRoutes fails when a registered route is missing here.
+func apiDocument() *openapi.Document {
+	b := openapi.New(openapi.Info{
+		Title:   "goooo API",
+		Version: "1.0.0",
+		Description: "Admin API of the VPN bot, the Telegram Mini App API and the Telegram webhook. " +
+			"Errors are returned as {\"error\": \"message\"}.",
+	})
+
+	b.AddSecurityScheme(securityBearer, openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "Access token from /api/admin/login or an API key gbk_<prefix>_<secret>.",
+	})
+	b.AddSecurityScheme(securityWebApp, openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "Authorization",
+		Description: "Telegram Mini App initData: \"tma <initData>\".",
+	})
+	b.AddSecurityScheme(securityWebhook, openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        handlers.SecretTokenHeader,
+		Description: "Webhook secret token set with setWebhook.",
+	})
+
+	b.AddTag("auth", "Admin login, sessions and account")
+	b.AddTag("2fa", "Two-factor authentication of the current admin")
+	b.AddTag("api-keys", "API keys of the current admin")
+	b.AddTag("users", "Telegram users of the bot")
+	b.AddTag("messages", "Conversation history with users")
+	b.AddTag("tickets", "Support tickets")
+	b.AddTag("bans", "Banned users and chats")
+	b.AddTag("referrals", "Referral program")
+	b.AddTag("payments", "Subscription payments")
+	b.AddTag("admins", "Admin accounts and roles")
+	b.AddTag("webapp", "Telegram Mini App")
+	b.AddTag("telegram", "Telegram webhook")
+	b.AddTag("monitoring", "Health checks, metrics and API docs")
+
+	admin := func(path string) string { return APIPrefix + "/admin" + path }
+	adminResponse := b.Object(map[string]any{"admin": database.Admin{}})
+	recoveryCodes := b.Object(map[string]any{"recovery_codes": []string{}})
+	loginErrors := map[int]string{
+		http.StatusUnauthorized: "Invalid credentials or code",
+		http.StatusForbidden:    "Account is inactive",
+		http.StatusLocked:       "Account is locked after failed logins",
+	}
+
+	endpoints := []endpoint{
+		{method: http.MethodGet, path: "/health", tag: "monitoring", summary: "Liveness check",
+			response: b.Object(map[string]any{"status": ""})},
+		{method: http.MethodGet, path: "/ready", tag: "monitoring", summary: "Readiness check including the database",
+			response: b.Object(map[string]any{"status": ""}),
+			errors:   map[int]string{http.StatusServiceUnavailable: "Database is unavailable"}},
+		{method: http.MethodGet, path: "/debug/vars", tag: "monitoring", summary: "Application metrics (expvar)",
+			security: securityBearer, permission: auth.PermMonitoringRead, response: map[string]any{}},
+		{method: http.MethodGet, path: APIPrefix + OpenAPIPath, tag: "monitoring", summary: "This OpenAPI document",
+			response: map[string]any{}},
+
+		{method: http.MethodPost, path: APIPrefix + WebhookPath, tag: "telegram", summary: "Receive a Telegram update",
+			security: securityWebhook, body: &openapi.Schema{Type: "object", Description: "Telegram Update"}},
+
+		{method: http.MethodGet, path: APIPrefix + "/webapp/me", tag: "webapp", summary: "Current user and subscriptions",
+			security: securityWebApp,
+			response: b.Object(map[string]any{"user": webapp.User{}, "subscriptions": []handlers.WebAppSubscription{}})},
+		{method: http.MethodGet, path: APIPrefix + "/webapp/usage", tag: "webapp", summary: "Daily traffic of a subscription",
+			security: securityWebApp, query: handlers.WebAppUsageQuery{},
+			response: b.Object(map[string]any{"email": "", "days": []handlers.WebAppUsageDay{}})},
+		{method: http.MethodGet, path: APIPrefix + "/webapp/plans", tag: "webapp", summary: "Subscription plans",
+			security: securityWebApp,
+			response: b.Object(map[string]any{"currency": "", "plans": []handlers.WebAppPlan{}})},
+
+		{method: http.MethodPost, path: admin("/login"), tag: "auth", summary: "Log in with login and password",
+			body: handlers.LoginRequest{}, errors: loginErrors,
+			response: &openapi.Schema{
+				Description: "Tokens, or a challenge for /login/verify when two-factor authentication is enabled",
+				OneOf:       []*openapi.Schema{b.Schema(handlers.TokenResponse{}), b.Schema(handlers.ChallengeResponse{})},
+			}},
+		{method: http.MethodPost, path: admin("/login/verify"), tag: "auth", summary: "Complete a login with a two-factor code",
+			body: handlers.VerifyLoginRequest{}, response: handlers.TokenResponse{}, errors: loginErrors},
+		{method: http.MethodPost, path: admin("/refresh"), tag: "auth", summary: "Exchange a refresh token for new tokens",
+			body: handlers.RefreshRequest{}, response: handlers.TokenResponse{},
+			errors: map[int]string{http.StatusUnauthorized: "Invalid refresh token", http.StatusForbidden: "Account is inactive"}},
+		{method: http.MethodPost, path: admin("/logout"), tag: "auth", summary: "End the current or all sessions",
+			security: securityBearer, sessionOnly: true, body: handlers.LogoutRequest{}, status: http.StatusNoContent},
+		{method: http.MethodGet, path: admin("/profile"), tag: "auth", summary: "Profile of the current admin",
+			security: securityBearer,
+			response: b.Object(map[string]any{
+				"id": uint64(0), "login": "", "is_active": false, "role": database.Role{},
+				"totp_enabled": false, "last_login_at": &time.Time{},
+			})},
+		{method: http.MethodPost, path: admin("/change-password"), tag: "auth", summary: "Change the password and end all sessions",
+			security: securityBearer, sessionOnly: true, body: handlers.ChangePasswordRequest{}, status: http.StatusNoContent},
+
+		{method: http.MethodPost, path: admin("/2fa/setup"), tag: "2fa", summary: "Generate a TOTP secret and its QR code",
+			security: securityBearer, sessionOnly: true,
+			response: b.Object(map[string]any{"secret": "", "uri": "", "qr_code": ""}),
+			errors:   map[int]string{http.StatusConflict: "Two-factor authentication is already enabled"}},
+		{method: http.MethodPost, path: admin("/2fa/enable"), tag: "2fa", summary: "Confirm the secret and get recovery codes",
+			security: securityBearer, sessionOnly: true, body: handlers.TwoFactorCodeRequest{}, response: recoveryCodes,
+			errors: map[int]string{http.StatusConflict: "Already enabled or not set up"}},
+		{method: http.MethodPost, path: admin("/2fa/disable"), tag: "2fa", summary: "Turn off two-factor authentication",
+			security: securityBearer, sessionOnly: true, body: handlers.TwoFactorChangeRequest{}, status: http.StatusNoContent,
+			errors: map[int]string{http.StatusConflict: "Two-factor authentication is not enabled"}},
+		{method: http.MethodPost, path: admin("/2fa/recovery-codes"), tag: "2fa", summary: "Replace the recovery codes",
+			security: securityBearer, sessionOnly: true, body: handlers.TwoFactorChangeRequest{}, response: recoveryCodes,
+			errors: map[int]string{http.StatusConflict: "Two-factor authentication is not enabled"}},
+
+		{method: http.MethodGet, path: admin("/api-keys"), tag: "api-keys", summary: "List API keys",
+			security: securityBearer, sessionOnly: true,
+			response: b.Object(map[string]any{"api_keys": []database.APIKey{}})},
+		{method: http.MethodPost, path: admin("/api-keys"), tag: "api-keys", summary: "Create an API key, the key is shown once",
+			security: securityBearer, sessionOnly: true, body: handlers.CreateAPIKeyRequest{}, status: http.StatusCreated,
+			response: b.Object(map[string]any{"api_key": database.APIKey{}, "key": ""})},
+		{method: http.MethodDelete, path: admin("/api-keys/:id"), tag: "api-keys", summary: "Revoke an API key",
+			security: securityBearer, sessionOnly: true, status: http.StatusNoContent},
+
+		{method: http.MethodGet, path: admin("/referrals/settings"), tag: "referrals", summary: "Referral reward settings",
+			security: securityBearer, permission: "referrals:read", response: database.ReferralSettings{}},
+		{method: http.MethodPut, path: admin("/referrals/settings"), tag: "referrals", summary: "Update referral rewards",
+			security: securityBearer, permission: "referrals:write", body: handlers.ReferralSettingsRequest{},
+			response: database.ReferralSettings{}},
+		{method: http.MethodGet, path: admin("/referrals/tree"), tag: "referrals", summary: "Referral tree",
+			security: securityBearer, permission: "referrals:read",
+			params: []openapi.Parameter{{Name: "root", In: "query", Description: "Telegram ID of the tree root",
+				Schema: &openapi.Schema{Type: "integer", Format: "int64"}}},
+			response: b.Object(map[string]any{"tree": []*service.ReferralNode{}})},
+
+		{method: http.MethodGet, path: admin("/tickets"), tag: "tickets", summary: "List support tickets",
+			security: securityBearer, permission: "tickets:read", query: handlers.ListTicketsQuery{},
+			response: page(b, "tickets", []database.Ticket{})},
+		{method: http.MethodGet, path: admin("/tickets/:id"), tag: "tickets", summary: "Ticket with messages",
+			security: securityBearer, permission: "tickets:read", response: database.Ticket{}},
+		{method: http.MethodPost, path: admin("/tickets/:id/close"), tag: "tickets", summary: "Close a ticket",
+			security: securityBearer, permission: "tickets:write", response: database.Ticket{}},
+
+		{method: http.MethodGet, path: admin("/payments"), tag: "payments", summary: "List payments",
+			security: securityBearer, permission: "payments:read", query: handlers.ListPaymentsQuery{},
+			response: page(b, "payments", []database.Payment{})},
+		{method: http.MethodPost, path: admin("/payments/:id/apply"), tag: "payments", summary: "Apply a pending or failed payment",
+			security: securityBearer, permission: "payments:write", response: database.Payment{},
+			errors: map[int]string{http.StatusConflict: "Payment is already applied"}},
+
+		{method: http.MethodGet, path: admin("/users"), tag: "users", summary: "List users",
+			security: securityBearer, permission: "users:read", query: handlers.ListUsersQuery{},
+			response: page(b, "users", []database.User{})},
+		{method: http.MethodGet, path: admin("/users/:id"), tag: "users", summary: "User with clients and ban",
+			security: securityBearer, permission: "users:read",
+			response: b.Object(map[string]any{"user": database.User{}, "clients": []handlers.UserClient{}, "ban": &database.Ban{}})},
+		{method: http.MethodPatch, path: admin("/users/:id"), tag: "users", summary: "Update notes and tags, ban or unban",
+			security: securityBearer, permission: "users:write", body: handlers.UpdateUserRequest{},
+			response: b.Object(map[string]any{"user": database.User{}, "ban": &database.Ban{}})},
+
+		{method: http.MethodGet, path: admin("/users/:id/messages"), tag: "messages", summary: "Conversation with a user",
+			security: securityBearer, permission: "messages:read", query: handlers.ListMessagesQuery{},
+			response: service.MessagePage{}},
+		{method: http.MethodGet, path: admin("/messages"), tag: "messages", summary: "Messages of all users",
+			security: securityBearer, permission: "messages:read", query: handlers.ListMessagesQuery{},
+			response: service.MessagePage{}},
+		{method: http.MethodPost, path: admin("/users/:id/messages"), tag: "messages", summary: "Send a message to a user",
+			security: securityBearer, permission: "messages:send", body: handlers.SendMessageRequest{},
+			status: http.StatusCreated, response: database.Message{},
+			errors: map[int]string{http.StatusBadGateway: "Telegram didn't accept the message"}},
+
+		{method: http.MethodGet, path: admin("/bans"), tag: "bans", summary: "List bans",
+			security: securityBearer, permission: "bans:read", query: handlers.ListBansQuery{},
+			response: page(b, "bans", []database.Ban{})},
+		{method: http.MethodPost, path: admin("/bans"), tag: "bans", summary: "Ban a user or chat",
+			security: securityBearer, permission: "bans:write", body: handlers.BanRequest{}, response: database.Ban{}},
+		{method: http.MethodDelete, path: admin("/bans/:telegram_id"), tag: "bans", summary: "Lift a ban",
+			security: securityBearer, permission: "bans:write", status: http.StatusNoContent},
+
+		{method: http.MethodGet, path: admin("/admins"), tag: "admins", summary: "List admins",
+			security: securityBearer, permission: "admins:manage",
+			response: b.Object(map[string]any{"admins": []database.Admin{}})},
+		{method: http.MethodPost, path: admin("/admins"), tag: "admins", summary: "Create an admin",
+			security: securityBearer, permission: "admins:manage", body: handlers.CreateAdminRequest{}, status: http.StatusCreated,
+			response: &openapi.Schema{
+				Type:        "object",
+				Description: "password is present only when it was generated",
+				Properties:  map[string]*openapi.Schema{"admin": b.Schema(database.Admin{}), "password": b.Schema("")},
+				Required:    []string{"admin"},
+			},
+			errors: map[int]string{http.StatusConflict: "Login is taken"}},
+		{method: http.MethodPost, path: admin("/admins/:id/deactivate"), tag: "admins", summary: "Deactivate an admin",
+			security: securityBearer, permission: "admins:manage", response: adminResponse},
+		{method: http.MethodPost, path: admin("/admins/:id/activate"), tag: "admins", summary: "Activate an admin",
+			security: securityBearer, permission: "admins:manage", response: adminResponse},
+		{method: http.MethodPost, path: admin("/admins/:id/unlock"), tag: "admins", summary: "Clear the login lockout",
+			security: securityBearer, permission: "admins:manage", response: adminResponse},
+		{method: http.MethodPost, path: admin("/admins/:id/reset-password"), tag: "admins", summary: "Issue a generated password",
+			security: securityBearer, permission: "admins:manage",
+			response: b.Object(map[string]any{"admin": database.Admin{}, "password": ""})},
+		{method: http.MethodPut, path: admin("/admins/:id/role"), tag: "admins", summary: "Change the role of an admin",
+			security: securityBearer, permission: "admins:manage", body: handlers.SetRoleRequest{}, response: adminResponse},
+		{method: http.MethodPost, path: admin("/admins/:id/reset-2fa"), tag: "admins", summary: "Turn off two-factor authentication",
+			security: securityBearer, permission: "admins:manage", response: adminResponse},
+		{method: http.MethodGet, path: admin("/roles"), tag: "admins", summary: "Roles and all permissions",
+			security: securityBearer, permission: "admins:manage",
+			response: b.Object(map[string]any{"roles": []database.Role{}, "permissions": []string{}})},
+		{method: http.MethodPut, path: admin("/roles/:name"), tag: "admins", summary: "Create or update a role",
+			security: securityBearer, permission: "admins:manage", body: handlers.SaveRoleRequest{},
+			response: b.Object(map[string]any{"role": database.Role{}})},
+	}
+
+	for _, e := range endpoints {
+		b.Add(e.method, e.path, e.operation(b))
+	}
+	return b.Document()
+}
+
+// page is the response of offset-paginated lists.
+func page(b *openapi.Builder, name string, items any) *openapi.Schema {
+	return b.Object(map[string]any{name: items, "total": int64(0), "limit": 0, "offset": 0})
+}
+
+func (e endpoint) operation(b *openapi.Builder) openapi.Operation {
+	op := openapi.Operation{
+		Tags:      []string{e.tag},
+		Summary:   e.summary,
+		Responses: make(map[string]openapi.Response),
+	}
+
+	var notes []string
+	if e.permission != "" {
+		notes = append(notes, fmt.Sprintf("Requires the `%s` permission.", e.permission))
+	}
+	if e.sessionOnly {
+		notes = append(notes, "Requires a login session, API keys are rejected.")
+	}
+	op.Description = strings.Join(notes, " ")
+
+	for _, name := range pathParams(e.path) {
+		schema := &openapi.Schema{Type: "string"}
+		if name == "id" || name == "telegram_id" {
+			schema = &openapi.Schema{Type: "integer", Format: "int64"}
+		}
+		op.Parameters = append(op.Parameters, openapi.Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	if e.query != nil {
+		op.Parameters = append(op.Parameters, b.Query(e.query)...)
+	}
+	op.Parameters = append(op.Parameters, e.params...)
+
+	if e.body != nil {
+		op.RequestBody = b.Body(e.body)
+	}
+	if e.security != "" {
+		op.Security = []map[string][]string{{e.security: {}}}
+	}
+
+	status := e.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := openapi.Response{Description: http.StatusText(status)}
+	if e.response != nil {
+		success.Content = b.JSON(e.response)
+	}
+	op.Responses[fmt.Sprint(status)] = success
+
+	errors := map[int]string{http.StatusInternalServerError: "Internal server error"}
+	if e.body != nil || e.query != nil || len(e.params) > 0 {
+		errors[http.StatusBadRequest] = "Invalid request"
+	}
+	if e.security != "" {
+		errors[http.StatusUnauthorized] = "Authentication required"
+	}
+	if e.permission != "" || e.sessionOnly {
+		errors[http.StatusForbidden] = "Permission denied"
+	}
+	if e.security == securityWebApp {
+		errors[http.StatusForbidden] = "User is banned"
+	}
+	if len(pathParams(e.path)) > 0 {
+		errors[http.StatusNotFound] = "Not found"
+	}
+	for code, description := range e.errors {
+		errors[code] = description
+	}
+	for code, description := range errors {
+		op.Responses[fmt.Sprint(code)] = b.Error(description)
+	}
+	return op
+}
+
+// pathParams returns the names of the parameters of a gin route path.
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			names = append(names, name)
+		}
+	}
+	return slices.Clip(names)
+}
@@ -0,0 +1,388 @@
+// Package openapi builds an OpenAPI 3 document from Go types and serves the bundled docs UI.
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+//go:embed static
+var staticFiles embed.FS
+
+// DocsUI returns the files of the docs UI, which renders the document from /api/openapi.json.
+func DocsUI() fs.FS {
+	sub, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The static directory is embedded at build time, this only fails on a typo in the path.
+		panic(err)
+	}
+	return sub
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Tags       []Tag                           `json:"tags,omitempty"`
+	Paths      map[string]map[string]Operation `json:"paths"` // path -> lowercase method -> operation
+	Components Components                      `json:"components"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations in the docs UI.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the named schemas and security schemes referenced by operations.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how a request is authenticated.
+type SecurityScheme struct {
+	Type         string `json:"type"`             // http or apiKey
+	Scheme       string `json:"scheme,omitempty"` // for http, e.g. bearer
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"` // header name for apiKey
+	In           string `json:"in,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Operation is a single API method on a path.
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security"` // empty means no authentication
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema of a value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// ErrorResponse is the body of every error response of the API.
+type ErrorResponse struct {
+	Error string `json:"error" validate:"required"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	pathParam      = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+	schemaName     = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+)
+
+// Builder collects operations and the schemas of the Go types they use.
+type Builder struct {
+	doc   Document
+	names map[reflect.Type]string
+}
+
+// New creates a Builder for a document with the given metadata.
+func New(info Info) *Builder {
+	b := &Builder{
+		doc: Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]map[string]Operation),
+			Components: Components{
+				Schemas:         make(map[string]*Schema),
+				SecuritySchemes: make(map[string]SecurityScheme),
+			},
+		},
+		names: make(map[reflect.Type]string),
+	}
+	b.Schema(ErrorResponse{})
+	return b
+}
+
+// Document returns the built document.
+func (b *Builder) Document() *Document {
+	return &b.doc
+}
+
+// AddTag adds a tag description.
+func (b *Builder) AddTag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// AddSecurityScheme adds a security scheme operations can refer to by name.
+func (b *Builder) AddSecurityScheme(name string, scheme SecurityScheme) {
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// Add adds an operation. The path uses gin syntax: ":id" and "*path" become path parameters.
+// Path parameters missing in op.Parameters are added as required strings.
+func (b *Builder) Add(method, path string, op Operation) {
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		if !slices.ContainsFunc(op.Parameters, func(p Parameter) bool { return p.In == "path" && p.Name == name }) {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	if op.Security == nil {
+		op.Security = []map[string][]string{}
+	}
+
+	path = PathFromGin(path)
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = make(map[string]Operation)
+	}
+	b.doc.Paths[path][strings.ToLower(method)] = op
+}
+
+// PathFromGin converts a gin route path to an OpenAPI path: "/users/:id" becomes "/users/{id}".
+func PathFromGin(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// JSON returns a request body or response content with the schema of v.
+func (b *Builder) JSON(v any) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: b.Schema(v)}}
+}
+
+// Body returns a required JSON request body with the schema of v.
+func (b *Builder) Body(v any) *RequestBody {
+	return &RequestBody{Required: true, Content: b.JSON(v)}
+}
+
+// Error returns an error response with the ErrorResponse body.
+func (b *Builder) Error(description string) Response {
+	return Response{Description: description, Content: b.JSON(ErrorResponse{})}
+}
+
+// Object returns an inline object schema for responses built from maps: each value is an example
+// of the property type, e.g. Object(map[string]any{"total": int64(0)}).
+func (b *Builder) Object(properties map[string]any) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(properties))}
+	// Sorted order keeps the component names stable when two types share a name.
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		schema.Properties[name] = b.Schema(properties[name])
+		schema.Required = append(schema.Required, name)
+	}
+	return schema
+}
+
+// Query returns the query parameters of a struct with form tags, as bound by gin's ShouldBindQuery.
+func (b *Builder) Query(v any) []Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := b.schema(field.Type)
+		applyValidation(schema, field.Tag.Get("validate"))
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: hasRule(field.Tag.Get("validate"), "required"),
+			Schema:   schema,
+		})
+	}
+	return params
+}
+
+// Schema returns the schema of v. A *Schema is returned as is; named struct types are added to
+// the components and referenced.
+func (b *Builder) Schema(v any) *Schema {
+	if schema, ok := v.(*Schema); ok {
+		return schema
+	}
+	return b.schema(reflect.TypeOf(v))
+}
+
+func (b *Builder) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schema(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return b.ref(t)
+	}
+	// Interfaces and other kinds can hold any value.
+	return &Schema{}
+}
+
+// ref adds a named struct type to the components and returns a reference to it. The name is
+// registered before the fields are resolved, so recursive types work.
+func (b *Builder) ref(t reflect.Type) *Schema {
+	name, ok := b.names[t]
+	if !ok {
+		name = schemaName.ReplaceAllString(t.Name(), "_")
+		if _, taken := b.doc.Components.Schemas[name]; taken {
+			name = schemaName.ReplaceAllString(t.String(), "_")
+		}
+		b.names[t] = name
+		b.doc.Components.Schemas[name] = &Schema{}
+		*b.doc.Components.Schemas[name] = *b.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema describes the JSON encoding of a struct. Embedded structs without a json tag
+// are flattened like encoding/json does; a validate:"required" tag marks a required property.
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := b.structSchema(embedded)
+				for prop, s := range inner.Properties {
+					schema.Properties[prop] = s
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		prop := b.schema(field.Type)
+		applyValidation(prop, field.Tag.Get("validate"))
+		schema.Properties[name] = prop
+		if hasRule(field.Tag.Get("validate"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	slices.Sort(schema.Required)
+	return schema
+}
+
+// applyValidation copies the oneof values of a validate tag into the enum of a string schema.
+func applyValidation(schema *Schema, validate string) {
+	for _, rule := range strings.Split(validate, ",") {
+		if values, ok := strings.CutPrefix(rule, "oneof="); ok && schema.Type == "string" {
+			schema.Enum = strings.Fields(values)
+		}
+	}
+}
+
+func hasRule(validate, rule string) bool {
+	return slices.Contains(strings.Split(validate, ","), rule)
+}
+
+// Handler serves the document as JSON. The document is encoded once.
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		// The document is built from static types, it can't fail to encode.
+		panic(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(body)
+	}
+}
@@ -0,0 +1,126 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testNode struct {
+	testBase
+	Name     string          `json:"name" validate:"required"`
+	Status   string          `json:"status" validate:"omitempty,oneof=open closed"`
+	Note     *string         `json:"note"`
+	Children []*testNode     `json:"children"`
+	Extra    json.RawMessage `json:"extra"`
+	Secret   string          `json:"-"`
+	hidden   string
+}
+
+type testQuery struct {
+	Limit  int    `form:"limit"`
+	Status string `form:"status" validate:"required,oneof=open closed"`
+	Other  string
+}
+
+func TestSchema_Struct(t *testing.T) {
+	b := New(Info{Title: "test", Version: "1"})
+
+	ref := b.Schema(testNode{})
+	assert.Equal(t, "#/components/schemas/testNode", ref.Ref)
+
+	schema := b.Document().Components.Schemas["testNode"]
+	require.NotNil(t, schema)
+	assert.Equal(t, "object", schema.Type)
+	assert.ElementsMatch(t, []string{"id", "created_at", "name", "status", "note", "children", "extra"}, keys(schema.Properties))
+	assert.Equal(t, []string{"name"}, schema.Required)
+
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, schema.Properties["id"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["created_at"])
+	assert.Equal(t, []string{"open", "closed"}, schema.Properties["status"].Enum)
+	assert.True(t, schema.Properties["note"].Nullable)
+	assert.Equal(t, &Schema{}, schema.Properties["extra"])
+
+	// Рекурсивный тип ссылается сам на себя
+	assert.Equal(t, "array", schema.Properties["children"].Type)
+	assert.Equal(t, "#/components/schemas/testNode", schema.Properties["children"].Items.Ref)
+}
+
+func TestObject(t *testing.T) {
+	b := New(Info{})
+
+	schema := b.Object(map[string]any{"items": []testBase{}, "total": int64(0)})
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"items", "total"}, schema.Required)
+	assert.Equal(t, "#/components/schemas/testBase", schema.Properties["items"].Items.Ref)
+
+	custom := &Schema{Type: "object", Description: "custom"}
+	assert.Same(t, custom, b.Schema(custom))
+}
+
+func TestQuery(t *testing.T) {
+	params := New(Info{}).Query(testQuery{})
+
+	require.Len(t, params, 2)
+	assert.Equal(t, Parameter{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Format: "int32"}}, params[0])
+	assert.Equal(t, "status", params[1].Name)
+	assert.True(t, params[1].Required)
+	assert.Equal(t, []string{"open", "closed"}, params[1].Schema.Enum)
+}
+
+func TestAdd(t *testing.T) {
+	b := New(Info{})
+	b.Add(http.MethodGet, "/items/:id/files/*path", Operation{
+		Parameters: []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer"}}},
+		Responses:  map[string]Response{"200": {Description: "OK"}},
+	})
+
+	op, ok := b.Document().Paths["/items/{id}/files/{path}"]["get"]
+	require.True(t, ok)
+	require.Len(t, op.Parameters, 2)
+	assert.Equal(t, "integer", op.Parameters[0].Schema.Type)
+	assert.Equal(t, Parameter{Name: "path", In: "path", Required: true, Schema: &Schema{Type: "string"}}, op.Parameters[1])
+	assert.NotNil(t, op.Security, "security must be explicit so that public operations don't inherit a global one")
+}
+
+func TestHandler(t *testing.T) {
+	b := New(Info{Title: "test", Version: "1"})
+	b.Add(http.MethodGet, "/health", Operation{Responses: map[string]Response{"200": {Description: "OK"}}})
+
+	w := httptest.NewRecorder()
+	Handler(b.Document())(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	var doc Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Contains(t, doc.Paths, "/health")
+	assert.Contains(t, doc.Components.Schemas, "ErrorResponse")
+}
+
+func TestDocsUI(t *testing.T) {
+	for _, name := range []string{"index.html", "docs.js", "docs.css"} {
+		_, err := fs.Stat(DocsUI(), name)
+		assert.NoError(t, err, name)
+	}
+}
+
+func keys(m map[string]*Schema) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,195 @@
+// Простой просмотр openapi.json: операции по тегам, схемы и форма для отправки запроса.
+(function () {
+    const specURL = new URL('../openapi.json', location.href);
+    const tokenInput = document.getElementById('token');
+    let spec;
+
+    tokenInput.value = localStorage.getItem('apiDocsToken') || '';
+    tokenInput.addEventListener('change', () => localStorage.setItem('apiDocsToken', tokenInput.value));
+
+    function el(tag, attrs, ...children) {
+        const node = document.createElement(tag);
+        Object.assign(node, attrs || {});
+        for (const child of children) {
+            node.append(child);
+        }
+        return node;
+    }
+
+    // resolve раскрывает $ref; seen защищает от рекурсивных схем.
+    function resolve(schema, seen) {
+        if (!schema || !schema.$ref) {
+            return { schema, seen };
+        }
+        const name = schema.$ref.split('/').pop();
+        if (seen.has(name)) {
+            return { schema: { type: name + ' (recursive)' }, seen };
+        }
+        return { schema: spec.components.schemas[name], seen: new Set(seen).add(name) };
+    }
+
+    // example строит пример значения по схеме.
+    function example(schema, seen = new Set()) {
+        ({ schema, seen } = resolve(schema, seen));
+        if (!schema) {
+            return null;
+        }
+        if (schema.enum) {
+            return schema.enum[0];
+        }
+        if (schema.oneOf) {
+            return example(schema.oneOf[0], seen);
+        }
+        switch (schema.type) {
+        case 'object': {
+            const obj = {};
+            for (const [name, prop] of Object.entries(schema.properties || {})) {
+                obj[name] = example(prop, seen);
+            }
+            return obj;
+        }
+        case 'array':
+            return [example(schema.items, seen)];
+        case 'integer':
+        case 'number':
+            return 0;
+        case 'boolean':
+            return false;
+        case 'string':
+            return schema.format === 'date-time' ? new Date().toISOString() : '';
+        default:
+            return null;
+        }
+    }
+
+    function schemaBlock(schema) {
+        return el('pre', { textContent: JSON.stringify(example(schema), null, 2) });
+    }
+
+    function parametersTable(params) {
+        const table = el('table', {}, el('tr', {}, el('th', { textContent: 'name' }),
+            el('th', { textContent: 'in' }), el('th', { textContent: 'type' }), el('th', { textContent: '' })));
+        for (const p of params) {
+            table.append(el('tr', {}, el('td', { textContent: p.name }), el('td', { textContent: p.in }),
+                el('td', { textContent: (p.schema.type || '') + (p.schema.enum ? ' ' + p.schema.enum.join('|') : '') }),
+                el('td', { textContent: p.required ? 'required' : '' })));
+        }
+        return table;
+    }
+
+    function tryForm(method, path, op) {
+        const form = el('form', { className: 'try' });
+        const inputs = {};
+        for (const p of op.parameters || []) {
+            inputs[p.name] = el('input', { name: p.name });
+            form.append(el('label', { textContent: p.name + ' ' }, inputs[p.name]));
+        }
+        let body;
+        if (op.requestBody) {
+            body = el('textarea', { value: JSON.stringify(example(op.requestBody.content['application/json'].schema), null, 2) });
+            form.append(body);
+        }
+        const output = el('pre', { hidden: true });
+        form.append(el('button', { type: 'submit', textContent: 'Отправить' }), output);
+
+        form.addEventListener('submit', async (event) => {
+            event.preventDefault();
+            let url = path;
+            const query = new URLSearchParams();
+            for (const p of op.parameters || []) {
+                const value = inputs[p.name].value;
+                if (p.in === 'path') {
+                    url = url.replace('{' + p.name + '}', encodeURIComponent(value));
+                } else if (p.in === 'query' && value !== '') {
+                    query.set(p.name, value);
+                }
+            }
+            if (query.size > 0) {
+                url += '?' + query;
+            }
+            const headers = { 'Content-Type': 'application/json' };
+            if (tokenInput.value) {
+                headers.Authorization = 'Bearer ' + tokenInput.value;
+            }
+            output.hidden = false;
+            try {
+                const resp = await fetch(url, { method: method.toUpperCase(), headers, body: body ? body.value : undefined });
+                const text = await resp.text();
+                let pretty = text;
+                try {
+                    pretty = JSON.stringify(JSON.parse(text), null, 2);
+                } catch (e) {
+                    // не JSON, показываем как есть
+                }
+                output.textContent = resp.status + ' ' + resp.statusText + '\n\n' + pretty;
+            } catch (e) {
+                output.textContent = String(e);
+            }
+        });
+        return form;
+    }
+
+    function operation(method, path, op) {
+        const content = el('div', { className: 'operation' });
+        if (op.description) {
+            content.append(el('p', { textContent: op.description }));
+        }
+        if (op.security.length > 0) {
+            content.append(el('p', { textContent: 'Авторизация: ' + op.security.flatMap(Object.keys).join(', ') }));
+        }
+        if (op.parameters && op.parameters.length > 0) {
+            content.append(el('h4', { textContent: 'Параметры' }), parametersTable(op.parameters));
+        }
+        if (op.requestBody) {
+            content.append(el('h4', { textContent: 'Тело запроса' }), schemaBlock(op.requestBody.content['application/json'].schema));
+        }
+        content.append(el('h4', { textContent: 'Ответы' }));
+        for (const [status, resp] of Object.entries(op.responses)) {
+            content.append(el('p', { textContent: status + ' — ' + resp.description }));
+            if (resp.content && !status.startsWith('4') && !status.startsWith('5')) {
+                content.append(schemaBlock(resp.content['application/json'].schema));
+            }
+        }
+        content.append(el('h4', { textContent: 'Попробовать' }), tryForm(method, path, op));
+
+        return el('details', {}, el('summary', {},
+            el('span', { className: 'method ' + method, textContent: method.toUpperCase() }),
+            el('span', { className: 'path', textContent: path }),
+            el('span', { className: 'summary', textContent: op.summary || '' })), content);
+    }
+
+    function render() {
+        document.title = spec.info.title;
+        document.getElementById('title').textContent = spec.info.title + ' ' + spec.info.version;
+        document.getElementById('description').textContent = spec.info.description || '';
+
+        const main = document.getElementById('tags');
+        const sections = new Map();
+        for (const tag of spec.tags || []) {
+            const section = el('section', {}, el('h2', {}, tag.name + ' ', el('small', { textContent: tag.description || '' })));
+            sections.set(tag.name, section);
+            main.append(section);
+        }
+        for (const path of Object.keys(spec.paths).sort()) {
+            for (const [method, op] of Object.entries(spec.paths[path])) {
+                const tag = (op.tags || ['other'])[0];
+                if (!sections.has(tag)) {
+                    const section = el('section', {}, el('h2', { textContent: tag }));
+                    sections.set(tag, section);
+                    main.append(section);
+                }
+                sections.get(tag).append(operation(method, path, op));
+            }
+        }
+    }
+
+    fetch(specURL)
+        .then((resp) => resp.json())
+        .then((doc) => {
+            spec = doc;
+            render();
+        })
+        .catch((e) => {
+            document.getElementById('tags').textContent = 'Не удалось загрузить ' + specURL + ': ' + e;
+        });
+})();
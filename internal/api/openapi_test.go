@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-bot/internal/api/openapi"
+	"go-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAPI_CoversRoutes сверяет маршруты роутера со спецификацией в обе стороны,
+// чтобы новый эндпоинт нельзя было добавить без описания в apiDocument.
+func TestOpenAPI_CoversRoutes(t *testing.T) {
+	server := NewServer(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, &config.Config{}, nil, nil, nil, nil)
+	doc := apiDocument()
+
+	routes := make(map[string]bool)
+	for _, route := range server.router.Routes() {
+		// Статические файлы не входят в спецификацию
+		if strings.HasSuffix(route.Path, "/*filepath") {
+			continue
+		}
+		key := route.Method + " " + openapi.PathFromGin(route.Path)
+		routes[key] = true
+		path, ok := doc.Paths[openapi.PathFromGin(route.Path)]
+		assert.True(t, ok && path[strings.ToLower(route.Method)].Responses != nil, "route %s is missing in the OpenAPI document", key)
+	}
+
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			key := strings.ToUpper(method) + " " + path
+			assert.True(t, routes[key], "documented operation %s is not registered", key)
+		}
+	}
+}
+
+func TestOpenAPI_Operations(t *testing.T) {
+	doc := apiDocument()
+
+	users := doc.Paths["/api/admin/users/{id}"]["patch"]
+	require.NotNil(t, users.Responses)
+	assert.Equal(t, []map[string][]string{{securityBearer: {}}}, users.Security)
+	assert.Contains(t, users.Description, "users:write")
+	require.NotEmpty(t, users.Parameters)
+	assert.Equal(t, "id", users.Parameters[0].Name)
+	assert.Equal(t, "integer", users.Parameters[0].Schema.Type)
+	for _, status := range []string{"200", "400", "401", "403", "404", "500"} {
+		assert.Contains(t, users.Responses, status)
+	}
+
+	login := doc.Paths["/api/admin/login"]["post"]
+	assert.Empty(t, login.Security, "login must be public")
+	assert.NotNil(t, login.RequestBody)
+	assert.Contains(t, login.Responses, "423")
+	// Ответ входа - токены или challenge для второго шага
+	loginResponse := login.Responses["200"].Content["application/json"].Schema
+	require.Len(t, loginResponse.OneOf, 2)
+	assert.Equal(t, "#/components/schemas/TokenResponse", loginResponse.OneOf[0].Ref)
+	assert.Equal(t, "#/components/schemas/ChallengeResponse", loginResponse.OneOf[1].Ref)
+
+	apiKeys := doc.Paths["/api/admin/api-keys"]["post"]
+	assert.Contains(t, apiKeys.Description, "API keys are rejected")
+	assert.Contains(t, apiKeys.Responses, "201")
+
+	webApp := doc.Paths["/api/webapp/me"]["get"]
+	assert.Equal(t, []map[string][]string{{securityWebApp: {}}}, webApp.Security)
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	cfg := &config.Config{RateLimitRequests: 100, RateLimitWindowMinutes: 1}
+	server := NewServer(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, cfg, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, APIPrefix+OpenAPIPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, openapi.Version, doc["openapi"])
+
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, APIPrefix+DocsPath+"/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "docs.js")
+}
@@ -2,16 +2,22 @@ package api
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"go-bot/internal/api/apierror"
 	"go-bot/internal/api/handlers"
 	"go-bot/internal/api/middleware"
+	"go-bot/internal/api/openapi"
+	"go-bot/internal/auth"
 	"go-bot/internal/config"
 	"go-bot/internal/service"
 	"go-bot/internal/services"
+	"go-bot/internal/traffic"
+	"go-bot/internal/webapp"
 
 	"github.com/gin-gonic/gin"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -21,8 +27,14 @@ import (
 const (
 	// APIPrefix is the prefix for all API routes.
 	APIPrefix = "/api"
+	// WebAppPath is the path of the Telegram Mini App frontend.
+	WebAppPath = "/webapp"
 	// WebhookPath is the path for the Telegram webhook, relative to APIPrefix.
 	WebhookPath = "/webhook"
+	// OpenAPIPath is the path of the OpenAPI document, relative to APIPrefix.
+	OpenAPIPath = "/openapi.json"
+	// DocsPath is the path of the API docs UI, relative to APIPrefix.
+	DocsPath = "/docs"
 )
 
 // Server is the main application server.
@@ -34,11 +46,16 @@ type Server struct {
 	bot        *tgbotapi.BotAPI
 	cfg        *config.Config
 	xuiService *service.XUIService
+	payments   *service.PaymentService
+	bans       *service.BanService
+	updates    handlers.UpdateEnqueuer
 	httpServer *http.Server
 }
 
-// NewServer creates a new server instance.
-func NewServer(logger *slog.Logger, db *gorm.DB, bot *tgbotapi.BotAPI, cfg *config.Config, xuiService *service.XUIService) *Server {
+// NewServer creates a new server instance. payments and bans are shared with the bot, so both
+// use the plans validated at startup and a ban made through the API drops the cached bans at once.
+func NewServer(logger *slog.Logger, db *gorm.DB, bot *tgbotapi.BotAPI, cfg *config.Config, xuiService *service.XUIService,
+	payments *service.PaymentService, bans *service.BanService, updates handlers.UpdateEnqueuer) *Server {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 
@@ -49,6 +66,9 @@ func NewServer(logger *slog.Logger, db *gorm.DB, bot *tgbotapi.BotAPI, cfg *conf
 		bot:        bot,
 		cfg:        cfg,
 		xuiService: xuiService,
+		payments:   payments,
+		bans:       bans,
+		updates:    updates,
 		httpServer: &http.Server{
 			Addr:    fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
 			Handler: router,
@@ -72,30 +92,163 @@ func (s *Server) setupRouter() {
 	s.router.GET("/health", healthHandler.HealthCheck)
 	s.router.GET("/ready", healthHandler.ReadinessCheck)
 
+	// Telegram Mini App frontend
+	s.router.StaticFS(WebAppPath, http.FS(webapp.Static()))
+
 	// API group with rate limiting
+	rateLimit := middleware.RateLimitMiddleware(s.cfg)
 	api := s.router.Group(APIPrefix)
-	api.Use(middleware.RateLimitMiddleware(s.cfg))
+	api.Use(rateLimit)
 	{
+		// Документация API: спецификация OpenAPI и страница для ее просмотра
+		api.GET(OpenAPIPath, gin.WrapF(openapi.Handler(apiDocument())))
+		api.StaticFS(DocsPath, http.FS(openapi.DocsUI()))
+
 		// Создаем сервисы
 		adminService := services.NewAdminService(s.db, s.logger)
+		sessionService := services.NewSessionService(s.db, s.cfg.JWTSecretKey,
+			time.Duration(s.cfg.JWTAccessTTLMinutes)*time.Minute, time.Duration(s.cfg.JWTRefreshTTLHours)*time.Hour,
+			time.Duration(s.cfg.JWTSessionMaxAgeHours)*time.Hour, s.logger)
+		apiKeyService := services.NewAPIKeyService(s.db, s.logger)
+		referralService := service.NewReferralService(s.db, s.xuiService, s.logger)
+		supportService := service.NewSupportService(s.db)
+		auditService := service.NewAuditService(s.db)
+		linkService := service.NewClientLinkService(s.db)
+		userService := service.NewUserService(s.db)
+		messageService := service.NewMessageService(s.db)
+		history := traffic.NewHistory(s.db, traffic.PanelName(s.cfg.XUIURL))
 
 		// Handlers
-		adminHandler := handlers.NewAdminHandler(adminService, s.logger, s.cfg.JWTSecretKey)
-		webhookHandler := handlers.NewWebhookHandler(s.cfg, s.logger, s.bot, s.db)
+		adminHandler := handlers.NewAdminHandler(adminService, sessionService, s.logger)
+		adminAccountHandler := handlers.NewAdminAccountHandler(adminService, auditService, s.logger)
+		apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, auditService, s.logger)
+		webhookHandler := handlers.NewWebhookHandler(s.cfg, s.logger, s.updates)
+		referralHandler := handlers.NewReferralHandler(referralService, s.logger)
+		ticketHandler := handlers.NewTicketHandler(supportService, s.logger)
+		banHandler := handlers.NewBanHandler(s.bans, auditService, s.logger)
+		userHandler := handlers.NewUserHandler(userService, linkService, s.xuiService, s.bans, auditService, s.logger)
+		messageHandler := handlers.NewMessageHandler(messageService, userService, s.bot, auditService, s.logger)
+		paymentHandler := handlers.NewPaymentHandler(s.payments, auditService, s.logger)
+		webAppHandler := handlers.NewWebAppHandler(linkService, s.xuiService, history, s.payments, s.cfg.XUISubURL, s.logger)
+
+		// Метрики приложения (expvar) содержат cmdline и memstats, поэтому закрыты авторизацией
+		s.router.GET("/debug/vars", rateLimit,
+			middleware.AuthMiddleware(s.cfg.JWTSecretKey, sessionService, apiKeyService),
+			middleware.RequirePermission(auth.PermMonitoringRead),
+			gin.WrapH(expvar.Handler()))
 
 		// Webhook for Telegram
 		api.POST(WebhookPath, apierror.ErrorWrapper(webhookHandler.HandleWebhook))
 
+		// Telegram Mini App, authenticated by initData; banned users are rejected as in the bot
+		webApp := api.Group("/webapp",
+			middleware.WebAppAuth(s.cfg.TelegramToken, time.Duration(s.cfg.WebAppAuthMaxAgeHours)*time.Hour),
+			middleware.WebAppBanCheck(s.bans, s.logger))
+		{
+			webApp.GET("/me", apierror.ErrorWrapper(webAppHandler.Me))
+			webApp.GET("/usage", apierror.ErrorWrapper(webAppHandler.Usage))
+			webApp.GET("/plans", apierror.ErrorWrapper(webAppHandler.Plans))
+		}
+
 		// Admin routes
 		admin := api.Group("/admin")
 		{
 			admin.POST("/login", apierror.ErrorWrapper(adminHandler.Login))
+			admin.POST("/login/verify", apierror.ErrorWrapper(adminHandler.VerifyLogin))
+			admin.POST("/refresh", apierror.ErrorWrapper(adminHandler.Refresh))
 
 			// Protected routes
-			authRequired := admin.Group("/", middleware.AuthMiddleware(s.cfg.JWTSecretKey)) // Используем middleware.JWT
+			authRequired := admin.Group("/", middleware.AuthMiddleware(s.cfg.JWTSecretKey, sessionService, apiKeyService)) // JWT или API-ключ
 			{
 				authRequired.GET("/profile", apierror.ErrorWrapper(adminHandler.GetProfile))
-				authRequired.POST("/change-password", apierror.ErrorWrapper(adminHandler.ChangePassword))
+
+				// Учетная запись администратора доступна только по токену сессии, не по API-ключу
+				account := authRequired.Group("", middleware.RequireSession())
+				{
+					account.POST("/logout", apierror.ErrorWrapper(adminHandler.Logout))
+					account.POST("/change-password", apierror.ErrorWrapper(adminHandler.ChangePassword))
+					account.POST("/2fa/setup", apierror.ErrorWrapper(adminHandler.SetupTwoFactor))
+					account.POST("/2fa/enable", apierror.ErrorWrapper(adminHandler.EnableTwoFactor))
+					account.POST("/2fa/disable", apierror.ErrorWrapper(adminHandler.DisableTwoFactor))
+					account.POST("/2fa/recovery-codes", apierror.ErrorWrapper(adminHandler.RegenerateRecoveryCodes))
+					account.GET("/api-keys", apierror.ErrorWrapper(apiKeyHandler.ListAPIKeys))
+					account.POST("/api-keys", apierror.ErrorWrapper(apiKeyHandler.CreateAPIKey))
+					account.DELETE("/api-keys/:id", apierror.ErrorWrapper(apiKeyHandler.RevokeAPIKey))
+				}
+
+				// Остальные маршруты доступны по разрешениям роли администратора
+				referralsRead := authRequired.Group("/referrals", middleware.RequirePermission(auth.PermReferralsRead))
+				{
+					referralsRead.GET("/settings", apierror.ErrorWrapper(referralHandler.GetSettings))
+					referralsRead.GET("/tree", apierror.ErrorWrapper(referralHandler.GetTree))
+				}
+				referralsWrite := authRequired.Group("/referrals", middleware.RequirePermission(auth.PermReferralsWrite))
+				{
+					referralsWrite.PUT("/settings", apierror.ErrorWrapper(referralHandler.UpdateSettings))
+				}
+
+				ticketsRead := authRequired.Group("/tickets", middleware.RequirePermission(auth.PermTicketsRead))
+				{
+					ticketsRead.GET("", apierror.ErrorWrapper(ticketHandler.ListTickets))
+					ticketsRead.GET("/:id", apierror.ErrorWrapper(ticketHandler.GetTicket))
+				}
+				ticketsWrite := authRequired.Group("/tickets", middleware.RequirePermission(auth.PermTicketsWrite))
+				{
+					ticketsWrite.POST("/:id/close", apierror.ErrorWrapper(ticketHandler.CloseTicket))
+				}
+
+				paymentsRead := authRequired.Group("/payments", middleware.RequirePermission(auth.PermPaymentsRead))
+				{
+					paymentsRead.GET("", apierror.ErrorWrapper(paymentHandler.ListPayments))
+				}
+				paymentsWrite := authRequired.Group("/payments", middleware.RequirePermission(auth.PermPaymentsWrite))
+				{
+					paymentsWrite.POST("/:id/apply", apierror.ErrorWrapper(paymentHandler.ApplyPayment))
+				}
+
+				usersRead := authRequired.Group("/users", middleware.RequirePermission(auth.PermUsersRead))
+				{
+					usersRead.GET("", apierror.ErrorWrapper(userHandler.ListUsers))
+					usersRead.GET("/:id", apierror.ErrorWrapper(userHandler.GetUser))
+				}
+				usersWrite := authRequired.Group("/users", middleware.RequirePermission(auth.PermUsersWrite))
+				{
+					usersWrite.PATCH("/:id", apierror.ErrorWrapper(userHandler.UpdateUser))
+				}
+
+				messagesRead := authRequired.Group("", middleware.RequirePermission(auth.PermMessagesRead))
+				{
+					messagesRead.GET("/users/:id/messages", apierror.ErrorWrapper(messageHandler.ListUserMessages))
+					messagesRead.GET("/messages", apierror.ErrorWrapper(messageHandler.ListMessages))
+				}
+				messagesSend := authRequired.Group("", middleware.RequirePermission(auth.PermMessagesSend))
+				{
+					messagesSend.POST("/users/:id/messages", apierror.ErrorWrapper(messageHandler.SendMessage))
+				}
+
+				bansRead := authRequired.Group("/bans", middleware.RequirePermission(auth.PermBansRead))
+				{
+					bansRead.GET("", apierror.ErrorWrapper(banHandler.ListBans))
+				}
+				bansWrite := authRequired.Group("/bans", middleware.RequirePermission(auth.PermBansWrite))
+				{
+					bansWrite.POST("", apierror.ErrorWrapper(banHandler.CreateBan))
+					bansWrite.DELETE("/:telegram_id", apierror.ErrorWrapper(banHandler.DeleteBan))
+				}
+
+				admins := authRequired.Group("", middleware.RequirePermission(auth.PermAdminsManage))
+				{
+					admins.GET("/admins", apierror.ErrorWrapper(adminAccountHandler.ListAdmins))
+					admins.POST("/admins", apierror.ErrorWrapper(adminAccountHandler.CreateAdmin))
+					admins.POST("/admins/:id/deactivate", apierror.ErrorWrapper(adminAccountHandler.DeactivateAdmin))
+					admins.POST("/admins/:id/activate", apierror.ErrorWrapper(adminAccountHandler.ActivateAdmin))
+					admins.POST("/admins/:id/unlock", apierror.ErrorWrapper(adminAccountHandler.UnlockAdmin))
+					admins.POST("/admins/:id/reset-password", apierror.ErrorWrapper(adminAccountHandler.ResetPassword))
+					admins.PUT("/admins/:id/role", apierror.ErrorWrapper(adminAccountHandler.SetRole))
+					admins.POST("/admins/:id/reset-2fa", apierror.ErrorWrapper(adminAccountHandler.ResetTwoFactor))
+					admins.GET("/roles", apierror.ErrorWrapper(adminAccountHandler.ListRoles))
+					admins.PUT("/roles/:name", apierror.ErrorWrapper(adminAccountHandler.SaveRole))
+				}
 			}
 		}
 	}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugVars_RequiresAuth(t *testing.T) {
+	cfg := &config.Config{RateLimitRequests: 100, RateLimitWindowMinutes: 1, JWTSecretKey: "test-secret-key-with-32-characters!!"}
+	server := NewServer(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, cfg, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotContains(t, w.Body.String(), "cmdline")
+}
@@ -1,10 +1,17 @@
+//go:build integration
+
 package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"go-bot/internal/api/handlers"
+	"go-bot/internal/bot"
 	"go-bot/internal/config"
+	"go-bot/internal/queue"
 	"go-bot/internal/service"
+	"go-bot/internal/services"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -24,7 +31,7 @@ func TestWebhookHandler_Integration_GetClientCommand(t *testing.T) {
 	require.NoError(t, err, "Failed to load .env file")
 
 	// Инициализируем конфигурацию
-	cfg, err := config.Load("../../deploy/.env")
+	cfg, err := config.Load()
 	require.NoError(t, err, "Failed to load .env config")
 
 	// Проверяем, что необходимые для теста переменные установлены
@@ -43,8 +50,11 @@ func TestWebhookHandler_Integration_GetClientCommand(t *testing.T) {
 	tgBot, err := tgbotapi.NewBotAPI(cfg.TelegramToken)
 	require.NoError(t, err)
 
-	// Создаем сервер (без БД, так как для этой команды она не нужна)
-	server := NewServer(logger, nil, tgBot, cfg, xuiService)
+	// Создаем очередь и сервер (без БД, так как для этой команды она не нужна)
+	botHandler := bot.NewHandler(bot.Deps{Bot: tgBot, XUI: xuiService, AdminIDs: []int64{98765}})
+	updateQueue := queue.New(services.NewWebhookService(botHandler, logger), queue.Options{Logger: logger})
+	require.NoError(t, updateQueue.Start(context.Background()))
+	server := NewServer(logger, nil, tgBot, cfg, xuiService, nil, nil, updateQueue)
 
 	// --- Подготовка тестового запроса ---
 
@@ -71,6 +81,7 @@ func TestWebhookHandler_Integration_GetClientCommand(t *testing.T) {
 	url := APIPrefix + WebhookPath
 	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handlers.SecretTokenHeader, cfg.WebhookSecret)
 
 	// Создаем рекордер для записи ответа
 	rr := httptest.NewRecorder()
@@ -86,5 +97,8 @@ func TestWebhookHandler_Integration_GetClientCommand(t *testing.T) {
 
 	// В логах приложения мы должны увидеть результат отправки сообщения ботом.
 	// Для этого теста достаточно убедиться, что обработчик отработал без паники и вернул OK.
+	// Дожидаемся обработки обновления воркером очереди.
+	require.NoError(t, updateQueue.Shutdown(context.Background()))
+
 	t.Log("Webhook handler successfully processed the /getclient command.")
 }
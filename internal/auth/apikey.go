@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// APIKeyPrefix - начало всех API-ключей, по нему AuthMiddleware отличает ключ от JWT,
+// а сканеры секретов находят ключи в коде и логах.
+const APIKeyPrefix = "gbk_"
+
+// apiKeyEncoding - base32 в нижнем регистре без '_', чтобы ключ однозначно делился на части.
+var apiKeyEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// GenerateAPIKey создает API-ключ вида gbk_<prefix>_<secret>. Prefix хранится открыто и
+// показывается в списке ключей, по нему ключ находится в базе; сам ключ хранится только
+// в виде HashAPIKey.
+func GenerateAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, 5+20) // 8 символов префикса и 160 бит секрета
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	prefix = apiKeyEncoding.EncodeToString(buf[:5])
+	return APIKeyPrefix + prefix + "_" + apiKeyEncoding.EncodeToString(buf[5:]), prefix, nil
+}
+
+// IsAPIKey сообщает, похожа ли строка из заголовка Authorization на API-ключ, а не на JWT.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ParseAPIKey возвращает префикс API-ключа. ok равен false, если ключ имеет неверный формат.
+func ParseAPIKey(key string) (prefix string, ok bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 8 || len(secret) != 32 {
+		return "", false
+	}
+	return prefix, true
+}
+
+// HashAPIKey возвращает hex SHA-256 API-ключа. У ключа 160 случайных бит, поэтому
+// медленный хеш вроде bcrypt не нужен, а проверка на каждом запросе остается быстрой.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
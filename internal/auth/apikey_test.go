@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^gbk_[a-z2-7]{8}_[a-z2-7]{32}$`, key)
+	assert.True(t, IsAPIKey(key))
+	parsed, ok := ParseAPIKey(key)
+	require.True(t, ok)
+	assert.Equal(t, prefix, parsed)
+
+	other, _, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+	assert.NotEqual(t, HashAPIKey(key), HashAPIKey(other))
+	assert.Len(t, HashAPIKey(key), 64)
+}
+
+func TestParseAPIKey(t *testing.T) {
+	testCases := []string{
+		"",
+		"eyJhbGciOiJIUzI1NiJ9.e30.signature",
+		"gbk_",
+		"gbk_abcdefgh",
+		"gbk_abcdefgh_short",
+		"gbk_abc_" + strings.Repeat("a", 32),
+		"xyz_abcdefgh_" + strings.Repeat("a", 32),
+	}
+
+	for _, key := range testCases {
+		_, ok := ParseAPIKey(key)
+		assert.False(t, ok, key)
+	}
+	assert.False(t, IsAPIKey("eyJhbGciOiJIUzI1NiJ9.e30.signature"))
+}
@@ -9,27 +9,41 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	// TokenIssuer - издатель (iss) токенов администраторов.
+	TokenIssuer = "goooo-admin"
+	// TokenAudience - получатель (aud) токенов администраторов.
+	TokenAudience = "admin-panel"
+	// ChallengeAudience - получатель (aud) токенов второго шага входа. Другой получатель не дает
+	// использовать такой токен вместо access-токена.
+	ChallengeAudience = "admin-2fa"
+)
+
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token expired")
 )
 
 type Claims struct {
-	AdminID uint64 `json:"admin_id"`
-	Login   string `json:"login"`
+	AdminID     uint64   `json:"admin_id"`
+	Login       string   `json:"login"`
+	Permissions []string `json:"perms"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken создает JWT токен для администратора
-func GenerateToken(admin *database.Admin, secretKey string, expiresIn time.Duration) (string, error) {
+// GenerateToken создает JWT токен для администратора. tokenID (jti) связывает токен с сессией,
+// по нему проверяется отзыв. В токен попадают разрешения роли, поэтому роль должна быть загружена.
+func GenerateToken(admin *database.Admin, tokenID, secretKey string, expiresIn time.Duration) (string, error) {
 	now := time.Now()
 	claims := Claims{
-		AdminID: admin.ID,
-		Login:   admin.Login,
+		AdminID:     admin.ID,
+		Login:       admin.Login,
+		Permissions: RolePermissions(admin.Role),
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "goooo-admin",
+			ID:        tokenID,
+			Issuer:    TokenIssuer,
 			Subject:   admin.Login,
-			Audience:  []string{"admin-panel"},
+			Audience:  []string{TokenAudience},
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -40,15 +54,49 @@ func GenerateToken(admin *database.Admin, secretKey string, expiresIn time.Durat
 	return token.SignedString([]byte(secretKey))
 }
 
-// ValidateToken валидирует JWT токен и возвращает claims
+// ValidateToken валидирует JWT токен и возвращает claims. Кроме подписи и срока действия
+// проверяются издатель и получатель, чтобы не принимать токены, выпущенные для других целей
+// с тем же секретом.
 func ValidateToken(tokenString string, secretKey string) (*Claims, error) {
+	return parseToken(tokenString, secretKey, TokenAudience)
+}
+
+// GenerateChallengeToken создает короткоживущий токен второго шага входа для администратора
+// с двухфакторной аутентификацией: пароль уже проверен, осталось проверить код.
+func GenerateChallengeToken(adminID uint64, secretKey string, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		AdminID: adminID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    TokenIssuer,
+			Audience:  []string{ChallengeAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secretKey))
+}
+
+// ValidateChallengeToken валидирует токен второго шага входа и возвращает ID администратора.
+func ValidateChallengeToken(tokenString string, secretKey string) (uint64, error) {
+	claims, err := parseToken(tokenString, secretKey, ChallengeAudience)
+	if err != nil {
+		return 0, err
+	}
+	return claims.AdminID, nil
+}
+
+func parseToken(tokenString, secretKey, audience string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		// Проверяем алгоритм подписи
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
 		return []byte(secretKey), nil
-	})
+	}, jwt.WithIssuer(TokenIssuer), jwt.WithAudience(audience), jwt.WithExpirationRequired())
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -57,7 +105,7 @@ func ValidateToken(tokenString string, secretKey string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.AdminID != 0 {
 		return claims, nil
 	}
 
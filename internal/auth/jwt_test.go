@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-bot/internal/database"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret-for-jwt-token-generation"
+
+func signClaims(t *testing.T, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestValidateToken(t *testing.T) {
+	admin := &database.Admin{
+		ID:    7,
+		Login: "admin",
+		Role:  &database.Role{Name: "support", Permissions: database.StringList{PermUsersRead, PermTicketsRead}},
+	}
+	token, err := GenerateToken(admin, "token-id", testSecret, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(token, testSecret)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), claims.AdminID)
+	assert.Equal(t, "admin", claims.Login)
+	assert.Equal(t, "token-id", claims.ID)
+	assert.Equal(t, []string{PermUsersRead, PermTicketsRead}, claims.Permissions)
+
+	_, err = ValidateToken(token, "other-secret")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := GenerateToken(&database.Admin{ID: 7, Login: "admin"}, "token-id", testSecret, -time.Minute)
+	require.NoError(t, err)
+	_, err = ValidateToken(expired, testSecret)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestGenerateToken_SuperadminHasAllPermissions(t *testing.T) {
+	// Разрешения superadmin не берутся из базы: роль получает и разрешения, добавленные позже.
+	admin := &database.Admin{ID: 1, Login: "root", Role: &database.Role{Name: SuperadminRole, Permissions: database.StringList{PermUsersRead}}}
+	token, err := GenerateToken(admin, "token-id", testSecret, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(token, testSecret)
+	require.NoError(t, err)
+	assert.Equal(t, Permissions, claims.Permissions)
+	assert.Contains(t, claims.Permissions, PermMonitoringRead)
+}
+
+func TestValidateToken_IssuerAndAudience(t *testing.T) {
+	valid := func() Claims {
+		return Claims{
+			AdminID: 7,
+			Login:   "admin",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    TokenIssuer,
+				Audience:  []string{TokenAudience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(*Claims)
+		valid  bool
+	}{
+		{name: "valid", modify: func(*Claims) {}, valid: true},
+		{name: "wrong issuer", modify: func(c *Claims) { c.Issuer = "someone-else" }},
+		{name: "missing issuer", modify: func(c *Claims) { c.Issuer = "" }},
+		{name: "wrong audience", modify: func(c *Claims) { c.Audience = []string{"mini-app"} }},
+		{name: "missing audience", modify: func(c *Claims) { c.Audience = nil }},
+		{name: "missing expiration", modify: func(c *Claims) { c.ExpiresAt = nil }},
+		{name: "missing admin id", modify: func(c *Claims) { c.AdminID = 0 }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := valid()
+			tc.modify(&claims)
+
+			_, err := ValidateToken(signClaims(t, claims), testSecret)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestChallengeToken(t *testing.T) {
+	challenge, err := GenerateChallengeToken(7, testSecret, time.Minute)
+	require.NoError(t, err)
+
+	adminID, err := ValidateChallengeToken(challenge, testSecret)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), adminID)
+
+	// Токен второго шага не заменяет access-токен и наоборот
+	_, err = ValidateToken(challenge, testSecret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	access, err := GenerateToken(&database.Admin{ID: 7, Login: "admin"}, "token-id", testSecret, time.Hour)
+	require.NoError(t, err)
+	_, err = ValidateChallengeToken(access, testSecret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := GenerateChallengeToken(7, testSecret, -time.Minute)
+	require.NoError(t, err)
+	_, err = ValidateChallengeToken(expired, testSecret)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithPrincipal(context.Background(), &Principal{AdminID: 7, Login: "admin"})
+	principal, ok := PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, uint64(7), principal.AdminID)
+
+	_, ok = PrincipalFromContext(WithPrincipal(context.Background(), nil))
+	assert.False(t, ok)
+}
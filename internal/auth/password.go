@@ -1,10 +1,22 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BCryptCost - стоимость хеширования паролей bcrypt.
+const BCryptCost = 12
+
+// passwordCharset - алфавит сгенерированных паролей.
+const passwordCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*"
 
 // HashPassword создает хеш пароля с использованием bcrypt.
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), BCryptCost)
 	return string(bytes), err
 }
 
@@ -13,3 +25,21 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// GeneratePassword генерирует случайный пароль заданной длины.
+func GeneratePassword(length int) (string, error) {
+	password := make([]byte, length)
+
+	// rand.Int, а не остаток от деления случайного байта: 256 не делится на длину алфавита,
+	// и первые символы выпадали бы чаще остальных.
+	max := big.NewInt(int64(len(passwordCharset)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random password: %w", err)
+		}
+		password[i] = passwordCharset[n.Int64()]
+	}
+
+	return string(password), nil
+}
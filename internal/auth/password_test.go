@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	password, err := GeneratePassword(64)
+	require.NoError(t, err)
+	assert.Len(t, password, 64)
+	for _, r := range password {
+		assert.True(t, strings.ContainsRune(passwordCharset, r), "unexpected character %q", r)
+	}
+
+	other, err := GeneratePassword(64)
+	require.NoError(t, err)
+	assert.NotEqual(t, password, other)
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("secure_password_123")
+	require.NoError(t, err)
+	assert.True(t, CheckPasswordHash("secure_password_123", hash))
+	assert.False(t, CheckPasswordHash("wrong_password", hash))
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"slices"
+
+	"go-bot/internal/database"
+)
+
+// SuperadminRole - встроенная роль со всеми разрешениями. Ее разрешения не хранятся в базе,
+// а берутся из Permissions, поэтому новые разрешения достаются ей без миграций.
+const SuperadminRole = "superadmin"
+
+// Разрешения администраторов. Роль администратора - набор разрешений, он попадает в токен
+// и проверяется middleware.RequirePermission.
+const (
+	PermUsersRead      = "users:read"      // пользователи бота и их клиенты 3x-ui
+	PermUsersWrite     = "users:write"     // заметки, теги и блокировка пользователей
+	PermMessagesRead   = "messages:read"   // переписка с пользователями
+	PermMessagesSend   = "messages:send"   // сообщения пользователям от имени бота
+	PermTicketsRead    = "tickets:read"    // обращения в поддержку
+	PermTicketsWrite   = "tickets:write"   // закрытие обращений
+	PermBansRead       = "bans:read"       // список блокировок
+	PermBansWrite      = "bans:write"      // блокировка и разблокировка
+	PermReferralsRead  = "referrals:read"  // реферальная программа
+	PermReferralsWrite = "referrals:write" // настройки реферальной программы
+	PermPaymentsRead   = "payments:read"   // платежи за подписки
+	PermPaymentsWrite  = "payments:write"  // повторное применение платежей
+	PermAdminsManage   = "admins:manage"   // администраторы и роли
+	PermMonitoringRead = "monitoring:read" // метрики приложения (/debug/vars)
+)
+
+// Permissions - все разрешения в порядке вывода в API.
+var Permissions = []string{
+	PermUsersRead,
+	PermUsersWrite,
+	PermMessagesRead,
+	PermMessagesSend,
+	PermTicketsRead,
+	PermTicketsWrite,
+	PermBansRead,
+	PermBansWrite,
+	PermReferralsRead,
+	PermReferralsWrite,
+	PermPaymentsRead,
+	PermPaymentsWrite,
+	PermAdminsManage,
+	PermMonitoringRead,
+}
+
+// ValidPermission сообщает, существует ли разрешение.
+func ValidPermission(permission string) bool {
+	return slices.Contains(Permissions, permission)
+}
+
+// RolePermissions возвращает разрешения роли: для superadmin - все разрешения, для остальных
+// ролей - сохраненные в базе.
+func RolePermissions(role *database.Role) []string {
+	if role == nil {
+		return nil
+	}
+	if role.Name == SuperadminRole {
+		return slices.Clone(Permissions)
+	}
+	return role.Permissions
+}
+
+// Can сообщает, есть ли у администратора разрешение.
+func (p *Principal) Can(permission string) bool {
+	return slices.Contains(p.Permissions, permission)
+}
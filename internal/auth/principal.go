@@ -0,0 +1,30 @@
+package auth
+
+import "context"
+
+// Principal - аутентифицированный администратор, от имени которого выполняется запрос.
+type Principal struct {
+	AdminID uint64
+	Login   string
+	// TokenID - jti access-токена, по нему отзывается сессия при выходе.
+	TokenID string
+	// Permissions - разрешения роли администратора на момент выдачи токена, для API-ключа -
+	// разрешения ключа, которые есть и у роли владельца.
+	Permissions []string
+	// APIKeyID - ID API-ключа, если запрос аутентифицирован ключом, а не сессией.
+	APIKeyID uint64
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным администратором.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает аутентифицированного администратора из контекста.
+// ok равен false, если запрос не прошел AuthMiddleware.
+func PrincipalFromContext(ctx context.Context) (principal *Principal, ok bool) {
+	principal, ok = ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPIssuer - издатель в URI для приложений-аутентификаторов, под ним показывается учетная запись.
+	TOTPIssuer = "goooo admin"
+	// TOTPPeriod - длительность интервала TOTP.
+	TOTPPeriod = 30 * time.Second
+	// TOTPDigits - число цифр кода.
+	TOTPDigits = 6
+	// totpSkew - сколько соседних интервалов принимается, чтобы не отклонять коды
+	// при расхождении часов и задержке ввода.
+	totpSkew = 1
+
+	// RecoveryCodeCount - число кодов восстановления, выдаваемых за раз.
+	RecoveryCodeCount = 10
+	// recoveryCodeCharset - алфавит кодов восстановления без похожих символов (0/o, 1/l/i).
+	recoveryCodeCharset = "abcdefghjkmnpqrstuvwxyz23456789"
+	// recoveryCodeLength - длина кода восстановления без дефиса.
+	recoveryCodeLength = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret создает случайный секрет TOTP в base32, как его принимают приложения-аутентификаторы.
+func GenerateTOTPSecret() (string, error) {
+	key := make([]byte, 20) // 160 бит, рекомендация RFC 4226 для HMAC-SHA1
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(key), nil
+}
+
+// TOTPURI возвращает otpauth:// URI для подключения приложения-аутентификатора, обычно в виде QR-кода.
+func TOTPURI(account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + TOTPIssuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// TOTPCode возвращает код TOTP для момента t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(totpStep(t)), TOTPDigits), nil
+}
+
+// ValidateTOTP проверяет код на момент t с допуском в соседние интервалы. Принимаются только
+// интервалы после lastStep, поэтому перехваченный код нельзя использовать повторно.
+// Возвращает номер интервала принятого кода, его нужно сохранить как новый lastStep.
+func ValidateTOTP(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := totpStep(t)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(step), TOTPDigits)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes создает коды восстановления вида xxxxx-xxxxx.
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	max := big.NewInt(int64(len(recoveryCodeCharset)))
+	codes := make([]string, count)
+	for i := range codes {
+		code := make([]byte, recoveryCodeLength)
+		for j := range code {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+			}
+			code[j] = recoveryCodeCharset[n.Int64()]
+		}
+		codes[i] = string(code[:recoveryCodeLength/2]) + "-" + string(code[recoveryCodeLength/2:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode возвращает hex SHA-256 кода восстановления. Регистр, пробелы и дефисы
+// не учитываются, чтобы код можно было ввести как угодно.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid totp secret: %w", err)
+	}
+	return key, nil
+}
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// hotp вычисляет код HOTP (RFC 4226) для счетчика.
+func hotp(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение: 31 бит начиная со смещения из младших 4 бит последнего байта.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret - ключ "12345678901234567890" из тестовых векторов RFC 6238 в base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTP_RFC6238Vectors(t *testing.T) {
+	key, err := decodeTOTPSecret(rfc6238Secret)
+	require.NoError(t, err)
+	require.Equal(t, "12345678901234567890", string(key))
+
+	// Приложение B RFC 6238, SHA1, 8 цифр
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+		{unix: 20000000000, code: "65353130"},
+	}
+
+	for _, tc := range testCases {
+		step := totpStep(time.Unix(tc.unix, 0))
+		assert.Equal(t, tc.code, hotp(key, uint64(step), 8), "time %d", tc.unix)
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	code, err := TOTPCode(rfc6238Secret, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code) // последние 6 цифр вектора 94287082
+
+	_, err = TOTPCode("not base32!", time.Unix(59, 0))
+	assert.Error(t, err)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totpStep(now)
+	code, err := TOTPCode(rfc6238Secret, now)
+	require.NoError(t, err)
+
+	got, ok := ValidateTOTP(rfc6238Secret, code, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+
+	t.Run("adjacent steps are accepted", func(t *testing.T) {
+		_, ok := ValidateTOTP(rfc6238Secret, code, now.Add(TOTPPeriod), 0)
+		assert.True(t, ok)
+		_, ok = ValidateTOTP(rfc6238Secret, code, now.Add(-TOTPPeriod), 0)
+		assert.True(t, ok)
+	})
+
+	t.Run("distant steps are rejected", func(t *testing.T) {
+		_, ok := ValidateTOTP(rfc6238Secret, code, now.Add(3*TOTPPeriod), 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("used code is rejected", func(t *testing.T) {
+		_, ok := ValidateTOTP(rfc6238Secret, code, now, step)
+		assert.False(t, ok)
+	})
+
+	t.Run("malformed code is rejected", func(t *testing.T) {
+		for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+			_, ok := ValidateTOTP(rfc6238Secret, bad, now, 0)
+			assert.False(t, ok, bad)
+		}
+	})
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	key, err := decodeTOTPSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, 20)
+
+	other, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("admin", rfc6238Secret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/"+TOTPIssuer+":admin", uri.Path)
+	assert.Equal(t, rfc6238Secret, uri.Query().Get("secret"))
+	assert.Equal(t, TOTPIssuer, uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+	assert.Equal(t, "30", uri.Query().Get("period"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		assert.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+
+	code := codes[0]
+	hash := HashRecoveryCode(code)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashRecoveryCode(strings.ToUpper(code)))
+	assert.Equal(t, hash, HashRecoveryCode(" "+strings.ReplaceAll(code, "-", "")+" "))
+	assert.NotEqual(t, hash, HashRecoveryCode(codes[1]))
+}
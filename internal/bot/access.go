@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"go-bot/internal/xui"
+)
+
+// isAdmin сообщает, является ли пользователь администратором бота (ADMIN_TELEGRAM_IDS).
+func (h *Handler) isAdmin(telegramID int64) bool {
+	return h.admins[telegramID]
+}
+
+// canViewClient проверяет доступ к данным клиента: администраторы видят всех клиентов,
+// остальные пользователи - только привязанных к своему аккаунту.
+func (h *Handler) canViewClient(ctx context.Context, telegramID int64, email string) (bool, error) {
+	if h.isAdmin(telegramID) {
+		return true, nil
+	}
+	links, err := h.links.ForUser(ctx, telegramID)
+	if err != nil {
+		return false, err
+	}
+	for _, link := range links {
+		if strings.EqualFold(link.Email, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// linkedClients возвращает данные о трафике всех клиентов, привязанных к пользователю.
+func (h *Handler) linkedClients(ctx context.Context, telegramID int64) ([]xui.ClientTraffic, error) {
+	links, err := h.links.ForUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []xui.ClientTraffic
+	for _, link := range links {
+		traffics, err := h.xui.GetClientTraffics(ctx, link.Email)
+		if err != nil {
+			return nil, err
+		}
+		for _, traffic := range traffics {
+			if traffic.Email == link.Email {
+				clients = append(clients, traffic)
+			}
+		}
+	}
+	return clients, nil
+}
@@ -0,0 +1,377 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-bot/internal/database"
+	"go-bot/internal/render"
+	"go-bot/internal/xui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// usersCallbackPrefix - префикс callback data кнопок пагинации /users: "users:<offset>:<query>".
+	usersCallbackPrefix = "users:"
+	// maxCallbackDataLength - ограничение Telegram на длину callback data в байтах.
+	maxCallbackDataLength = 64
+
+	usersPageSize = 10
+	// maxUsersQueryLength - длина запроса /users в байтах, при которой callback data
+	// кнопок пагинации с любым смещением укладывается в maxCallbackDataLength.
+	maxUsersQueryLength = maxCallbackDataLength - len(usersCallbackPrefix) - 8
+
+	maxExtendDays   = 3650
+	maxAddTrafficGB = 10000
+)
+
+// adminHelp - справка по командам администратора, добавляется к /help.
+const adminHelp = "\n\nКоманды администратора:\n/stats - Статистика\n/users [запрос] - Поиск пользователей\n/extend <email> <дни> - Продлить клиента\n/addtraffic <email> <GB> - Добавить трафик\n/disable <email> - Отключить клиента\n/enable <email> - Включить клиента\n/link <id> <email> - Привязать клиента к пользователю\n/ban <id> [срок] [причина] - Заблокировать пользователя или чат\n/unban <id> - Снять блокировку"
+
+// handleAdminCommand выполняет команды администратора. Для остальных пользователей
+// команды выглядят как неизвестные, чтобы не раскрывать их существование.
+func (h *Handler) handleAdminCommand(ctx context.Context, message *tgbotapi.Message) {
+	if !h.isAdmin(message.From.ID) {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Неизвестная команда. Используй /help для получения справки."))
+		return
+	}
+	// Статистика, пользователи и клиенты не показываются в группах; /ban и /unban отвечают
+	// только подтверждением и доступны в группах для модерации.
+	if command := message.Command(); command != "ban" && command != "unban" && !h.requirePrivateChat(message) {
+		return
+	}
+
+	switch message.Command() {
+	case "stats":
+		h.handleStatsCommand(ctx, message)
+	case "users":
+		h.handleUsersCommand(ctx, message)
+	case "extend":
+		h.handleExtendCommand(ctx, message)
+	case "addtraffic":
+		h.handleAddTrafficCommand(ctx, message)
+	case "disable", "enable":
+		h.handleSetEnabledCommand(ctx, message, message.Command() == "enable")
+	case "link":
+		h.handleLinkCommand(ctx, message)
+	case "ban":
+		h.handleBanCommand(ctx, message)
+	case "unban":
+		h.handleUnbanCommand(ctx, message)
+	}
+}
+
+// handleStatsCommand показывает число пользователей и активных клиентов 3x-ui.
+func (h *Handler) handleStatsCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.db == nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Статистика недоступна: нет подключения к базе данных."))
+		return
+	}
+
+	now := time.Now().In(h.userLocation(ctx, message.From.ID))
+	stats, err := h.users.Stats(ctx, now)
+	if err != nil {
+		log.Printf("ERROR: failed to get user stats: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении данных. Пожалуйста, попробуйте позже."))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<b>Статистика</b>\n")
+	sb.WriteString(fmt.Sprintf("Пользователи: %d (новых сегодня: %d)\n", stats.Total, stats.NewToday))
+	sb.WriteString(fmt.Sprintf("С привязанной подпиской: %d\n", stats.Linked))
+	sb.WriteString(fmt.Sprintf("Заблокировали бота: %d\n", stats.Blocked))
+
+	clients, err := h.xui.ListClientTraffics(ctx)
+	if err != nil {
+		log.Printf("ERROR: failed to list clients for stats: %v", err)
+		sb.WriteString("Клиенты 3x-ui: не удалось получить")
+	} else {
+		active := 0
+		for _, client := range clients {
+			if render.ClientActive(client, now) {
+				active++
+			}
+		}
+		sb.WriteString(fmt.Sprintf("Клиенты 3x-ui: активных %d из %d", active, len(clients)))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, sb.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	h.bot.Send(msg)
+}
+
+// handleUsersCommand ищет пользователей по имени, username или Telegram ID: /users [запрос].
+func (h *Handler) handleUsersCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.db == nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Поиск недоступен: нет подключения к базе данных."))
+		return
+	}
+
+	query := truncateBytes(strings.TrimSpace(message.CommandArguments()), maxUsersQueryLength)
+	text, markup, err := h.usersPage(ctx, query, 0)
+	if err != nil {
+		log.Printf("ERROR: failed to search users [%s]: %v", query, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении данных. Пожалуйста, попробуйте позже."))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if len(markup.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = markup
+	}
+	h.bot.Send(msg)
+}
+
+// handleUsersCallback переключает страницу результатов /users.
+func (h *Handler) handleUsersCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	if !h.isAdmin(callback.From.ID) || h.db == nil || callback.Message == nil {
+		h.bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	}
+
+	offset, query, ok := parseUsersCallback(callback.Data)
+	if !ok {
+		h.bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	}
+
+	text, markup, err := h.usersPage(ctx, query, offset)
+	if err != nil {
+		log.Printf("ERROR: failed to search users [%s]: %v", query, err)
+		h.bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка, попробуйте позже"))
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(callback.Message.Chat.ID, callback.Message.MessageID, text, markup)
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := h.bot.Send(edit); err != nil {
+		log.Printf("ERROR: failed to update users page: %v", err)
+	}
+	h.bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+}
+
+// usersPage формирует страницу результатов поиска пользователей с кнопками пагинации.
+func (h *Handler) usersPage(ctx context.Context, query string, offset int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	users, total, err := h.users.Search(ctx, query, usersPageSize, offset)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	var sb strings.Builder
+	if query != "" {
+		sb.WriteString(fmt.Sprintf("Поиск: <code>%s</code>\n", html.EscapeString(query)))
+	}
+	if len(users) == 0 {
+		sb.WriteString("Пользователи не найдены.")
+		return sb.String(), tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}, nil
+	}
+
+	sb.WriteString(fmt.Sprintf("<b>Пользователи %d-%d из %d</b>\n\n", offset+1, offset+len(users), total))
+	for i, user := range users {
+		sb.WriteString(fmt.Sprintf("%d. %s", offset+i+1, html.EscapeString(strings.TrimSpace(user.FirstName+" "+user.LastName))))
+		if user.Username != "" {
+			sb.WriteString(" @" + html.EscapeString(user.Username))
+		}
+		sb.WriteString(fmt.Sprintf(" (id <code>%d</code>) · %s\n", user.TelegramID, user.CreatedAt.Format("02.01.2006")))
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("◀ Назад", usersCallbackData(max(offset-usersPageSize, 0), query)))
+	}
+	if int64(offset+len(users)) < total {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Вперед ▶", usersCallbackData(offset+usersPageSize, query)))
+	}
+	markup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if len(row) > 0 {
+		markup = tgbotapi.NewInlineKeyboardMarkup(row)
+	}
+	return sb.String(), markup, nil
+}
+
+// usersCallbackData кодирует страницу /users в callback data.
+func usersCallbackData(offset int, query string) string {
+	return truncateBytes(usersCallbackPrefix+strconv.Itoa(offset)+":"+query, maxCallbackDataLength)
+}
+
+// truncateBytes обрезает строку до limit байт по границе символа.
+func truncateBytes(s string, limit int) string {
+	for len(s) > limit {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	return s
+}
+
+// parseUsersCallback разбирает callback data кнопок пагинации /users.
+func parseUsersCallback(data string) (int, string, bool) {
+	rest, ok := strings.CutPrefix(data, usersCallbackPrefix)
+	if !ok {
+		return 0, "", false
+	}
+	rawOffset, query, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, "", false
+	}
+	offset, err := strconv.Atoi(rawOffset)
+	if err != nil || offset < 0 {
+		return 0, "", false
+	}
+	return offset, query, true
+}
+
+// handleExtendCommand продлевает клиента: /extend <email> <дни>.
+func (h *Handler) handleExtendCommand(ctx context.Context, message *tgbotapi.Message) {
+	email, days, ok := parseEmailAndAmount(message.CommandArguments(), maxExtendDays)
+	if !ok {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("Использование: /extend <email> <дни>, от 1 до %d. Пример: /extend user@example.com 30", maxExtendDays)))
+		return
+	}
+
+	client, err := h.xui.ExtendClient(ctx, email, days, 0)
+	if err != nil {
+		h.replyClientError(message, email, err)
+		return
+	}
+
+	h.recordAudit(ctx, message.From.ID, "client.extend", email, map[string]any{"days": days, "expiry_time": client.ExpiryTime})
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+		fmt.Sprintf("Клиент %s продлен на %d дн. Срок: %s.", email, days,
+			render.Expiry(client.ExpiryTime, h.userLocation(ctx, message.From.ID), time.Now()))))
+}
+
+// handleAddTrafficCommand добавляет трафик к лимиту клиента: /addtraffic <email> <GB>.
+func (h *Handler) handleAddTrafficCommand(ctx context.Context, message *tgbotapi.Message) {
+	email, gb, ok := parseEmailAndAmount(message.CommandArguments(), maxAddTrafficGB)
+	if !ok {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("Использование: /addtraffic <email> <GB>, от 1 до %d. Пример: /addtraffic user@example.com 50", maxAddTrafficGB)))
+		return
+	}
+
+	client, err := h.xui.ExtendClient(ctx, email, 0, int64(gb)*1024*1024*1024)
+	if err != nil {
+		h.replyClientError(message, email, err)
+		return
+	}
+
+	h.recordAudit(ctx, message.From.ID, "client.add_traffic", email, map[string]any{"gb": gb, "total": client.TotalGB})
+	if client.TotalGB <= 0 {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("У клиента %s трафик без ограничений, лимит не изменен.", email)))
+		return
+	}
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+		fmt.Sprintf("Клиенту %s добавлено %d GB. Лимит: %s.", email, gb, render.GB(client.TotalGB))))
+}
+
+// handleSetEnabledCommand включает или отключает клиента: /enable <email>, /disable <email>.
+func (h *Handler) handleSetEnabledCommand(ctx context.Context, message *tgbotapi.Message, enable bool) {
+	email := strings.TrimSpace(message.CommandArguments())
+	if email == "" || strings.ContainsAny(email, " \t\n") {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Использование: /%s <email>", message.Command())))
+		return
+	}
+
+	if _, err := h.xui.SetClientEnabled(ctx, email, enable); err != nil {
+		h.replyClientError(message, email, err)
+		return
+	}
+
+	if enable {
+		h.recordAudit(ctx, message.From.ID, "client.enable", email, nil)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Клиент %s включен.", email)))
+		return
+	}
+	h.recordAudit(ctx, message.From.ID, "client.disable", email, nil)
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Клиент %s отключен.", email)))
+}
+
+// handleLinkCommand привязывает существующего клиента 3x-ui к пользователю: /link <telegram_id> <email>.
+// Так клиенты, созданные в панели, а не через /trial, могут продлевать подписку через /buy.
+func (h *Handler) handleLinkCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.db == nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Привязка недоступна: нет подключения к базе данных."))
+		return
+	}
+
+	fields := strings.Fields(message.CommandArguments())
+	var telegramID int64
+	if len(fields) == 2 {
+		telegramID, _ = strconv.ParseInt(fields[0], 10, 64)
+	}
+	if telegramID <= 0 {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Использование: /link <telegram_id> <email>. Пример: /link 123456789 user@example.com"))
+		return
+	}
+	email := fields[1]
+
+	traffics, err := h.xui.GetClientTraffics(ctx, email)
+	if err != nil {
+		h.replyClientError(message, email, err)
+		return
+	}
+	inboundID := 0
+	for _, traffic := range traffics {
+		if traffic.Email == email {
+			inboundID = traffic.InboundID
+			break
+		}
+	}
+	if inboundID == 0 {
+		h.replyClientError(message, email, xui.ErrClientNotFound)
+		return
+	}
+
+	if _, err := h.links.Link(ctx, telegramID, email, inboundID); err != nil {
+		log.Printf("ERROR: failed to link client [%s] to %d: %v", email, telegramID, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось привязать клиента. Пожалуйста, попробуйте позже."))
+		return
+	}
+
+	h.recordAudit(ctx, message.From.ID, "client.link", email, map[string]any{"telegram_id": telegramID, "inbound_id": inboundID})
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Клиент %s привязан к пользователю %d.", email, telegramID)))
+}
+
+// replyClientError сообщает администратору об ошибке изменения клиента.
+func (h *Handler) replyClientError(message *tgbotapi.Message, email string, err error) {
+	if errors.Is(err, xui.ErrClientNotFound) {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Клиент с email %s не найден.", email)))
+		return
+	}
+	log.Printf("ERROR: /%s for client [%s] failed: %v", message.Command(), email, err)
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось изменить клиента. Пожалуйста, попробуйте позже."))
+}
+
+// recordAudit записывает действие администратора в журнал. Ошибка записи не отменяет действие.
+func (h *Handler) recordAudit(ctx context.Context, adminID int64, action, target string, details any) {
+	if h.db == nil {
+		return
+	}
+	if err := h.audit.Record(ctx, database.AuditActorTelegram, adminID, action, target, details); err != nil {
+		log.Printf("ERROR: failed to record audit of %s by %d: %v", action, adminID, err)
+	}
+}
+
+// parseEmailAndAmount разбирает аргументы вида "<email> <число>", число от 1 до limit.
+func parseEmailAndAmount(args string, limit int) (string, int, bool) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return "", 0, false
+	}
+	amount, err := strconv.Atoi(fields[1])
+	if err != nil || amount < 1 || amount > limit {
+		return "", 0, false
+	}
+	return fields[0], amount, true
+}
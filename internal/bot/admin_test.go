@@ -0,0 +1,148 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-bot/internal/xui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commandUpdate создает update с командой от пользователя fromID в личном чате.
+func commandUpdate(fromID int64, text string) tgbotapi.Update {
+	command, _, _ := strings.Cut(text, " ")
+	return tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			From:     &tgbotapi.User{ID: fromID},
+			Chat:     &tgbotapi.Chat{ID: fromID, Type: "private"},
+			Text:     text,
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}},
+		},
+	}
+}
+
+func TestAdminCommands_HiddenFromUsers(t *testing.T) {
+	// --- Arrange ---
+	called := false
+	mockXUIService := &MockXUIService{
+		SetClientEnabledFunc: func(ctx context.Context, email string, enable bool) (*xui.InboundClient, error) {
+			called = true
+			return &xui.InboundClient{Email: email}, nil
+		},
+	}
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{Bot: mockBot, XUI: mockXUIService, AdminIDs: []int64{42}})
+
+	// --- Act ---
+	handler.ProcessUpdate(context.Background(), commandUpdate(7, "/disable test@example.com"))
+
+	// --- Assert ---
+	assert.False(t, called, "Non-admin must not change clients")
+	require.Len(t, mockBot.SentMessages, 1)
+	msg := mockBot.SentMessages[0].(tgbotapi.MessageConfig)
+	assert.Contains(t, msg.Text, "Неизвестная команда")
+}
+
+func TestAdminCommands_ModifyClients(t *testing.T) {
+	expiry := time.Date(2030, 1, 31, 12, 0, 0, 0, time.UTC).UnixMilli()
+
+	testCases := []struct {
+		name         string
+		text         string
+		expectedCall string
+		expectedText string
+	}{
+		{
+			name:         "Disable",
+			text:         "/disable test@example.com",
+			expectedCall: "enable test@example.com false",
+			expectedText: "Клиент test@example.com отключен.",
+		},
+		{
+			name:         "Enable",
+			text:         "/enable test@example.com",
+			expectedCall: "enable test@example.com true",
+			expectedText: "Клиент test@example.com включен.",
+		},
+		{
+			name:         "Extend",
+			text:         "/extend test@example.com 30",
+			expectedCall: "extend test@example.com 30 0",
+			expectedText: "Клиент test@example.com продлен на 30 дн. Срок: до 31.01.2030.",
+		},
+		{
+			name:         "Add Traffic",
+			text:         "/addtraffic test@example.com 5",
+			expectedCall: fmt.Sprintf("extend test@example.com 0 %d", 5*1024*1024*1024),
+			expectedText: "Клиенту test@example.com добавлено 5 GB. Лимит: 15.00 GB.",
+		},
+		{
+			name:         "Unknown Client",
+			text:         "/disable missing@example.com",
+			expectedCall: "enable missing@example.com false",
+			expectedText: "Клиент с email missing@example.com не найден.",
+		},
+		{
+			name:         "Invalid Days",
+			text:         "/extend test@example.com many",
+			expectedText: "Использование: /extend",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// --- Arrange ---
+			var calls []string
+			mockXUIService := &MockXUIService{
+				SetClientEnabledFunc: func(ctx context.Context, email string, enable bool) (*xui.InboundClient, error) {
+					calls = append(calls, fmt.Sprintf("enable %s %t", email, enable))
+					if email == "missing@example.com" {
+						return nil, fmt.Errorf("XUIService error: %w: %s", xui.ErrClientNotFound, email)
+					}
+					return &xui.InboundClient{Email: email, Enable: enable}, nil
+				},
+				ExtendClientFunc: func(ctx context.Context, email string, days int, extraBytes int64) (*xui.InboundClient, error) {
+					calls = append(calls, fmt.Sprintf("extend %s %d %d", email, days, extraBytes))
+					return &xui.InboundClient{Email: email, ExpiryTime: expiry, TotalGB: 10*1024*1024*1024 + extraBytes}, nil
+				},
+			}
+			mockBot := &MockBotSender{}
+			handler := NewHandler(Deps{Bot: mockBot, XUI: mockXUIService, AdminIDs: []int64{42}})
+
+			// --- Act ---
+			handler.ProcessUpdate(context.Background(), commandUpdate(42, tc.text))
+
+			// --- Assert ---
+			if tc.expectedCall == "" {
+				assert.Empty(t, calls)
+			} else {
+				assert.Equal(t, []string{tc.expectedCall}, calls)
+			}
+			require.Len(t, mockBot.SentMessages, 1)
+			msg := mockBot.SentMessages[0].(tgbotapi.MessageConfig)
+			assert.Contains(t, msg.Text, tc.expectedText)
+		})
+	}
+}
+
+func TestUsersCallbackData(t *testing.T) {
+	query := strings.Repeat("пользователь", 10)
+
+	data := usersCallbackData(120, truncateBytes(query, maxUsersQueryLength))
+	assert.LessOrEqual(t, len(data), maxCallbackDataLength)
+
+	offset, parsed, ok := parseUsersCallback(data)
+	require.True(t, ok)
+	assert.Equal(t, 120, offset)
+	assert.True(t, strings.HasPrefix(query, parsed))
+	assert.Equal(t, truncateBytes(query, maxUsersQueryLength), parsed, "Query must survive pagination unchanged")
+
+	_, _, ok = parseUsersCallback("users:-1:abc")
+	assert.False(t, ok)
+}
@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// allowUpdate отбрасывает обновления от заблокированных пользователей и из заблокированных чатов.
+// Обновления администраторов не проверяются. Если блокировки не удалось загрузить,
+// используются закэшированные, чтобы сбой базы не останавливал бота.
+func (h *Handler) allowUpdate(ctx context.Context, update tgbotapi.Update) bool {
+	userID, chatID := updateSource(update)
+	if h.bans == nil || h.isAdmin(userID) {
+		return true
+	}
+
+	ban, err := h.bans.Check(ctx, userID, chatID)
+	if err != nil {
+		log.Printf("ERROR: failed to check bans: %v", err)
+	}
+	if ban != nil {
+		log.Printf("Update %d from banned %d dropped (user %d, chat %d)", update.UpdateID, ban.TelegramID, userID, chatID)
+		return false
+	}
+	return true
+}
+
+// updateSource возвращает отправителя и чат обновления. 0 - не указан.
+func updateSource(update tgbotapi.Update) (userID, chatID int64) {
+	switch {
+	case update.Message != nil:
+		return userIDOf(update.Message.From), update.Message.Chat.ID
+	case update.EditedMessage != nil:
+		return userIDOf(update.EditedMessage.From), update.EditedMessage.Chat.ID
+	case update.CallbackQuery != nil:
+		if update.CallbackQuery.Message != nil {
+			chatID = update.CallbackQuery.Message.Chat.ID
+		}
+		return userIDOf(update.CallbackQuery.From), chatID
+	case update.InlineQuery != nil:
+		return userIDOf(update.InlineQuery.From), 0
+	case update.ChosenInlineResult != nil:
+		return userIDOf(update.ChosenInlineResult.From), 0
+	case update.PreCheckoutQuery != nil:
+		return userIDOf(update.PreCheckoutQuery.From), 0
+	case update.MyChatMember != nil:
+		return update.MyChatMember.From.ID, update.MyChatMember.Chat.ID
+	case update.ChatMember != nil:
+		return update.ChatMember.From.ID, update.ChatMember.Chat.ID
+	}
+	return 0, 0
+}
+
+func userIDOf(user *tgbotapi.User) int64 {
+	if user == nil {
+		return 0
+	}
+	return user.ID
+}
+
+// allowCommand ограничивает частоту команд, обращающихся к 3x-ui. Администраторы не ограничиваются.
+func (h *Handler) allowCommand(message *tgbotapi.Message) bool {
+	if h.commandLimiter == nil || !throttledCommands[message.Command()] || h.isAdmin(message.From.ID) {
+		return true
+	}
+
+	allowed, retryAfter := h.commandLimiter.allow(message.From.ID, time.Now())
+	if !allowed {
+		seconds := int(retryAfter.Round(time.Second) / time.Second)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("Слишком много запросов. Повторите через %d сек.", max(seconds, 1))))
+	}
+	return allowed
+}
+
+// handleBanCommand блокирует пользователя или чат: /ban <id> [срок] [причина].
+// Срок задается как 30m, 12h, 7d или 4w; без срока блокировка бессрочная.
+func (h *Handler) handleBanCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.bans == nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Блокировки недоступны: нет подключения к базе данных."))
+		return
+	}
+
+	targetID, duration, reason, ok := parseBanArgs(message.CommandArguments())
+	if !ok {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+			"Использование: /ban <telegram_id> [срок] [причина]. Срок: 30m, 12h, 7d, 4w. Пример: /ban 123456789 7d спам"))
+		return
+	}
+	if h.isAdmin(targetID) {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Нельзя заблокировать администратора."))
+		return
+	}
+
+	ban := database.Ban{
+		TelegramID:   targetID,
+		Reason:       reason,
+		BannedByType: database.AuditActorTelegram,
+		BannedBy:     message.From.ID,
+	}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	saved, err := h.bans.Ban(ctx, ban)
+	if err != nil {
+		log.Printf("ERROR: failed to ban %d: %v", targetID, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось заблокировать. Пожалуйста, попробуйте позже."))
+		return
+	}
+
+	h.recordAudit(ctx, message.From.ID, "user.ban", strconv.FormatInt(targetID, 10),
+		map[string]any{"reason": saved.Reason, "expires_at": saved.ExpiresAt})
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("%d заблокирован %s.",
+		targetID, formatBanExpiry(saved.ExpiresAt, h.userLocation(ctx, message.From.ID)))))
+}
+
+// handleUnbanCommand снимает блокировку: /unban <id>.
+func (h *Handler) handleUnbanCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.bans == nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Блокировки недоступны: нет подключения к базе данных."))
+		return
+	}
+
+	targetID, err := strconv.ParseInt(strings.TrimSpace(message.CommandArguments()), 10, 64)
+	if err != nil || targetID == 0 {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Использование: /unban <telegram_id>"))
+		return
+	}
+
+	if err := h.bans.Unban(ctx, targetID); err != nil {
+		if errors.Is(err, service.ErrBanNotFound) {
+			h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("%d не заблокирован.", targetID)))
+			return
+		}
+		log.Printf("ERROR: failed to unban %d: %v", targetID, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось снять блокировку. Пожалуйста, попробуйте позже."))
+		return
+	}
+
+	h.recordAudit(ctx, message.From.ID, "user.unban", strconv.FormatInt(targetID, 10), nil)
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Блокировка %d снята.", targetID)))
+}
+
+// parseBanArgs разбирает аргументы /ban. duration 0 - бессрочно.
+func parseBanArgs(args string) (targetID int64, duration time.Duration, reason string, ok bool) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return 0, 0, "", false
+	}
+	targetID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || targetID == 0 {
+		return 0, 0, "", false
+	}
+	fields = fields[1:]
+
+	if len(fields) > 0 {
+		if d, ok := parseBanDuration(fields[0]); ok {
+			duration = d
+			fields = fields[1:]
+		}
+	}
+	return targetID, duration, strings.Join(fields, " "), true
+}
+
+// parseBanDuration разбирает срок блокировки: число и единица m, h, d или w.
+func parseBanDuration(s string) (time.Duration, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch strings.ToLower(s[len(s)-1:]) {
+	case "m":
+		unit = time.Minute
+	case "h":
+		unit = time.Hour
+	case "d":
+		unit = 24 * time.Hour
+	case "w":
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// formatBanExpiry описывает срок блокировки.
+func formatBanExpiry(expiresAt *time.Time, loc *time.Location) string {
+	if expiresAt == nil {
+		return "бессрочно"
+	}
+	return "до " + expiresAt.In(loc).Format("02.01.2006 15:04")
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBanArgs(t *testing.T) {
+	testCases := []struct {
+		name             string
+		args             string
+		expectedID       int64
+		expectedDuration time.Duration
+		expectedReason   string
+		expectedOK       bool
+	}{
+		{name: "Permanent", args: "123", expectedID: 123, expectedOK: true},
+		{name: "With Duration", args: "123 7d", expectedID: 123, expectedDuration: 7 * 24 * time.Hour, expectedOK: true},
+		{name: "Duration And Reason", args: "123 12h flood in support", expectedID: 123, expectedDuration: 12 * time.Hour, expectedReason: "flood in support", expectedOK: true},
+		{name: "Reason Only", args: "123 спам", expectedID: 123, expectedReason: "спам", expectedOK: true},
+		{name: "Group Chat", args: "-1001234 2w", expectedID: -1001234, expectedDuration: 14 * 24 * time.Hour, expectedOK: true},
+		{name: "Minutes", args: "123 30m", expectedID: 123, expectedDuration: 30 * time.Minute, expectedOK: true},
+		{name: "Not A Duration", args: "123 0d", expectedID: 123, expectedReason: "0d", expectedOK: true},
+		{name: "Empty", args: "", expectedOK: false},
+		{name: "Username", args: "@someone", expectedOK: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, duration, reason, ok := parseBanArgs(tc.args)
+			assert.Equal(t, tc.expectedOK, ok)
+			if tc.expectedOK {
+				assert.Equal(t, tc.expectedID, id)
+				assert.Equal(t, tc.expectedDuration, duration)
+				assert.Equal(t, tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestUpdateSource(t *testing.T) {
+	user := &tgbotapi.User{ID: 7}
+	group := &tgbotapi.Chat{ID: -100}
+
+	userID, chatID := updateSource(tgbotapi.Update{Message: &tgbotapi.Message{From: user, Chat: group}})
+	assert.Equal(t, int64(7), userID)
+	assert.Equal(t, int64(-100), chatID)
+
+	userID, chatID = updateSource(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{From: user, Message: &tgbotapi.Message{Chat: group}}})
+	assert.Equal(t, int64(7), userID)
+	assert.Equal(t, int64(-100), chatID)
+
+	userID, chatID = updateSource(tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{From: user}})
+	assert.Equal(t, int64(7), userID)
+	assert.Zero(t, chatID)
+
+	userID, chatID = updateSource(tgbotapi.Update{})
+	assert.Zero(t, userID)
+	assert.Zero(t, chatID)
+}
@@ -7,29 +7,36 @@ import (
 	"strings"
 	"time"
 
+	"go-bot/internal/database"
+	"go-bot/internal/render"
 	"go-bot/internal/service"
+	"go-bot/internal/traffic"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// SetupWebhook настраивает webhook для Telegram бота
-func SetupWebhook(bot *tgbotapi.BotAPI, webhookURL string) {
+// SetupWebhook настраивает webhook для Telegram бота.
+// secretToken передается как secret_token: Telegram будет присылать его
+// в заголовке X-Telegram-Bot-Api-Secret-Token каждого запроса.
+func SetupWebhook(bot *tgbotapi.BotAPI, webhookURL string, secretToken string) {
 	// Delete existing webhook first
 	_, err := bot.Request(tgbotapi.DeleteWebhookConfig{})
 	if err != nil {
 		log.Printf("Error deleting webhook: %v", err)
 	}
 
-	// Set new webhook
-	webhookConfig, err := tgbotapi.NewWebhook(webhookURL)
-	if err != nil {
-		log.Printf("Error creating webhook config: %v", err)
-		return
+	// Set new webhook. WebhookConfig из tgbotapi v5.5.1 не поддерживает secret_token,
+	// поэтому параметры собираются вручную.
+	params := tgbotapi.Params{"url": webhookURL}
+	params.AddNonZero("max_connections", 100)
+	params.AddNonEmpty("secret_token", secretToken)
+	if err := params.AddInterface("allowed_updates", AllowedUpdates); err != nil {
+		log.Printf("Error encoding allowed updates: %v", err)
 	}
-	webhookConfig.MaxConnections = 100
 
-	_, err = bot.Request(webhookConfig)
+	_, err = bot.MakeRequest("setWebhook", params)
 	if err != nil {
 		log.Printf("Error setting webhook: %v", err)
 	} else {
@@ -37,70 +44,253 @@ func SetupWebhook(bot *tgbotapi.BotAPI, webhookURL string) {
 	}
 }
 
+// Deps - зависимости обработчика обновлений.
+type Deps struct {
+	Bot      BotSender
+	DB       *gorm.DB
+	XUI      service.ClientManager
+	Payments *service.PaymentService
+	Trials   *service.TrialService
+	Referral *service.ReferralService
+	Support  *service.SupportService
+	// Bans - блокировки, общие с Admin API: блокировка через API сразу сбрасывает кэш бота.
+	// nil отключает проверку блокировок.
+	Bans *service.BanService
+	// History - записанная история трафика для графиков /usage. nil отключает /usage.
+	History *traffic.History
+	// SupportChatID - чат операторов, куда пересылаются обращения. 0 отключает поддержку.
+	SupportChatID int64
+	// AdminIDs - Telegram ID администраторов бота.
+	AdminIDs []int64
+	// CommandRateLimit и CommandRateWindow ограничивают частоту команд, обращающихся к 3x-ui:
+	// не больше CommandRateLimit за CommandRateWindow на пользователя. 0 отключает ограничение.
+	CommandRateLimit  int
+	CommandRateWindow time.Duration
+	// InlineRateLimit - то же для inline-запросов мимо кэша, за CommandRateWindow. 0 отключает ограничение.
+	InlineRateLimit int
+	// BotUsername нужен для реферальных ссылок вида t.me/<bot>?start=ref_<id>.
+	BotUsername string
+}
+
+// Handler обрабатывает обновления Telegram.
+// Создается один раз и разделяется всеми воркерами очереди обновлений.
+type Handler struct {
+	bot      BotSender
+	db       *gorm.DB
+	xui      service.ClientManager
+	links    *service.ClientLinkService
+	users    *service.UserService
+	audit    *service.AuditService
+	chats    *service.ChatService
+	messages *service.MessageService
+	bans     *service.BanService
+	payments *service.PaymentService
+	trials   *service.TrialService
+	referral *service.ReferralService
+	support  *service.SupportService
+	history  *traffic.History
+
+	supportChatID int64
+	admins        map[int64]bool
+	botUsername   string
+	inlineCache   *inlineCache
+	// inlineLimiter - nil, если частота inline-запросов не ограничена.
+	inlineLimiter *rateLimiter
+	// commandLimiter - nil, если частота команд не ограничена.
+	commandLimiter *rateLimiter
+}
+
+// NewHandler создает обработчик обновлений.
+func NewHandler(deps Deps) *Handler {
+	admins := make(map[int64]bool, len(deps.AdminIDs))
+	for _, id := range deps.AdminIDs {
+		admins[id] = true
+	}
+
+	h := &Handler{
+		bot:      deps.Bot,
+		db:       deps.DB,
+		xui:      deps.XUI,
+		links:    service.NewClientLinkService(deps.DB),
+		users:    service.NewUserService(deps.DB),
+		audit:    service.NewAuditService(deps.DB),
+		chats:    service.NewChatService(deps.DB),
+		messages: service.NewMessageService(deps.DB),
+		payments: deps.Payments,
+		trials:   deps.Trials,
+		referral: deps.Referral,
+		support:  deps.Support,
+		bans:     deps.Bans,
+		history:  deps.History,
+
+		supportChatID: deps.SupportChatID,
+		admins:        admins,
+		botUsername:   deps.BotUsername,
+		inlineCache:   newInlineCache(inlineCacheTTL),
+	}
+	if deps.CommandRateLimit > 0 && deps.CommandRateWindow > 0 {
+		h.commandLimiter = newRateLimiter(deps.CommandRateLimit, deps.CommandRateWindow)
+	}
+	if deps.InlineRateLimit > 0 && deps.CommandRateWindow > 0 {
+		h.inlineLimiter = newRateLimiter(deps.InlineRateLimit, deps.CommandRateWindow)
+	}
+	return h
+}
+
 // ProcessUpdate обрабатывает входящие update от Telegram
-func ProcessUpdate(ctx context.Context, update tgbotapi.Update, bot BotSender, db *gorm.DB, xuiService service.ClientTrafficProvider) {
+func (h *Handler) ProcessUpdate(ctx context.Context, update tgbotapi.Update) {
+	if !h.allowUpdate(ctx, update) {
+		return
+	}
+
 	if update.Message != nil {
-		handleMessage(ctx, update.Message, bot, db, xuiService)
+		h.handleMessage(ctx, update.Message)
 	}
 
 	// Handle other types of updates (callback queries, etc.)
 	if update.CallbackQuery != nil {
-		handleCallbackQuery(update.CallbackQuery, bot, db)
+		h.handleCallbackQuery(ctx, update.CallbackQuery)
+	}
+
+	if update.PreCheckoutQuery != nil {
+		h.handlePreCheckoutQuery(ctx, update.PreCheckoutQuery)
+	}
+
+	if update.InlineQuery != nil {
+		h.handleInlineQuery(ctx, update.InlineQuery)
+	}
+
+	if update.EditedMessage != nil {
+		h.handleEditedMessage(ctx, update.EditedMessage)
+	}
+
+	if update.MyChatMember != nil {
+		h.handleMyChatMember(ctx, update.MyChatMember)
+	}
+
+	if update.ChatMember != nil {
+		h.handleChatMember(update.ChatMember)
 	}
 }
 
-func handleMessage(ctx context.Context, message *tgbotapi.Message, bot BotSender, db *gorm.DB, xuiService service.ClientTrafficProvider) {
+func (h *Handler) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 	// Ignore messages without a sender
 	if message.From == nil {
 		return
 	}
 
+	// Messages in the operator chat are replies to support tickets
+	if h.supportChatID != 0 && message.Chat.ID == h.supportChatID {
+		h.handleSupportChatMessage(ctx, message)
+		return
+	}
+
+	// In groups the bot reacts only to commands and messages addressed to it
+	if !message.Chat.IsPrivate() && !h.addressedToBot(message) {
+		return
+	}
+
 	// Save user and message to the database
-	saveUser(message.From, db)
-	saveMessage(message, db)
+	newUser := saveUser(ctx, message.From, h.db)
+	h.saveMessage(ctx, message)
+
+	if message.SuccessfulPayment != nil {
+		h.handleSuccessfulPayment(ctx, message)
+		return
+	}
 
 	// Handle commands
 	if message.IsCommand() {
-		handleCommand(ctx, message, bot, db, xuiService)
+		h.handleCommand(ctx, message, newUser)
+		return
+	}
+
+	if !message.Chat.IsPrivate() {
+		h.handleGroupMention(message)
 		return
 	}
 
-	// Handle regular messages
+	// Handle regular messages: relay them to support
 	log.Printf("Message from %s: %s", formatUserInfo(message.From), message.Text)
-	msg := tgbotapi.NewMessage(message.Chat.ID, "Ваше сообщение получено: "+message.Text)
-	bot.Send(msg)
+	h.handleSupportRequest(ctx, message)
 }
 
-func handleCommand(ctx context.Context, message *tgbotapi.Message, bot BotSender, db *gorm.DB, xuiService service.ClientTrafficProvider) {
-	userInfo := formatUserInfo(message.From)
+// privateCommands - команды пользователей, ответ на которые содержит данные подписки или
+// аккаунта. В группах они не выполняются, чтобы данные не увидели другие участники.
+var privateCommands = map[string]bool{
+	"getclient": true,
+	"usage":     true,
+	"buy":       true,
+	"referral":  true,
+}
+
+// handleCommand обрабатывает команды. newUser - пользователь написал боту впервые.
+func (h *Handler) handleCommand(ctx context.Context, message *tgbotapi.Message, newUser bool) {
+	if !h.allowCommand(message) {
+		return
+	}
+	if privateCommands[message.Command()] && !h.requirePrivateChat(message) {
+		return
+	}
 
 	switch message.Command() {
 	case "start":
-		// Corrected greeting message
-		msgText := "Привет, " + userInfo + ", рады видеть вас снова!"
-		msg := tgbotapi.NewMessage(message.Chat.ID, msgText)
-		bot.Send(msg)
+		h.handleStartCommand(ctx, message, newUser)
 	case "help":
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Доступные команды:\n/start - Начать работу с ботом\n/help - Показать справку\n/getclient <email> - Получить данные по клиенту")
-		bot.Send(msg)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Доступные команды:\n/start - Начать работу с ботом\n/help - Показать справку\n/getclient <email> - Получить данные по клиенту\n/usage [7d|30d] - График расхода трафика\n/timezone <пояс> - Часовой пояс для дат\n/trial - Получить пробный период\n/buy - Купить или продлить подписку\n/referral - Пригласить друзей")
+		if h.isAdmin(message.From.ID) {
+			msg.Text += adminHelp
+		}
+		h.bot.Send(msg)
 	case "getclient":
-		handleGetClientCommand(ctx, message, bot, xuiService)
+		h.handleGetClient(ctx, message)
+	case "timezone":
+		h.handleTimezoneCommand(ctx, message)
+	case "usage":
+		h.handleUsageCommand(ctx, message)
+	case "stats", "users", "extend", "addtraffic", "disable", "enable", "link", "ban", "unban":
+		h.handleAdminCommand(ctx, message)
+	case "trial":
+		h.handleTrialCommand(ctx, message)
+	case "buy":
+		h.handleBuyCommand(ctx, message)
+	case "referral":
+		h.handleReferralCommand(ctx, message)
 	default:
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Неизвестная команда. Используй /help для получения справки.")
-		bot.Send(msg)
+		h.bot.Send(msg)
 	}
 }
 
+// requirePrivateChat сообщает, что команда доступна только в личном чате, если она пришла
+// из группы. Возвращает true для личного чата.
+func (h *Handler) requirePrivateChat(message *tgbotapi.Message) bool {
+	if message.Chat.IsPrivate() {
+		return true
+	}
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Эта команда доступна только в личных сообщениях с ботом."))
+	return false
+}
+
 // BotSender defines the interface for sending messages and making requests, allowing for mocking in tests.
 type BotSender interface {
 	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
 	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
 }
 
-func handleCallbackQuery(callback *tgbotapi.CallbackQuery, bot BotSender, db *gorm.DB) {
+func (h *Handler) handleCallbackQuery(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	if strings.HasPrefix(callback.Data, buyCallbackPrefix) {
+		h.handleBuyCallback(ctx, callback)
+		return
+	}
+	if strings.HasPrefix(callback.Data, usersCallbackPrefix) {
+		h.handleUsersCallback(ctx, callback)
+		return
+	}
+
 	// Handle callback queries
 	callbackConfig := tgbotapi.NewCallback(callback.ID, "Callback received!")
-	_, err := bot.Request(callbackConfig)
+	_, err := h.bot.Request(callbackConfig)
 	if err != nil {
 		log.Printf("ERROR: failed to send callback response: %v", err)
 	}
@@ -109,21 +299,81 @@ func handleCallbackQuery(callback *tgbotapi.CallbackQuery, bot BotSender, db *go
 	log.Printf("Callback from %s: %s", callback.From.UserName, callback.Data)
 }
 
-func saveUser(user *tgbotapi.User, db *gorm.DB) {
-	// Implementation for saving user to database
-	// This is a placeholder - implement based on your database schema
-	log.Printf("User: %d - %s", user.ID, user.UserName)
+// saveUser сохраняет пользователя и обновляет его профиль.
+// Возвращает true, если пользователь написал боту впервые.
+func saveUser(ctx context.Context, user *tgbotapi.User, db *gorm.DB) bool {
+	if db == nil {
+		return false
+	}
+
+	record := database.User{
+		TelegramID: user.ID,
+		Username:   user.UserName,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+	}
+	result := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		log.Printf("ERROR: failed to save user %d: %v", user.ID, result.Error)
+		return false
+	}
+	if result.RowsAffected > 0 {
+		return true
+	}
+
+	err := db.WithContext(ctx).Model(&database.User{}).Where("telegram_id = ?", user.ID).Updates(map[string]interface{}{
+		"username":   user.UserName,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+	}).Error
+	if err != nil {
+		log.Printf("ERROR: failed to update user %d: %v", user.ID, err)
+	}
+	return false
 }
 
-func saveMessage(message *tgbotapi.Message, db *gorm.DB) {
-	// Implementation for saving message to database
-	// This is a placeholder - implement based on your database schema
-	log.Printf("Message from %d: %s", message.From.ID, message.Text)
+// saveMessage сохраняет входящее сообщение в историю переписки (таблица messages).
+func (h *Handler) saveMessage(ctx context.Context, message *tgbotapi.Message) {
+	if h.db == nil {
+		return
+	}
+
+	messageType := messageContentType(message)
+	if message.IsCommand() {
+		messageType = "command"
+	}
+	if err := h.messages.SaveIncoming(ctx, message.From.ID, database.Message{
+		ChatID:            message.Chat.ID,
+		TelegramMessageID: message.MessageID,
+		MessageText:       messageText(message),
+		MessageType:       messageType,
+	}); err != nil {
+		log.Printf("ERROR: failed to save message of %d: %v", message.From.ID, err)
+	}
+}
+
+// handleGetClient проверяет доступ к клиенту и выполняет /getclient.
+// Администраторы могут смотреть любого клиента, остальные - только привязанных к ним.
+func (h *Handler) handleGetClient(ctx context.Context, message *tgbotapi.Message) {
+	email := strings.TrimSpace(message.CommandArguments())
+	if email != "" {
+		allowed, err := h.canViewClient(ctx, message.From.ID, email)
+		if err != nil {
+			log.Printf("ERROR: failed to check access of %d to client [%s]: %v", message.From.ID, email, err)
+			h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении данных. Пожалуйста, попробуйте позже."))
+			return
+		}
+		if !allowed {
+			h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Клиент с email %s не найден.", email)))
+			return
+		}
+	}
+	handleGetClientCommand(ctx, message, h.bot, h.xui, h.userLocation(ctx, message.From.ID))
 }
 
-// formatUserInfo creates a user-friendly string from a User object.
 // handleGetClientCommand processes the /getclient command and sends the data as a formatted table.
-func handleGetClientCommand(ctx context.Context, message *tgbotapi.Message, bot BotSender, xuiService service.ClientTrafficProvider) {
+// Dates are shown in loc; tables longer than the Telegram limit are sent in several messages.
+func handleGetClientCommand(ctx context.Context, message *tgbotapi.Message, bot BotSender, xuiService service.ClientTrafficProvider, loc *time.Location) {
 	email := strings.TrimSpace(message.CommandArguments())
 	if email == "" {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Пожалуйста, укажите email после команды. Пример: /getclient user@example.com")
@@ -145,39 +395,14 @@ func handleGetClientCommand(ctx context.Context, message *tgbotapi.Message, bot
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("<b>Данные для клиента:</b> <code>%s</code>\n", email))
-	sb.WriteString("<pre>")
-	sb.WriteString(fmt.Sprintf("%-22s | %-13s | %-10s | %s\n", "Email", "Usage (GB)", "Expiry", "Status"))
-	sb.WriteString(strings.Repeat("-", 56) + "\n")
-
-	for _, traffic := range clientTraffics {
-		upGB := float64(traffic.Up) / (1024 * 1024 * 1024)
-		downGB := float64(traffic.Down) / (1024 * 1024 * 1024)
-		totalGB := float64(traffic.Total) / (1024 * 1024 * 1024)
-		usageStr := fmt.Sprintf("%.2f/%.2f", upGB+downGB, totalGB)
-
-		expiry := time.Unix(0, traffic.ExpiryTime*int64(time.Millisecond))
-		expiryStr := expiry.Format("02.01.2006")
-
-		status := "❌"
-		if traffic.Enable {
-			status = "✅"
-		}
-
-		// Truncate email if it's too long
-		displayEmail := traffic.Email
-		if len(displayEmail) > 20 {
-			displayEmail = displayEmail[:19] + ".."
+	for _, text := range render.ClientTable(email, clientTraffics, render.TableOptions{Location: loc}) {
+		msg := tgbotapi.NewMessage(message.Chat.ID, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		if _, err := bot.Send(msg); err != nil {
+			log.Printf("ERROR: failed to send client table for email [%s]: %v", email, err)
+			return
 		}
-
-		sb.WriteString(fmt.Sprintf("%-22s | %-13s | %-10s | %s\n", displayEmail, usageStr, expiryStr, status))
 	}
-	sb.WriteString("</pre>")
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, sb.String())
-	msg.ParseMode = tgbotapi.ModeHTML
-	bot.Send(msg)
 }
 
 // formatUserInfo creates a user-friendly string from a User object.
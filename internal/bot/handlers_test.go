@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"go-bot/internal/xui"
 
@@ -32,6 +33,9 @@ func (m *MockBotSender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, er
 // MockXUIService is a mock implementation of the XUIService.
 type MockXUIService struct {
 	GetClientTrafficsFunc func(ctx context.Context, email string) ([]xui.ClientTraffic, error)
+	SearchClientsFunc     func(ctx context.Context, query string, limit int) ([]xui.ClientTraffic, error)
+	ExtendClientFunc      func(ctx context.Context, email string, days int, extraBytes int64) (*xui.InboundClient, error)
+	SetClientEnabledFunc  func(ctx context.Context, email string, enable bool) (*xui.InboundClient, error)
 }
 
 func (m *MockXUIService) GetClientTraffics(ctx context.Context, email string) ([]xui.ClientTraffic, error) {
@@ -41,6 +45,35 @@ func (m *MockXUIService) GetClientTraffics(ctx context.Context, email string) ([
 	return nil, errors.New("GetClientTrafficsFunc not implemented")
 }
 
+func (m *MockXUIService) SearchClients(ctx context.Context, query string, limit int) ([]xui.ClientTraffic, error) {
+	if m.SearchClientsFunc != nil {
+		return m.SearchClientsFunc(ctx, query, limit)
+	}
+	return nil, errors.New("SearchClientsFunc not implemented")
+}
+
+func (m *MockXUIService) ExtendClient(ctx context.Context, email string, days int, extraBytes int64) (*xui.InboundClient, error) {
+	if m.ExtendClientFunc != nil {
+		return m.ExtendClientFunc(ctx, email, days, extraBytes)
+	}
+	return nil, errors.New("ExtendClient not implemented")
+}
+
+func (m *MockXUIService) ListClientTraffics(ctx context.Context) ([]xui.ClientTraffic, error) {
+	return nil, errors.New("ListClientTraffics not implemented")
+}
+
+func (m *MockXUIService) SetClientEnabled(ctx context.Context, email string, enable bool) (*xui.InboundClient, error) {
+	if m.SetClientEnabledFunc != nil {
+		return m.SetClientEnabledFunc(ctx, email, enable)
+	}
+	return nil, errors.New("SetClientEnabled not implemented")
+}
+
+func (m *MockXUIService) CreateClient(ctx context.Context, inboundID int, client xui.InboundClient) (*xui.InboundClient, error) {
+	return nil, errors.New("CreateClient not implemented")
+}
+
 func TestHandleGetClientCommand_TableFormat(t *testing.T) {
 	// --- Arrange ---
 
@@ -76,7 +109,7 @@ func TestHandleGetClientCommand_TableFormat(t *testing.T) {
 	}
 
 	// --- Act ---
-	handleGetClientCommand(context.Background(), message, mockBot, mockXUIService)
+	handleGetClientCommand(context.Background(), message, mockBot, mockXUIService, time.UTC)
 
 	// --- Assert ---
 	// Проверяем, что бот попытался отправить ровно одно сообщение
@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go-bot/internal/render"
+	"go-bot/internal/xui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// inlineCacheTTL - сколько результаты inline-запроса пользователя хранятся в кэше бота
+	// и на стороне Telegram (cache_time).
+	inlineCacheTTL = 30 * time.Second
+	// inlineMaxResults ограничивает количество карточек в ответе на inline-запрос.
+	inlineMaxResults = 20
+	// inlineMinSearchLength - минимальная длина запроса для поиска клиентов администратором.
+	inlineMinSearchLength = 3
+)
+
+// handleInlineQuery отвечает на inline-запрос "@bot [email]" карточками с трафиком и сроком подписки.
+// Пользователь видит своих привязанных клиентов, администратор может искать любых клиентов по email.
+func (h *Handler) handleInlineQuery(ctx context.Context, query *tgbotapi.InlineQuery) {
+	text := strings.TrimSpace(query.Query)
+
+	clients, ok := h.inlineCache.get(query.From.ID, text)
+	if !ok {
+		// Telegram присылает запрос на каждое изменение текста, поэтому запросы к 3x-ui ограничены.
+		// Ограничение действует и на администраторов: их поиск обходит всех клиентов панели.
+		if h.inlineLimiter != nil {
+			if allowed, retryAfter := h.inlineLimiter.allow(query.From.ID, time.Now()); !allowed {
+				seconds := int(retryAfter.Round(time.Second) / time.Second)
+				h.answerInline(query.ID, nil, fmt.Sprintf("Слишком много запросов, повторите через %d сек.", max(seconds, 1)))
+				return
+			}
+		}
+
+		var err error
+		clients, err = h.inlineClients(ctx, query.From.ID, text)
+		if err != nil {
+			log.Printf("ERROR: failed to get clients for inline query from %d: %v", query.From.ID, err)
+			h.answerInline(query.ID, nil, "Не удалось получить данные, откройте бота")
+			return
+		}
+		h.inlineCache.put(query.From.ID, text, clients)
+	}
+
+	if len(clients) == 0 {
+		hint := "Подписка не найдена, откройте бота"
+		if h.isAdmin(query.From.ID) && text != "" {
+			hint = "Клиенты не найдены"
+		}
+		h.answerInline(query.ID, nil, hint)
+		return
+	}
+
+	results := make([]interface{}, 0, len(clients))
+	loc, now := h.userLocation(ctx, query.From.ID), time.Now()
+	for _, client := range clients {
+		article := tgbotapi.NewInlineQueryResultArticleHTML(inlineResultID(client.Email), client.Email, formatClientCard(client, loc, now))
+		article.Description = formatClientSummary(client, loc, now)
+		results = append(results, article)
+	}
+	h.answerInline(query.ID, results, "")
+}
+
+// inlineClients выбирает клиентов для inline-запроса с теми же правилами доступа, что и у /getclient.
+func (h *Handler) inlineClients(ctx context.Context, telegramID int64, query string) ([]xui.ClientTraffic, error) {
+	if h.isAdmin(telegramID) && len([]rune(query)) >= inlineMinSearchLength {
+		return h.xui.SearchClients(ctx, query, inlineMaxResults)
+	}
+
+	clients, err := h.linkedClients(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		return clients, nil
+	}
+
+	filtered := clients[:0]
+	for _, client := range clients {
+		if strings.Contains(strings.ToLower(client.Email), strings.ToLower(query)) {
+			filtered = append(filtered, client)
+		}
+	}
+	return filtered, nil
+}
+
+// answerInline отправляет результаты inline-запроса. Ответ персональный, так как
+// зависит от пользователя. Если результатов нет, показывается кнопка перехода в бота.
+func (h *Handler) answerInline(queryID string, results []interface{}, switchPMText string) {
+	if results == nil {
+		results = []interface{}{}
+	}
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       results,
+		CacheTime:     int(inlineCacheTTL.Seconds()),
+		IsPersonal:    true,
+	}
+	if switchPMText != "" {
+		answer.SwitchPMText = switchPMText
+		answer.SwitchPMParameter = "inline"
+	}
+	if _, err := h.bot.Request(answer); err != nil {
+		log.Printf("ERROR: failed to answer inline query: %v", err)
+	}
+}
+
+// formatClientCard формирует текст сообщения, которое отправляется при выборе карточки.
+func formatClientCard(client xui.ClientTraffic, loc *time.Location, now time.Time) string {
+	status := "✅ активен"
+	if !client.Enable {
+		status = "❌ отключен"
+	}
+	return fmt.Sprintf("<b>Подписка</b> <code>%s</code>\nТрафик: %s\nСрок: %s\nСтатус: %s",
+		html.EscapeString(client.Email), render.TrafficLeft(client), render.Expiry(client.ExpiryTime, loc, now), status)
+}
+
+// formatClientSummary формирует короткое описание карточки в списке результатов.
+func formatClientSummary(client xui.ClientTraffic, loc *time.Location, now time.Time) string {
+	return fmt.Sprintf("Трафик: %s · Срок: %s", render.TrafficLeft(client), render.Expiry(client.ExpiryTime, loc, now))
+}
+
+// inlineResultID возвращает стабильный ID результата, Telegram ограничивает его 64 байтами.
+func inlineResultID(email string) string {
+	sum := sha1.Sum([]byte(email))
+	return hex.EncodeToString(sum[:])
+}
+
+// inlineCache кратковременно хранит клиентов, найденных по inline-запросам, чтобы
+// набор текста в поле ввода не порождал запрос к 3x-ui на каждый символ.
+type inlineCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[inlineCacheKey]inlineCacheEntry
+}
+
+type inlineCacheKey struct {
+	userID int64
+	query  string
+}
+
+type inlineCacheEntry struct {
+	clients []xui.ClientTraffic
+	expires time.Time
+}
+
+func newInlineCache(ttl time.Duration) *inlineCache {
+	return &inlineCache{ttl: ttl, entries: make(map[inlineCacheKey]inlineCacheEntry)}
+}
+
+func (c *inlineCache) get(userID int64, query string) ([]xui.ClientTraffic, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[inlineCacheKey{userID: userID, query: query}]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.clients, true
+}
+
+func (c *inlineCache) put(userID int64, query string, clients []xui.ClientTraffic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Устаревшие записи удаляются при записи, отдельная горутина для очистки не нужна.
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[inlineCacheKey{userID: userID, query: query}] = inlineCacheEntry{clients: clients, expires: now.Add(c.ttl)}
+}
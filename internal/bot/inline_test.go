@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-bot/internal/xui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleInlineQuery_AdminSearch(t *testing.T) {
+	// --- Arrange ---
+	searches := 0
+	mockXUIService := &MockXUIService{
+		SearchClientsFunc: func(ctx context.Context, query string, limit int) ([]xui.ClientTraffic, error) {
+			searches++
+			assert.Equal(t, "example", query)
+			return []xui.ClientTraffic{
+				{
+					ID:         1,
+					Email:      "test@example.com",
+					Enable:     true,
+					Up:         1024 * 1024 * 1024,      // 1 GB
+					Down:       2 * 1024 * 1024 * 1024,  // 2 GB
+					Total:      10 * 1024 * 1024 * 1024, // 10 GB
+					ExpiryTime: 0,
+				},
+			}, nil
+		},
+	}
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{Bot: mockBot, XUI: mockXUIService, AdminIDs: []int64{42}})
+
+	update := tgbotapi.Update{
+		InlineQuery: &tgbotapi.InlineQuery{ID: "query-1", From: &tgbotapi.User{ID: 42}, Query: " example "},
+	}
+
+	// --- Act ---
+	handler.ProcessUpdate(context.Background(), update)
+	handler.ProcessUpdate(context.Background(), update)
+
+	// --- Assert ---
+	assert.Equal(t, 1, searches, "Repeated query should be served from the cache")
+	require.Len(t, mockBot.SentMessages, 2)
+
+	answer, ok := mockBot.SentMessages[0].(tgbotapi.InlineConfig)
+	require.True(t, ok, "Sent chattable should be of type InlineConfig")
+	assert.True(t, answer.IsPersonal, "Inline results depend on the user and must be personal")
+	require.Len(t, answer.Results, 1)
+
+	article, ok := answer.Results[0].(tgbotapi.InlineQueryResultArticle)
+	require.True(t, ok, "Result should be an article")
+	assert.Equal(t, "test@example.com", article.Title)
+	assert.Contains(t, article.Description, "осталось 7.00 из 10.00 GB")
+	assert.Contains(t, article.Description, "бессрочно")
+}
+
+func TestHandleInlineQuery_Throttled(t *testing.T) {
+	// --- Arrange ---
+	searches := 0
+	mockXUIService := &MockXUIService{
+		SearchClientsFunc: func(ctx context.Context, query string, limit int) ([]xui.ClientTraffic, error) {
+			searches++
+			return nil, nil
+		},
+	}
+	const inlineRateLimit = 3
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{Bot: mockBot, XUI: mockXUIService, AdminIDs: []int64{42},
+		InlineRateLimit: inlineRateLimit, CommandRateWindow: time.Minute})
+
+	// --- Act ---
+	// Каждое изменение текста - новый запрос мимо кэша.
+	for i := 0; i <= inlineRateLimit; i++ {
+		update := tgbotapi.Update{
+			InlineQuery: &tgbotapi.InlineQuery{ID: "query", From: &tgbotapi.User{ID: 42}, Query: fmt.Sprintf("client%d", i)},
+		}
+		handler.ProcessUpdate(context.Background(), update)
+	}
+
+	// --- Assert ---
+	assert.Equal(t, inlineRateLimit, searches, "Queries over the limit must not reach 3x-ui")
+	require.Len(t, mockBot.SentMessages, inlineRateLimit+1)
+	answer, ok := mockBot.SentMessages[inlineRateLimit].(tgbotapi.InlineConfig)
+	require.True(t, ok, "Sent chattable should be of type InlineConfig")
+	assert.Empty(t, answer.Results)
+	assert.Contains(t, answer.SwitchPMText, "Слишком много запросов")
+}
+
+func TestHandleInlineQuery_ZeroLimitDisablesThrottling(t *testing.T) {
+	// --- Arrange ---
+	searches := 0
+	mockXUIService := &MockXUIService{
+		SearchClientsFunc: func(ctx context.Context, query string, limit int) ([]xui.ClientTraffic, error) {
+			searches++
+			return nil, nil
+		},
+	}
+	handler := NewHandler(Deps{Bot: &MockBotSender{}, XUI: mockXUIService, AdminIDs: []int64{42},
+		InlineRateLimit: 0, CommandRateWindow: time.Minute})
+
+	// --- Act ---
+	for i := 0; i < 50; i++ {
+		update := tgbotapi.Update{
+			InlineQuery: &tgbotapi.InlineQuery{ID: "query", From: &tgbotapi.User{ID: 42}, Query: fmt.Sprintf("client%d", i)},
+		}
+		handler.ProcessUpdate(context.Background(), update)
+	}
+
+	// --- Assert ---
+	assert.Equal(t, 50, searches, "BOT_INLINE_RATE_LIMIT=0 must not throttle inline queries")
+}
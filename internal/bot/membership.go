@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// AllowedUpdates - типы обновлений, которые бот запрашивает у Telegram. chat_member
+// не приходит по умолчанию, поэтому список передается явно в setWebhook и getUpdates.
+var AllowedUpdates = []string{
+	"message",
+	"edited_message",
+	"callback_query",
+	"inline_query",
+	"pre_checkout_query",
+	"my_chat_member",
+	"chat_member",
+}
+
+// handleMyChatMember обрабатывает изменение статуса самого бота в чате. В личном чате это
+// блокировка и разблокировка бота пользователем, в группах и каналах - добавление и удаление бота.
+func (h *Handler) handleMyChatMember(ctx context.Context, update *tgbotapi.ChatMemberUpdated) {
+	if update.Chat.IsPrivate() {
+		active := isChatMember(update.NewChatMember)
+		if active {
+			log.Printf("User %s unblocked the bot", formatUserInfo(&update.From))
+		} else {
+			log.Printf("User %s blocked the bot", formatUserInfo(&update.From))
+		}
+		if h.db == nil {
+			return
+		}
+		user := database.User{
+			TelegramID: update.From.ID,
+			Username:   update.From.UserName,
+			FirstName:  update.From.FirstName,
+			LastName:   update.From.LastName,
+		}
+		if err := h.users.SetActive(ctx, user, active, time.Unix(int64(update.Date), 0)); err != nil {
+			log.Printf("ERROR: failed to update activity of user %d: %v", update.From.ID, err)
+		}
+		return
+	}
+
+	joined := isChatMember(update.NewChatMember) && !isChatMember(update.OldChatMember)
+	log.Printf("Bot status in chat %d (%s) changed: %s -> %s by %d", update.Chat.ID, update.Chat.Title,
+		update.OldChatMember.Status, update.NewChatMember.Status, update.From.ID)
+
+	if h.db != nil {
+		chat := database.Chat{
+			ChatID:   update.Chat.ID,
+			Type:     update.Chat.Type,
+			Title:    update.Chat.Title,
+			Username: update.Chat.UserName,
+			Status:   update.NewChatMember.Status,
+		}
+		if joined {
+			chat.AddedBy = &update.From.ID
+		}
+		if err := h.chats.Save(ctx, chat); err != nil {
+			log.Printf("ERROR: failed to save chat %d: %v", update.Chat.ID, err)
+		}
+	}
+
+	if joined && !update.Chat.IsChannel() && update.Chat.ID != h.supportChatID {
+		h.bot.Send(tgbotapi.NewMessage(update.Chat.ID, fmt.Sprintf(
+			"Всем привет! В группе я отвечаю только на команды, адресованные мне (например, /help@%s), и упоминания @%s. "+
+				"Вопросы по подписке лучше задавать в личных сообщениях.", h.botUsername, h.botUsername)))
+	}
+}
+
+// handleChatMember обрабатывает изменение статуса участников групп, где бот - администратор.
+// Участники групп не влияют на работу бота, изменения только записываются в лог.
+func (h *Handler) handleChatMember(update *tgbotapi.ChatMemberUpdated) {
+	if update.NewChatMember.User == nil {
+		return
+	}
+	log.Printf("Member %s of chat %d (%s): %s -> %s", formatUserInfo(update.NewChatMember.User),
+		update.Chat.ID, update.Chat.Title, update.OldChatMember.Status, update.NewChatMember.Status)
+}
+
+// isChatMember сообщает, состоит ли участник в чате. Ограниченный (restricted) участник
+// может как состоять в чате, так и нет.
+func isChatMember(member tgbotapi.ChatMember) bool {
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return member.IsMember
+	default:
+		return false
+	}
+}
+
+// handleEditedMessage обрабатывает исправленные сообщения. Команды повторно не выполняются,
+// исправленное обращение в поддержку пересылается операторам еще раз с пометкой.
+func (h *Handler) handleEditedMessage(ctx context.Context, message *tgbotapi.Message) {
+	if message.From == nil || !message.Chat.IsPrivate() || message.IsCommand() {
+		return
+	}
+	if h.support == nil || h.supportChatID == 0 {
+		return
+	}
+
+	original, err := h.support.FindIncomingMessage(ctx, message.From.ID, message.MessageID)
+	if err != nil {
+		if !errors.Is(err, service.ErrTicketNotFound) {
+			log.Printf("ERROR: failed to find relayed message %d of %d: %v", message.MessageID, message.From.ID, err)
+		}
+		// Сообщение не было обращением в поддержку.
+		return
+	}
+
+	ticket, err := h.support.Get(ctx, original.TicketID, false)
+	if err != nil {
+		log.Printf("ERROR: failed to get ticket #%d: %v", original.TicketID, err)
+		return
+	}
+	if ticket.Status == database.TicketStatusClosed {
+		return
+	}
+
+	header := fmt.Sprintf("<b>Обращение #%d</b>: сообщение изменено", ticket.ID)
+	supportMessageID, err := h.relayToSupport(message, header)
+	if err != nil {
+		log.Printf("ERROR: failed to relay edited message of %d to support chat: %v", message.From.ID, err)
+		return
+	}
+
+	// Операторы могут ответить и на исправленную версию.
+	if err := h.support.AddMessage(ctx, &database.TicketMessage{
+		TicketID:         ticket.ID,
+		Direction:        database.TicketMessageIncoming,
+		UserMessageID:    message.MessageID,
+		SupportMessageID: supportMessageID,
+		ContentType:      messageContentType(message),
+		Text:             messageText(message),
+	}); err != nil {
+		log.Printf("ERROR: failed to save edited message of ticket #%d: %v", ticket.ID, err)
+	}
+}
+
+// addressedToBot сообщает, обращено ли сообщение в группе к боту: команда без @ или с @ бота,
+// упоминание бота или ответ на его сообщение.
+func (h *Handler) addressedToBot(message *tgbotapi.Message) bool {
+	if message.IsCommand() {
+		command := message.CommandWithAt()
+		at := strings.Index(command, "@")
+		return at < 0 || strings.EqualFold(command[at+1:], h.botUsername)
+	}
+	if h.botUsername == "" {
+		return false
+	}
+	if reply := message.ReplyToMessage; reply != nil && reply.From != nil && strings.EqualFold(reply.From.UserName, h.botUsername) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(messageText(message)), "@"+strings.ToLower(h.botUsername))
+}
+
+// handleGroupMention отвечает на упоминание бота в группе: обращения в поддержку
+// принимаются только в личном чате.
+func (h *Handler) handleGroupMention(message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(
+		"Чтобы задать вопрос поддержке, напишите мне в личные сообщения: https://t.me/%s. Список команд - /help@%s.",
+		h.botUsername, h.botUsername))
+	msg.ReplyToMessageID = message.MessageID
+	msg.DisableWebPagePreview = true
+	h.bot.Send(msg)
+}
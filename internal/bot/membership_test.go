@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-bot/internal/xui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// groupMessage создает сообщение в группе; команда определяется по первому слову, начинающемуся с /.
+func groupMessage(text string) *tgbotapi.Message {
+	message := &tgbotapi.Message{
+		MessageID: 7,
+		From:      &tgbotapi.User{ID: 100, FirstName: "Alice"},
+		Chat:      &tgbotapi.Chat{ID: -1001, Type: "supergroup", Title: "VPN chat"},
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	return message
+}
+
+func TestAddressedToBot(t *testing.T) {
+	handler := NewHandler(Deps{Bot: &MockBotSender{}, BotUsername: "vpn_bot"})
+
+	tests := []struct {
+		name    string
+		message *tgbotapi.Message
+		want    bool
+	}{
+		{name: "command without username", message: groupMessage("/help"), want: true},
+		{name: "command with bot username", message: groupMessage("/help@VPN_bot"), want: true},
+		{name: "command for another bot", message: groupMessage("/help@other_bot"), want: false},
+		{name: "mention", message: groupMessage("@vpn_bot как продлить?"), want: true},
+		{name: "regular message", message: groupMessage("всем привет"), want: false},
+		{name: "reply to bot", message: func() *tgbotapi.Message {
+			message := groupMessage("спасибо")
+			message.ReplyToMessage = &tgbotapi.Message{From: &tgbotapi.User{ID: 1, UserName: "vpn_bot", IsBot: true}}
+			return message
+		}(), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, handler.addressedToBot(tt.message))
+		})
+	}
+}
+
+func TestProcessUpdate_GroupMessages(t *testing.T) {
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{Bot: mockBot, XUI: &MockXUIService{}, BotUsername: "vpn_bot"})
+
+	// Обычные сообщения и команды другим ботам игнорируются.
+	handler.ProcessUpdate(context.Background(), tgbotapi.Update{Message: groupMessage("всем привет")})
+	handler.ProcessUpdate(context.Background(), tgbotapi.Update{Message: groupMessage("/help@other_bot")})
+	assert.Empty(t, mockBot.SentMessages)
+
+	// На упоминание бот отвечает подсказкой.
+	handler.ProcessUpdate(context.Background(), tgbotapi.Update{Message: groupMessage("@vpn_bot помогите")})
+	require.Len(t, mockBot.SentMessages, 1)
+	msg := mockBot.SentMessages[0].(tgbotapi.MessageConfig)
+	assert.Equal(t, int64(-1001), msg.ChatID)
+	assert.Equal(t, 7, msg.ReplyToMessageID)
+	assert.Contains(t, msg.Text, "https://t.me/vpn_bot")
+
+	// Адресованные боту команды выполняются.
+	handler.ProcessUpdate(context.Background(), tgbotapi.Update{Message: groupMessage("/help@vpn_bot")})
+	require.Len(t, mockBot.SentMessages, 2)
+	assert.Contains(t, mockBot.SentMessages[1].(tgbotapi.MessageConfig).Text, "Доступные команды")
+}
+
+func TestProcessUpdate_BotAddedToGroup(t *testing.T) {
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{Bot: mockBot, BotUsername: "vpn_bot"})
+
+	handler.ProcessUpdate(context.Background(), tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: -1001, Type: "supergroup", Title: "VPN chat"},
+		From:          tgbotapi.User{ID: 100},
+		OldChatMember: tgbotapi.ChatMember{Status: "left"},
+		NewChatMember: tgbotapi.ChatMember{Status: "member"},
+	}})
+
+	require.Len(t, mockBot.SentMessages, 1)
+	msg := mockBot.SentMessages[0].(tgbotapi.MessageConfig)
+	assert.Equal(t, int64(-1001), msg.ChatID)
+	assert.Contains(t, msg.Text, "/help@vpn_bot")
+
+	// Удаление из группы и блокировка в личном чате ничего не отправляют.
+	handler.ProcessUpdate(context.Background(), tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: -1001, Type: "supergroup"},
+		From:          tgbotapi.User{ID: 100},
+		OldChatMember: tgbotapi.ChatMember{Status: "member"},
+		NewChatMember: tgbotapi.ChatMember{Status: "kicked"},
+	}})
+	handler.ProcessUpdate(context.Background(), tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: 100, Type: "private"},
+		From:          tgbotapi.User{ID: 100},
+		OldChatMember: tgbotapi.ChatMember{Status: "member"},
+		NewChatMember: tgbotapi.ChatMember{Status: "kicked"},
+	}})
+	assert.Len(t, mockBot.SentMessages, 1)
+}
+
+func TestIsChatMember(t *testing.T) {
+	assert.True(t, isChatMember(tgbotapi.ChatMember{Status: "member"}))
+	assert.True(t, isChatMember(tgbotapi.ChatMember{Status: "administrator"}))
+	assert.True(t, isChatMember(tgbotapi.ChatMember{Status: "restricted", IsMember: true}))
+	assert.False(t, isChatMember(tgbotapi.ChatMember{Status: "restricted"}))
+	assert.False(t, isChatMember(tgbotapi.ChatMember{Status: "left"}))
+	assert.False(t, isChatMember(tgbotapi.ChatMember{Status: "kicked"}))
+}
+
+func TestProcessUpdate_DataCommandsPrivateOnly(t *testing.T) {
+	const adminID, userID = int64(1), int64(100)
+	called := false
+	mockXUIService := &MockXUIService{
+		GetClientTrafficsFunc: func(ctx context.Context, email string) ([]xui.ClientTraffic, error) {
+			called = true
+			return []xui.ClientTraffic{{Email: email}}, nil
+		},
+	}
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{Bot: mockBot, XUI: mockXUIService, AdminIDs: []int64{adminID}, BotUsername: "vpn_bot"})
+
+	groupCommand := func(fromID int64, text string) tgbotapi.Update {
+		message := groupMessage(text)
+		message.From.ID = fromID
+		return tgbotapi.Update{Message: message}
+	}
+
+	testCases := []struct {
+		name     string
+		update   tgbotapi.Update
+		expected string
+	}{
+		{name: "Getclient", update: groupCommand(userID, "/getclient user@example.com"), expected: "только в личных сообщениях"},
+		{name: "Usage", update: groupCommand(userID, "/usage"), expected: "только в личных сообщениях"},
+		{name: "Buy", update: groupCommand(userID, "/buy"), expected: "только в личных сообщениях"},
+		{name: "Admin Stats", update: groupCommand(adminID, "/stats"), expected: "только в личных сообщениях"},
+		{name: "Admin Users", update: groupCommand(adminID, "/users ivan"), expected: "только в личных сообщениях"},
+		{name: "Admin Getclient", update: groupCommand(adminID, "/getclient user@example.com"), expected: "только в личных сообщениях"},
+		// Для остальных пользователей команды администратора по-прежнему выглядят как неизвестные
+		{name: "User Stats", update: groupCommand(userID, "/stats"), expected: "Неизвестная команда"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockBot.SentMessages = nil
+
+			handler.ProcessUpdate(context.Background(), tc.update)
+
+			require.Len(t, mockBot.SentMessages, 1)
+			msg := mockBot.SentMessages[0].(tgbotapi.MessageConfig)
+			assert.Equal(t, int64(-1001), msg.ChatID)
+			assert.Contains(t, msg.Text, tc.expected)
+		})
+	}
+	assert.False(t, called, "3x-ui must not be queried for commands in groups")
+}
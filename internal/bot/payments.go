@@ -0,0 +1,213 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// buyCallbackPrefix - префикс callback data кнопок выбора тарифа: "buy:<plan_id>".
+const buyCallbackPrefix = "buy:"
+
+// handleBuyCommand показывает тарифы для продления привязанной подписки.
+func (h *Handler) handleBuyCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.payments == nil || !h.payments.Enabled() {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Оплата сейчас недоступна. Обратитесь в поддержку."))
+		return
+	}
+
+	link, err := h.links.Latest(ctx, message.From.ID)
+	if err != nil {
+		h.replyLinkError(message.Chat.ID, err)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, plan := range h.payments.Plans() {
+		button := tgbotapi.NewInlineKeyboardButtonData(formatPlan(plan, h.payments.Currency()), buyCallbackPrefix+plan.ID)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Выберите тариф для продления подписки <code>%s</code>:", link.Email))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.bot.Send(msg)
+}
+
+// handleBuyCallback выставляет счет за выбранный тариф.
+func (h *Handler) handleBuyCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	answer := tgbotapi.NewCallback(callback.ID, "")
+	defer func() {
+		if _, err := h.bot.Request(answer); err != nil {
+			log.Printf("ERROR: failed to send callback response: %v", err)
+		}
+	}()
+
+	if h.payments == nil || callback.Message == nil {
+		answer.Text = "Оплата сейчас недоступна."
+		return
+	}
+
+	plan, ok := h.payments.Plan(strings.TrimPrefix(callback.Data, buyCallbackPrefix))
+	if !ok {
+		answer.Text = "Этот тариф больше недоступен."
+		return
+	}
+
+	link, err := h.links.Latest(ctx, callback.From.ID)
+	if err != nil {
+		h.replyLinkError(callback.Message.Chat.ID, err)
+		return
+	}
+
+	invoice := tgbotapi.NewInvoice(
+		callback.Message.Chat.ID,
+		fmt.Sprintf("Подписка: %s", formatPlanTerms(plan)),
+		fmt.Sprintf("Продление подписки %s", link.Email),
+		h.payments.InvoicePayload(plan.ID, link.ID),
+		h.payments.ProviderToken(),
+		"",
+		h.payments.Currency(),
+		[]tgbotapi.LabeledPrice{{Label: formatPlanTerms(plan), Amount: plan.Price}},
+	)
+	// SuggestedTipAmounts сериализуется как null, если его не задать, и Telegram отклоняет счет.
+	invoice.SuggestedTipAmounts = []int{}
+
+	if _, err := h.bot.Send(invoice); err != nil {
+		log.Printf("ERROR: failed to send invoice to %d: %v", callback.From.ID, err)
+		answer.Text = "Не удалось выставить счет. Попробуйте позже."
+	}
+}
+
+// handlePreCheckoutQuery подтверждает или отклоняет платеж перед списанием.
+func (h *Handler) handlePreCheckoutQuery(ctx context.Context, query *tgbotapi.PreCheckoutQuery) {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: true}
+
+	if h.payments == nil {
+		answer.OK = false
+		answer.ErrorMessage = "Оплата сейчас недоступна."
+	} else if _, _, err := h.payments.ValidateCheckout(ctx, query.From.ID, query.InvoicePayload, query.Currency, query.TotalAmount); err != nil {
+		log.Printf("Pre-checkout rejected for %d: %v", query.From.ID, err)
+		answer.OK = false
+		switch {
+		case errors.Is(err, service.ErrUnknownPlan), errors.Is(err, service.ErrPriceMismatch):
+			answer.ErrorMessage = "Тариф изменился. Пожалуйста, выберите его заново через /buy."
+		case errors.Is(err, service.ErrClientNotLinked):
+			answer.ErrorMessage = "Подписка больше не привязана к вашему аккаунту. Обратитесь в поддержку."
+		default:
+			answer.ErrorMessage = "Не удалось проверить платеж. Попробуйте позже."
+		}
+	}
+
+	if _, err := h.bot.Request(answer); err != nil {
+		log.Printf("ERROR: failed to answer pre-checkout query: %v", err)
+	}
+}
+
+// handleSuccessfulPayment записывает платеж и продлевает подписку.
+func (h *Handler) handleSuccessfulPayment(ctx context.Context, message *tgbotapi.Message) {
+	paid := message.SuccessfulPayment
+	if h.payments == nil {
+		log.Printf("ERROR: received payment %s while payments are disabled", paid.TelegramPaymentChargeID)
+		return
+	}
+
+	payment, err := h.payments.CompletePayment(ctx, service.PaymentDetails{
+		TelegramID:              message.From.ID,
+		Currency:                paid.Currency,
+		Amount:                  paid.TotalAmount,
+		InvoicePayload:          paid.InvoicePayload,
+		TelegramPaymentChargeID: paid.TelegramPaymentChargeID,
+		ProviderPaymentChargeID: paid.ProviderPaymentChargeID,
+	})
+
+	var text string
+	switch {
+	case err != nil:
+		log.Printf("ERROR: failed to complete payment %s: %v", paid.TelegramPaymentChargeID, err)
+		text = "Оплата получена, но продлить подписку автоматически не удалось. Мы уже разбираемся, " +
+			"сохраните этот код платежа: " + paid.TelegramPaymentChargeID
+	case payment.Status == database.PaymentStatusApplied:
+		h.rewardReferrer(ctx, message.From.ID, database.ReferralEventPayment)
+		text = fmt.Sprintf("Спасибо! Подписка %s продлена: %s.", payment.ClientEmail, formatPlanTerms(service.Plan{Days: payment.Days, TrafficGB: payment.TrafficGB}))
+	default:
+		text = "Оплата получена, но продление подписки задерживается. Мы уже разбираемся, " +
+			"сохраните этот код платежа: " + paid.TelegramPaymentChargeID
+	}
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// RunPaymentRetries с заданным интервалом повторяет применение платежей, которые не удалось
+// применить из-за временной ошибки, и сообщает пользователям о продлении. Работает до отмены ctx.
+func (h *Handler) RunPaymentRetries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		h.retryPayments(ctx)
+	}
+}
+
+// retryPayments применяет ожидающие платежи и уведомляет пользователей о примененных.
+func (h *Handler) retryPayments(ctx context.Context) {
+	applied, err := h.payments.RetryPending(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("ERROR: failed to retry pending payments: %v", err)
+	}
+
+	for _, payment := range applied {
+		h.rewardReferrer(ctx, payment.TelegramID, database.ReferralEventPayment)
+		text := fmt.Sprintf("Оплата зачислена: подписка %s продлена: %s.", payment.ClientEmail,
+			formatPlanTerms(service.Plan{Days: payment.Days, TrafficGB: payment.TrafficGB}))
+		h.bot.Send(tgbotapi.NewMessage(payment.TelegramID, text))
+	}
+}
+
+// replyLinkError сообщает пользователю, что подписку не удалось найти.
+func (h *Handler) replyLinkError(chatID int64, err error) {
+	text := "Не удалось получить данные подписки. Попробуйте позже."
+	if errors.Is(err, service.ErrClientNotLinked) {
+		text = "К вашему аккаунту не привязана подписка. Обратитесь в поддержку."
+	} else {
+		log.Printf("ERROR: failed to get client link: %v", err)
+	}
+	h.bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// formatPlan формирует подпись кнопки тарифа: условия и цена.
+func formatPlan(plan service.Plan, currency string) string {
+	return fmt.Sprintf("%s — %s", formatPlanTerms(plan), formatPrice(plan.Price, currency))
+}
+
+// formatPlanTerms описывает, что добавляет тариф.
+func formatPlanTerms(plan service.Plan) string {
+	var terms []string
+	if plan.Days > 0 {
+		terms = append(terms, fmt.Sprintf("%d дн.", plan.Days))
+	}
+	if plan.TrafficGB > 0 {
+		terms = append(terms, fmt.Sprintf("+%d GB", plan.TrafficGB))
+	}
+	return strings.Join(terms, ", ")
+}
+
+// formatPrice форматирует цену в минимальных единицах валюты.
+func formatPrice(amount int, currency string) string {
+	if currency == service.CurrencyStars {
+		return fmt.Sprintf("%d ⭐", amount)
+	}
+	return fmt.Sprintf("%d.%02d %s", amount/100, amount%100, currency)
+}
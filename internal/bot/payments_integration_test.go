@@ -0,0 +1,76 @@
+//go:build integration
+
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-bot/internal/config"
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+	"go-bot/internal/xui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuy_LinkedClientWithoutTrial_Integration проверяет, что клиент, созданный в панели, а не
+// через /trial, после привязки администратором командой /link может продлить подписку через /buy.
+// Нужны переменные DB_* в deploy/.env и примененные миграции.
+func TestBuy_LinkedClientWithoutTrial_Integration(t *testing.T) {
+	err := godotenv.Load("../../deploy/.env")
+	require.NoError(t, err, "Failed to load .env file")
+	cfg, err := config.Load()
+	require.NoError(t, err, "Failed to load .env config")
+
+	db := database.Init(cfg)
+	t.Cleanup(func() { database.Close(db) })
+
+	suffix := time.Now().UnixNano()
+	adminID, userID := 900000000+suffix%1000000, 910000000+suffix%1000000
+	email := fmt.Sprintf("it-link-%d@example.com", suffix)
+	t.Cleanup(func() {
+		db.Where("email = ?", email).Delete(&database.ClientLink{})
+		db.Where("target = ?", email).Delete(&database.AuditLog{})
+		db.Where("telegram_id IN ?", []int64{adminID, userID}).Delete(&database.User{})
+	})
+
+	mockXUIService := &MockXUIService{
+		GetClientTrafficsFunc: func(ctx context.Context, query string) ([]xui.ClientTraffic, error) {
+			return []xui.ClientTraffic{{Email: email, InboundID: 3, Enable: true}}, nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	plans := []service.Plan{{ID: "1m", Days: 30, TrafficGB: 100, Price: 250}}
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{
+		Bot:      mockBot,
+		DB:       db,
+		XUI:      mockXUIService,
+		Payments: service.NewPaymentService(db, mockXUIService, plans, service.CurrencyStars, "", logger),
+		AdminIDs: []int64{adminID},
+	})
+
+	// --- Act & Assert ---
+	handler.ProcessUpdate(context.Background(), commandUpdate(userID, "/buy"))
+	require.NotEmpty(t, mockBot.SentMessages)
+	assert.Contains(t, mockBot.SentMessages[len(mockBot.SentMessages)-1].(tgbotapi.MessageConfig).Text, "не привязана подписка")
+
+	handler.ProcessUpdate(context.Background(), commandUpdate(adminID, fmt.Sprintf("/link %d %s", userID, email)))
+	assert.Contains(t, mockBot.SentMessages[len(mockBot.SentMessages)-1].(tgbotapi.MessageConfig).Text, "привязан к пользователю")
+
+	handler.ProcessUpdate(context.Background(), commandUpdate(userID, "/buy"))
+	msg := mockBot.SentMessages[len(mockBot.SentMessages)-1].(tgbotapi.MessageConfig)
+	assert.Contains(t, msg.Text, email)
+	markup, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	require.True(t, ok, "Plans should be offered as inline buttons")
+	require.Len(t, markup.InlineKeyboard, 1)
+	assert.Equal(t, buyCallbackPrefix+"1m", *markup.InlineKeyboard[0][0].CallbackData)
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pollingRetryDelay - пауза перед повторным запросом getUpdates после ошибки.
+const pollingRetryDelay = 3 * time.Second
+
+// UpdateHandler обрабатывает одно обновление от Telegram.
+type UpdateHandler func(ctx context.Context, update tgbotapi.Update)
+
+// DeleteWebhook удаляет webhook, без этого Telegram отклоняет вызовы getUpdates.
+func DeleteWebhook(bot *tgbotapi.BotAPI) error {
+	_, err := bot.Request(tgbotapi.DeleteWebhookConfig{})
+	return err
+}
+
+// RunPolling получает обновления через long polling (getUpdates) и передает их в handler
+// до отмены ctx. Обновления обрабатываются последовательно; текущее обновление
+// дообрабатывается с контекстом без отмены, после чего функция возвращает управление.
+// Незавершенный запрос getUpdates при остановке прерывается, а offset уже обработанных
+// обновлений подтверждается, чтобы Telegram не прислал их повторно после рестарта.
+func RunPolling(ctx context.Context, bot *tgbotapi.BotAPI, timeoutSeconds int, handler UpdateHandler) {
+	updateConfig := tgbotapi.NewUpdate(0)
+	updateConfig.Timeout = timeoutSeconds
+	updateConfig.AllowedUpdates = AllowedUpdates
+
+	processCtx := context.WithoutCancel(ctx)
+
+	for {
+		updates, err := getUpdates(ctx, bot, updateConfig)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			log.Printf("Error getting updates, retrying in %s: %v", pollingRetryDelay, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollingRetryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			if ctx.Err() != nil {
+				break
+			}
+			if update.UpdateID < updateConfig.Offset {
+				continue
+			}
+			updateConfig.Offset = update.UpdateID + 1
+			handler(processCtx, update)
+		}
+	}
+
+	confirmOffset(bot, updateConfig.Offset)
+	log.Printf("Polling stopped")
+}
+
+// confirmOffset сообщает Telegram, что все обновления с ID меньше offset обработаны.
+func confirmOffset(bot *tgbotapi.BotAPI, offset int) {
+	if offset == 0 {
+		return
+	}
+	confirm := tgbotapi.UpdateConfig{Offset: offset, Limit: 1, Timeout: 0}
+	if _, err := bot.GetUpdates(confirm); err != nil {
+		log.Printf("Error confirming polling offset %d: %v", offset, err)
+	}
+}
+
+// getUpdates выполняет вызов getUpdates, привязанный к ctx: при отмене ctx HTTP-запрос
+// прерывается, а не висит до истечения таймаута long polling.
+func getUpdates(ctx context.Context, bot *tgbotapi.BotAPI, config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	// Копия бота нужна, чтобы не подменять Client, которым одновременно пользуются обработчики.
+	pollBot := *bot
+	pollBot.Client = contextClient{ctx: ctx, client: bot.Client}
+	return pollBot.GetUpdates(config)
+}
+
+// contextClient добавляет ctx ко всем запросам, которые tgbotapi создает без контекста.
+type contextClient struct {
+	ctx    context.Context
+	client tgbotapi.HTTPClient
+}
+
+func (c contextClient) Do(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req.WithContext(c.ctx))
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go-bot/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleStartCommand приветствует пользователя и обрабатывает deep link /start <payload>.
+// Реферер записывается только для новых пользователей, иначе уже знакомый боту
+// пользователь мог бы "пригласить" сам себя через чужую ссылку.
+func (h *Handler) handleStartCommand(ctx context.Context, message *tgbotapi.Message, newUser bool) {
+	userInfo := formatUserInfo(message.From)
+
+	if newUser && h.referral != nil {
+		if referrerID, ok := service.ParseReferralPayload(message.CommandArguments()); ok {
+			if err := h.referral.Record(ctx, referrerID, message.From.ID); err != nil && !errors.Is(err, service.ErrSelfReferral) {
+				log.Printf("ERROR: failed to record referral %d -> %d: %v", referrerID, message.From.ID, err)
+			}
+		}
+	}
+
+	msgText := "Привет, " + userInfo + ", рады видеть вас снова!"
+	if newUser {
+		msgText = "Привет, " + userInfo + "! Используй /help, чтобы узнать, что умеет бот."
+	}
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, msgText))
+}
+
+// handleReferralCommand показывает персональную реферальную ссылку и статистику приглашений.
+func (h *Handler) handleReferralCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.referral == nil || h.botUsername == "" {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Реферальная программа сейчас недоступна."))
+		return
+	}
+
+	settings, err := h.referral.Settings(ctx)
+	if err != nil {
+		log.Printf("ERROR: failed to get referral settings: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось получить данные. Попробуйте позже."))
+		return
+	}
+	if !settings.Enabled || (settings.RewardDays == 0 && settings.RewardTrafficGB == 0) {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Реферальная программа сейчас недоступна."))
+		return
+	}
+
+	stats, err := h.referral.Stats(ctx, message.From.ID)
+	if err != nil {
+		log.Printf("ERROR: failed to get referral stats for %d: %v", message.From.ID, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось получить данные. Попробуйте позже."))
+		return
+	}
+
+	link := fmt.Sprintf("https://t.me/%s?start=%s", h.botUsername, service.ReferralPayload(message.From.ID))
+	text := fmt.Sprintf(
+		"Приглашайте друзей и получайте бонус: %s за каждого, кто %s.\n\n"+
+			"Ваша ссылка:\n%s\n\n"+
+			"Приглашено: %d\nБонусов начислено: %d\nПолучено: %s",
+		formatPlanTerms(service.Plan{Days: settings.RewardDays, TrafficGB: settings.RewardTrafficGB}),
+		rewardCondition(settings.RewardOnTrial, settings.RewardOnPayment),
+		link,
+		stats.Invited, stats.Rewarded,
+		formatReferralBonus(stats),
+	)
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// rewardReferrer начисляет бонус пригласившему пользователя, если он есть.
+func (h *Handler) rewardReferrer(ctx context.Context, telegramID int64, event string) {
+	if h.referral == nil {
+		return
+	}
+	if err := h.referral.Reward(ctx, telegramID, event); err != nil {
+		log.Printf("ERROR: failed to reward referrer of %d for %s: %v", telegramID, event, err)
+	}
+}
+
+// rewardCondition описывает, за какое действие приглашенного начисляется бонус.
+func rewardCondition(onTrial, onPayment bool) string {
+	switch {
+	case onTrial && onPayment:
+		return "активирует пробный период или оплатит подписку"
+	case onTrial:
+		return "активирует пробный период"
+	default:
+		return "оплатит подписку"
+	}
+}
+
+// formatReferralBonus описывает суммарный полученный бонус.
+func formatReferralBonus(stats *service.ReferralStats) string {
+	if stats.BonusDays == 0 && stats.BonusTrafficGB == 0 {
+		return "пока ничего"
+	}
+	return formatPlanTerms(service.Plan{Days: int(stats.BonusDays), TrafficGB: int(stats.BonusTrafficGB)})
+}
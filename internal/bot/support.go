@@ -0,0 +1,266 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxMessageLength и maxCaptionLength - ограничения Telegram на длину текста и подписи.
+	maxMessageLength = 4096
+	maxCaptionLength = 1024
+)
+
+// handleSupportRequest передает сообщение пользователя операторам в чат поддержки.
+// Сообщение привязывается к открытому обращению пользователя или открывает новое.
+func (h *Handler) handleSupportRequest(ctx context.Context, message *tgbotapi.Message) {
+	// Обращения принимаются только в личном чате, сообщения в группах не пересылаются.
+	if !message.Chat.IsPrivate() {
+		return
+	}
+
+	if h.support == nil || h.supportChatID == 0 {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Используй /help, чтобы узнать доступные команды."))
+		return
+	}
+
+	ticket, opened, err := h.support.OpenTicket(ctx, message.From.ID)
+	if err != nil {
+		log.Printf("ERROR: failed to open ticket for %d: %v", message.From.ID, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось передать сообщение в поддержку. Попробуйте позже."))
+		return
+	}
+
+	header := h.ticketHeader(ctx, ticket, message.From)
+	supportMessageID, err := h.relayToSupport(message, header)
+	if err != nil {
+		log.Printf("ERROR: failed to relay message of %d to support chat: %v", message.From.ID, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось передать сообщение в поддержку. Попробуйте позже."))
+		return
+	}
+
+	if err := h.support.AddMessage(ctx, &database.TicketMessage{
+		TicketID:         ticket.ID,
+		Direction:        database.TicketMessageIncoming,
+		UserMessageID:    message.MessageID,
+		SupportMessageID: supportMessageID,
+		ContentType:      messageContentType(message),
+		Text:             messageText(message),
+	}); err != nil {
+		log.Printf("ERROR: failed to save message of ticket #%d: %v", ticket.ID, err)
+	}
+
+	if opened {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("Обращение #%d создано, сообщение передано в поддержку. Ответ придет в этот чат.", ticket.ID)))
+	}
+}
+
+// relayToSupport отправляет сообщение пользователя в чат поддержки с заголовком обращения
+// и возвращает ID сообщения, на которое операторы будут отвечать.
+// Текст и подпись к медиа объединяются с заголовком в одно сообщение, если укладываются
+// в ограничения Telegram; иначе заголовок отправляется отдельно, а копия - ответом на него.
+func (h *Handler) relayToSupport(message *tgbotapi.Message, header string) (int, error) {
+	if message.Text != "" {
+		text := header + "\n\n" + html.EscapeString(message.Text)
+		if utf8.RuneCountInString(text) <= maxMessageLength {
+			msg := tgbotapi.NewMessage(h.supportChatID, text)
+			msg.ParseMode = tgbotapi.ModeHTML
+			sent, err := h.bot.Send(msg)
+			return sent.MessageID, err
+		}
+	}
+
+	copyMsg := tgbotapi.NewCopyMessage(h.supportChatID, message.Chat.ID, message.MessageID)
+	if supportsCaption(message) {
+		caption := header
+		if message.Caption != "" {
+			caption += "\n\n" + html.EscapeString(message.Caption)
+		}
+		if utf8.RuneCountInString(caption) <= maxCaptionLength {
+			copyMsg.Caption = caption
+			copyMsg.ParseMode = tgbotapi.ModeHTML
+			sent, err := h.bot.Send(copyMsg)
+			return sent.MessageID, err
+		}
+	}
+
+	headerMsg := tgbotapi.NewMessage(h.supportChatID, header)
+	headerMsg.ParseMode = tgbotapi.ModeHTML
+	sentHeader, err := h.bot.Send(headerMsg)
+	if err != nil {
+		return 0, err
+	}
+	copyMsg.ReplyToMessageID = sentHeader.MessageID
+	sent, err := h.bot.Send(copyMsg)
+	return sent.MessageID, err
+}
+
+// handleSupportChatMessage обрабатывает сообщения в чате поддержки: ответ (reply) оператора
+// на сообщение обращения пересылается пользователю, /close в ответ на него закрывает обращение.
+func (h *Handler) handleSupportChatMessage(ctx context.Context, message *tgbotapi.Message) {
+	if message.ReplyToMessage == nil || h.support == nil {
+		if message.IsCommand() && message.Command() == "close" {
+			h.replyInSupportChat(message, "Чтобы закрыть обращение, ответьте командой /close на его сообщение.")
+		}
+		return
+	}
+
+	ticket, err := h.support.FindBySupportMessage(ctx, message.ReplyToMessage.MessageID)
+	if err != nil {
+		if !errors.Is(err, service.ErrTicketNotFound) {
+			log.Printf("ERROR: failed to find ticket for support message %d: %v", message.ReplyToMessage.MessageID, err)
+		}
+		// Обычная переписка операторов между собой, а не ответ на обращение.
+		return
+	}
+
+	if message.IsCommand() {
+		if message.Command() == "close" {
+			h.closeTicket(ctx, ticket, message)
+		}
+		return
+	}
+
+	if ticket.Status == database.TicketStatusClosed {
+		h.replyInSupportChat(message, fmt.Sprintf("Обращение #%d закрыто, ответ не отправлен.", ticket.ID))
+		return
+	}
+
+	sent, err := h.bot.Send(tgbotapi.NewCopyMessage(ticket.TelegramID, message.Chat.ID, message.MessageID))
+	if err != nil {
+		log.Printf("ERROR: failed to deliver reply to ticket #%d: %v", ticket.ID, err)
+		h.replyInSupportChat(message, fmt.Sprintf("Не удалось доставить ответ по обращению #%d: %v", ticket.ID, err))
+		return
+	}
+
+	if err := h.support.AddMessage(ctx, &database.TicketMessage{
+		TicketID:         ticket.ID,
+		Direction:        database.TicketMessageOutgoing,
+		UserMessageID:    sent.MessageID,
+		SupportMessageID: message.MessageID,
+		OperatorID:       message.From.ID,
+		ContentType:      messageContentType(message),
+		Text:             messageText(message),
+	}); err != nil {
+		log.Printf("ERROR: failed to save reply to ticket #%d: %v", ticket.ID, err)
+	}
+
+	operatorID := message.From.ID
+	if err := h.messages.SaveOutgoingTo(ctx, ticket.TelegramID, database.Message{
+		ChatID:            ticket.TelegramID,
+		TelegramMessageID: sent.MessageID,
+		MessageText:       messageText(message),
+		MessageType:       messageContentType(message),
+		OperatorID:        &operatorID,
+	}); err != nil {
+		log.Printf("ERROR: failed to save reply to ticket #%d in message history: %v", ticket.ID, err)
+	}
+}
+
+// closeTicket закрывает обращение по команде оператора и уведомляет пользователя.
+func (h *Handler) closeTicket(ctx context.Context, ticket *database.Ticket, message *tgbotapi.Message) {
+	if ticket.Status == database.TicketStatusClosed {
+		h.replyInSupportChat(message, fmt.Sprintf("Обращение #%d уже закрыто.", ticket.ID))
+		return
+	}
+
+	if _, err := h.support.Close(ctx, ticket.ID); err != nil {
+		log.Printf("ERROR: failed to close ticket #%d: %v", ticket.ID, err)
+		h.replyInSupportChat(message, fmt.Sprintf("Не удалось закрыть обращение #%d.", ticket.ID))
+		return
+	}
+
+	h.bot.Send(tgbotapi.NewMessage(ticket.TelegramID,
+		fmt.Sprintf("Обращение #%d закрыто. Если вопрос остался, просто напишите сюда - мы откроем новое.", ticket.ID)))
+	h.replyInSupportChat(message, fmt.Sprintf("Обращение #%d закрыто.", ticket.ID))
+}
+
+// replyInSupportChat отвечает оператору в чате поддержки.
+func (h *Handler) replyInSupportChat(message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	h.bot.Send(msg)
+}
+
+// ticketHeader формирует заголовок сообщения в чате поддержки: номер обращения,
+// пользователь и привязанные к нему клиенты 3x-ui.
+func (h *Handler) ticketHeader(ctx context.Context, ticket *database.Ticket, user *tgbotapi.User) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>Обращение #%d</b>\n", ticket.ID))
+	sb.WriteString("От: " + html.EscapeString(formatUserInfo(user)))
+	if user.UserName != "" {
+		sb.WriteString(" @" + html.EscapeString(user.UserName))
+	}
+	sb.WriteString(fmt.Sprintf(" (id <code>%d</code>)\n", user.ID))
+
+	links, err := h.links.ForUser(ctx, user.ID)
+	switch {
+	case err != nil:
+		log.Printf("ERROR: failed to get client links of %d: %v", user.ID, err)
+		sb.WriteString("Подписка: не удалось получить")
+	case len(links) == 0:
+		sb.WriteString("Подписка: нет")
+	default:
+		emails := make([]string, 0, len(links))
+		for _, link := range links {
+			emails = append(emails, "<code>"+html.EscapeString(link.Email)+"</code>")
+		}
+		sb.WriteString("Подписка: " + strings.Join(emails, ", "))
+	}
+	return sb.String()
+}
+
+// supportsCaption сообщает, можно ли заменить подпись при копировании сообщения.
+func supportsCaption(message *tgbotapi.Message) bool {
+	return message.Photo != nil || message.Document != nil || message.Video != nil ||
+		message.Audio != nil || message.Animation != nil || message.Voice != nil
+}
+
+// messageContentType возвращает тип содержимого сообщения для истории обращения.
+func messageContentType(message *tgbotapi.Message) string {
+	switch {
+	case message.Text != "":
+		return "text"
+	case message.Photo != nil:
+		return "photo"
+	case message.Document != nil:
+		return "document"
+	case message.Video != nil:
+		return "video"
+	case message.Audio != nil:
+		return "audio"
+	case message.Voice != nil:
+		return "voice"
+	case message.Animation != nil:
+		return "animation"
+	case message.Sticker != nil:
+		return "sticker"
+	case message.VideoNote != nil:
+		return "video_note"
+	case message.Location != nil:
+		return "location"
+	case message.Contact != nil:
+		return "contact"
+	default:
+		return "other"
+	}
+}
+
+// messageText возвращает текст или подпись сообщения.
+func messageText(message *tgbotapi.Message) string {
+	if message.Text != "" {
+		return message.Text
+	}
+	return message.Caption
+}
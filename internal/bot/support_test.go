@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayToSupport(t *testing.T) {
+	const supportChatID = -1001234567890
+	header := "<b>Обращение #7</b>"
+
+	t.Run("Text Is Merged With Header", func(t *testing.T) {
+		mockBot := &MockBotSender{}
+		h := &Handler{bot: mockBot, supportChatID: supportChatID}
+
+		_, err := h.relayToSupport(&tgbotapi.Message{MessageID: 10, Chat: &tgbotapi.Chat{ID: 42}, Text: "<не работает>"}, header)
+		require.NoError(t, err)
+
+		require.Len(t, mockBot.SentMessages, 1)
+		msg, ok := mockBot.SentMessages[0].(tgbotapi.MessageConfig)
+		require.True(t, ok, "Sent chattable should be of type MessageConfig")
+		assert.Equal(t, int64(supportChatID), msg.ChatID)
+		assert.Equal(t, header+"\n\n&lt;не работает&gt;", msg.Text, "User text must be escaped")
+	})
+
+	t.Run("Photo Is Copied With Header Caption", func(t *testing.T) {
+		mockBot := &MockBotSender{}
+		h := &Handler{bot: mockBot, supportChatID: supportChatID}
+
+		message := &tgbotapi.Message{MessageID: 11, Chat: &tgbotapi.Chat{ID: 42}, Photo: []tgbotapi.PhotoSize{{FileID: "photo"}}, Caption: "скриншот"}
+		_, err := h.relayToSupport(message, header)
+		require.NoError(t, err)
+
+		require.Len(t, mockBot.SentMessages, 1)
+		copyMsg, ok := mockBot.SentMessages[0].(tgbotapi.CopyMessageConfig)
+		require.True(t, ok, "Sent chattable should be of type CopyMessageConfig")
+		assert.Equal(t, int64(42), copyMsg.FromChatID)
+		assert.Equal(t, 11, copyMsg.MessageID)
+		assert.Equal(t, header+"\n\nскриншот", copyMsg.Caption)
+	})
+
+	t.Run("Long Text Is Sent After Separate Header", func(t *testing.T) {
+		mockBot := &MockBotSender{}
+		h := &Handler{bot: mockBot, supportChatID: supportChatID}
+
+		message := &tgbotapi.Message{MessageID: 12, Chat: &tgbotapi.Chat{ID: 42}, Text: strings.Repeat("я", maxMessageLength)}
+		_, err := h.relayToSupport(message, header)
+		require.NoError(t, err)
+
+		require.Len(t, mockBot.SentMessages, 2)
+		headerMsg, ok := mockBot.SentMessages[0].(tgbotapi.MessageConfig)
+		require.True(t, ok, "Header should be sent as MessageConfig")
+		assert.Equal(t, header, headerMsg.Text)
+		_, ok = mockBot.SentMessages[1].(tgbotapi.CopyMessageConfig)
+		assert.True(t, ok, "Message should be copied after the header")
+	})
+}
@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// throttledCommands - команды, обращающиеся к 3x-ui. Их частота ограничивается
+// для каждого пользователя, чтобы один пользователь не нагружал панель.
+var throttledCommands = map[string]bool{
+	"getclient": true,
+	"trial":     true,
+}
+
+// rateLimiter ограничивает число событий от пользователя скользящим окном:
+// разрешено не больше limit событий за любой промежуток длиной window.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	hits      map[int64][]time.Time
+	lastSweep time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[int64][]time.Time),
+	}
+}
+
+// allow учитывает событие пользователя и сообщает, разрешено ли оно.
+// Если нет - возвращает, через сколько будет разрешено следующее.
+func (l *rateLimiter) allow(userID int64, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.window {
+		l.sweep(now)
+	}
+
+	hits := l.recent(l.hits[userID], now)
+	if len(hits) >= l.limit {
+		l.hits[userID] = hits
+		return false, hits[0].Add(l.window).Sub(now)
+	}
+	l.hits[userID] = append(hits, now)
+	return true, 0
+}
+
+// recent отбрасывает события, вышедшие за окно.
+func (l *rateLimiter) recent(hits []time.Time, now time.Time) []time.Time {
+	start := now.Add(-l.window)
+	i := 0
+	for i < len(hits) && !hits[i].After(start) {
+		i++
+	}
+	return hits[i:]
+}
+
+// sweep удаляет пользователей без событий в окне, чтобы карта не росла бесконечно.
+func (l *rateLimiter) sweep(now time.Time) {
+	for userID, hits := range l.hits {
+		if len(l.recent(hits, now)) == 0 {
+			delete(l.hits, userID)
+		}
+	}
+	l.lastSweep = now
+}
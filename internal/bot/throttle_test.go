@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-bot/internal/xui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_SlidingWindow(t *testing.T) {
+	limiter := newRateLimiter(2, time.Minute)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	allowed, _ := limiter.allow(1, start)
+	assert.True(t, allowed)
+	allowed, _ = limiter.allow(1, start.Add(30*time.Second))
+	assert.True(t, allowed)
+
+	allowed, retryAfter := limiter.allow(1, start.Add(40*time.Second))
+	assert.False(t, allowed, "Third hit within the window must be rejected")
+	assert.Equal(t, 20*time.Second, retryAfter)
+
+	allowed, _ = limiter.allow(2, start.Add(40*time.Second))
+	assert.True(t, allowed, "Limits are per user")
+
+	// Первое событие вышло из окна, второе еще в нем.
+	allowed, _ = limiter.allow(1, start.Add(61*time.Second))
+	assert.True(t, allowed)
+	allowed, _ = limiter.allow(1, start.Add(62*time.Second))
+	assert.False(t, allowed)
+}
+
+func TestRateLimiter_SweepsIdleUsers(t *testing.T) {
+	limiter := newRateLimiter(1, time.Minute)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter.allow(1, start)
+	limiter.allow(2, start.Add(2*time.Minute))
+
+	assert.NotContains(t, limiter.hits, int64(1))
+	assert.Contains(t, limiter.hits, int64(2))
+}
+
+func TestAllowCommand(t *testing.T) {
+	// --- Arrange ---
+	calls := 0
+	mockXUIService := &MockXUIService{
+		GetClientTrafficsFunc: func(ctx context.Context, email string) ([]xui.ClientTraffic, error) {
+			calls++
+			return nil, nil
+		},
+	}
+	mockBot := &MockBotSender{}
+	handler := NewHandler(Deps{
+		Bot:               mockBot,
+		XUI:               mockXUIService,
+		AdminIDs:          []int64{42},
+		CommandRateLimit:  2,
+		CommandRateWindow: time.Minute,
+	})
+
+	// --- Act & Assert ---
+	getClient := commandUpdate(7, "/getclient test@example.com").Message
+	assert.True(t, handler.allowCommand(getClient))
+	assert.True(t, handler.allowCommand(getClient))
+	assert.False(t, handler.allowCommand(getClient), "Third 3x-ui command within the window must be throttled")
+	require.Len(t, mockBot.SentMessages, 1)
+	assert.Contains(t, mockBot.SentMessages[0].(tgbotapi.MessageConfig).Text, "Слишком много запросов")
+
+	help := commandUpdate(7, "/help").Message
+	assert.True(t, handler.allowCommand(help), "Commands without 3x-ui calls are not throttled")
+
+	for i := 0; i < 3; i++ {
+		handler.ProcessUpdate(context.Background(), commandUpdate(42, "/getclient test@example.com"))
+	}
+	assert.Equal(t, 3, calls, "Admins are not throttled")
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gorm.io/gorm"
+)
+
+// handleTimezoneCommand показывает или меняет часовой пояс, в котором бот выводит даты:
+// /timezone Europe/Moscow.
+func (h *Handler) handleTimezoneCommand(ctx context.Context, message *tgbotapi.Message) {
+	name := strings.TrimSpace(message.CommandArguments())
+	if name == "" {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(
+			"Текущий часовой пояс: %s\nЧтобы изменить, укажите его после команды. Пример: /timezone Europe/Moscow",
+			h.userLocation(ctx, message.From.ID))))
+		return
+	}
+
+	loc, err := parseTimezone(name)
+	if err != nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+			"Неизвестный часовой пояс. Укажите его в формате IANA, например Europe/Moscow или Asia/Almaty."))
+		return
+	}
+
+	if h.db == nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось сохранить часовой пояс. Попробуйте позже."))
+		return
+	}
+	if err := h.db.WithContext(ctx).Model(&database.User{}).
+		Where("telegram_id = ?", message.From.ID).
+		Update("time_zone", loc.String()).Error; err != nil {
+		log.Printf("ERROR: failed to save time zone of %d: %v", message.From.ID, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось сохранить часовой пояс. Попробуйте позже."))
+		return
+	}
+
+	h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Часовой пояс изменен: %s (сейчас %s).",
+		loc, time.Now().In(loc).Format("15:04"))))
+}
+
+// userLocation возвращает часовой пояс пользователя, по умолчанию - UTC. Пользователя может не быть
+// в базе, например, если он пишет только inline-запросы: для него тоже используется UTC.
+func (h *Handler) userLocation(ctx context.Context, telegramID int64) *time.Location {
+	if h.db == nil {
+		return time.UTC
+	}
+
+	var user database.User
+	if err := h.db.WithContext(ctx).Select("time_zone").Where("telegram_id = ?", telegramID).Take(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.UTC
+		}
+		log.Printf("ERROR: failed to get time zone of %d: %v", telegramID, err)
+		return time.UTC
+	}
+	if user.TimeZone == "" {
+		return time.UTC
+	}
+
+	loc, err := parseTimezone(user.TimeZone)
+	if err != nil {
+		log.Printf("ERROR: invalid time zone [%s] of %d: %v", user.TimeZone, telegramID, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// parseTimezone загружает часовой пояс IANA. "Local" не принимается: это пояс сервера, а не пользователя.
+func parseTimezone(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "local") {
+		return nil, fmt.Errorf("unknown time zone %s", name)
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+
+	"go-bot/internal/database"
+	"go-bot/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleTrialCommand выдает пробную подписку и отправляет ссылку для подключения.
+func (h *Handler) handleTrialCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.trials == nil || !h.trials.Enabled() {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Пробный период сейчас недоступен."))
+		return
+	}
+
+	// Ссылка на подписку - личные данные, поэтому выдаем ее только в личном чате.
+	if !message.Chat.IsPrivate() {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Пробный период можно получить только в личных сообщениях с ботом."))
+		return
+	}
+	if message.From.IsBot {
+		return
+	}
+
+	result, err := h.trials.Start(ctx, message.From.ID)
+	if err != nil {
+		var text string
+		switch {
+		case errors.Is(err, service.ErrTrialUsed):
+			text = "Вы уже использовали пробный период. Оформить подписку можно через /buy."
+		case errors.Is(err, service.ErrAlreadySubscribed):
+			text = "У вас уже есть подписка, пробный период не нужен. Продлить ее можно через /buy."
+		case errors.Is(err, service.ErrTrialNotEligible):
+			text = "К сожалению, пробный период недоступен для вашего аккаунта. Оформить подписку можно через /buy."
+		case errors.Is(err, service.ErrTrialDisabled):
+			text = "Пробный период сейчас недоступен."
+		default:
+			log.Printf("ERROR: failed to start trial for %d: %v", message.From.ID, err)
+			text = "Не удалось выдать пробный период. Попробуйте позже."
+		}
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+		return
+	}
+
+	h.rewardReferrer(ctx, message.From.ID, database.ReferralEventTrial)
+
+	traffic := "без ограничения трафика"
+	if result.TrafficGB > 0 {
+		traffic = fmt.Sprintf("%d GB трафика", result.TrafficGB)
+	}
+
+	text := fmt.Sprintf(
+		"Пробный период активирован до %s, %s.\n\nСсылка для подключения (добавьте ее как подписку в приложении):\n<code>%s</code>",
+		result.ExpiresAt.Format("02.01.2006 15:04"), traffic, html.EscapeString(result.SubscriptionURL),
+	)
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	h.bot.Send(msg)
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/chart"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// usagePeriods - допустимые периоды /usage в днях.
+var usagePeriods = map[int]bool{7: true, 30: true}
+
+const defaultUsagePeriod = 7
+
+// handleUsageCommand отправляет график суточного трафика клиента: /usage [7d|30d] [email].
+// Без email берется последний привязанный к пользователю клиент; чужих клиентов
+// могут смотреть только администраторы.
+func (h *Handler) handleUsageCommand(ctx context.Context, message *tgbotapi.Message) {
+	if h.history == nil {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "История трафика не ведется."))
+		return
+	}
+
+	days, email, ok := parseUsageArgs(message.CommandArguments())
+	if !ok {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Использование: /usage [7d|30d] [email]. Пример: /usage 30d"))
+		return
+	}
+
+	if email == "" {
+		link, err := h.links.Latest(ctx, message.From.ID)
+		if err != nil {
+			if h.isAdmin(message.From.ID) {
+				h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Укажите email клиента. Пример: /usage 7d user@example.com"))
+				return
+			}
+			h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "К вашему аккаунту не привязана подписка. Используйте /trial или /buy."))
+			return
+		}
+		email = link.Email
+	} else {
+		allowed, err := h.canViewClient(ctx, message.From.ID, email)
+		if err != nil {
+			log.Printf("ERROR: failed to check access of %d to client [%s]: %v", message.From.ID, email, err)
+			h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении данных. Пожалуйста, попробуйте позже."))
+			return
+		}
+		if !allowed {
+			h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Клиент с email %s не найден.", email)))
+			return
+		}
+	}
+
+	now := time.Now()
+	rows, err := h.history.Daily(ctx, email, now.AddDate(0, 0, -(days-1)), now)
+	if err != nil {
+		log.Printf("ERROR: failed to get traffic history of client [%s]: %v", email, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении данных. Пожалуйста, попробуйте позже."))
+		return
+	}
+
+	usage := make([]chart.DailyUsage, 0, len(rows))
+	var up, down int64
+	for _, row := range rows {
+		usage = append(usage, chart.DailyUsage{Day: row.Day, Up: row.Up, Down: row.Down})
+		up += row.Up
+		down += row.Down
+	}
+	if up == 0 && down == 0 {
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("За последние %d дн. трафика у клиента %s не было.", days, email)))
+		return
+	}
+
+	png, err := chart.RenderUsage(fmt.Sprintf("%s - last %d days (UTC)", email, days), usage)
+	if err != nil {
+		log.Printf("ERROR: failed to render usage chart of client [%s]: %v", email, err)
+		h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Не удалось построить график. Пожалуйста, попробуйте позже."))
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FileBytes{Name: "usage.png", Bytes: png})
+	photo.Caption = formatUsageCaption(email, days, up, down)
+	if _, err := h.bot.Send(photo); err != nil {
+		log.Printf("ERROR: failed to send usage chart of client [%s]: %v", email, err)
+	}
+}
+
+// parseUsageArgs разбирает аргументы /usage: период вида 7d или 30d и email в любом порядке.
+func parseUsageArgs(args string) (days int, email string, ok bool) {
+	days = defaultUsagePeriod
+	periodSet := false
+	for _, arg := range strings.Fields(args) {
+		if n, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(arg), "d")); err == nil {
+			if periodSet || !usagePeriods[n] {
+				return 0, "", false
+			}
+			days, periodSet = n, true
+			continue
+		}
+		if email != "" {
+			return 0, "", false
+		}
+		email = arg
+	}
+	return days, email, true
+}
+
+// formatUsageCaption формирует подпись к графику с итогами за период.
+func formatUsageCaption(email string, days int, up, down int64) string {
+	const gb = 1024 * 1024 * 1024
+	return fmt.Sprintf("Трафик %s за %d дн. (UTC)\n↓ загрузка: %.2f GB\n↑ отдача: %.2f GB\nВсего: %.2f GB",
+		email, days, float64(down)/gb, float64(up)/gb, float64(up+down)/gb)
+}
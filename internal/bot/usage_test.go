@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUsageArgs(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          string
+		expectedDays  int
+		expectedEmail string
+		expectedOK    bool
+	}{
+		{name: "Default Period", args: "", expectedDays: 7, expectedOK: true},
+		{name: "Period", args: "30d", expectedDays: 30, expectedOK: true},
+		{name: "Period And Email", args: "30d user@example.com", expectedDays: 30, expectedEmail: "user@example.com", expectedOK: true},
+		{name: "Email First", args: " user@example.com  7D ", expectedDays: 7, expectedEmail: "user@example.com", expectedOK: true},
+		{name: "Unsupported Period", args: "14d", expectedOK: false},
+		{name: "Two Periods", args: "7d 30d", expectedOK: false},
+		{name: "Two Emails", args: "a@example.com b@example.com", expectedOK: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			days, email, ok := parseUsageArgs(tc.args)
+			assert.Equal(t, tc.expectedOK, ok)
+			if tc.expectedOK {
+				assert.Equal(t, tc.expectedDays, days)
+				assert.Equal(t, tc.expectedEmail, email)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Package chart рисует графики для отправки в Telegram в виде PNG.
+//
+// Используется только стандартная библиотека и растровый шрифт basicfont,
+// поэтому подписи на графике - только ASCII.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// DailyUsage - трафик клиента за сутки в байтах.
+type DailyUsage struct {
+	Day  time.Time
+	Up   int64
+	Down int64
+}
+
+const (
+	width  = 800
+	height = 420
+
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 50
+	marginBottom = 50
+
+	gridLines = 5
+)
+
+var (
+	colorBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	colorAxis       = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	colorGrid       = color.RGBA{R: 0xe3, G: 0xe3, B: 0xe3, A: 0xff}
+	colorText       = color.RGBA{R: 0x22, G: 0x22, B: 0x22, A: 0xff}
+	colorDownload   = color.RGBA{R: 0x3b, G: 0x82, B: 0xf6, A: 0xff}
+	colorUpload     = color.RGBA{R: 0xf5, G: 0x9e, B: 0x0b, A: 0xff}
+)
+
+// RenderUsage рисует столбчатую диаграмму суточного трафика: download и upload
+// одного дня складываются в один столбец. Возвращает PNG.
+func RenderUsage(title string, days []DailyUsage) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: colorBackground}, image.Point{}, draw.Src)
+
+	plot := image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+
+	var maxTotal int64
+	for _, day := range days {
+		maxTotal = max(maxTotal, day.Up+day.Down)
+	}
+	unit, unitName := pickUnit(maxTotal)
+	scaleMax := niceCeil(float64(maxTotal) / unit)
+
+	// Сетка и подписи оси Y.
+	for i := 0; i <= gridLines; i++ {
+		y := plot.Max.Y - plot.Dy()*i/gridLines
+		fillRect(img, image.Rect(plot.Min.X, y, plot.Max.X, y+1), colorGrid)
+		label := formatTick(scaleMax * float64(i) / gridLines)
+		drawText(img, plot.Min.X-8-textWidth(label), y+4, label, colorText)
+	}
+	drawText(img, 8, marginTop-12, unitName, colorText)
+
+	// Столбцы.
+	if len(days) > 0 {
+		slot := float64(plot.Dx()) / float64(len(days))
+		barWidth := max(int(slot*0.7), 1)
+		labelEvery := max(len(days)/10, 1)
+
+		for i, day := range days {
+			x0 := plot.Min.X + int(slot*float64(i)+(slot-float64(barWidth))/2)
+			downHeight := barHeight(day.Down, unit, scaleMax, plot.Dy())
+			upHeight := barHeight(day.Up+day.Down, unit, scaleMax, plot.Dy()) - downHeight
+
+			fillRect(img, image.Rect(x0, plot.Max.Y-downHeight, x0+barWidth, plot.Max.Y), colorDownload)
+			fillRect(img, image.Rect(x0, plot.Max.Y-downHeight-upHeight, x0+barWidth, plot.Max.Y-downHeight), colorUpload)
+
+			if i%labelEvery == 0 || i == len(days)-1 {
+				label := day.Day.Format("02.01")
+				center := x0 + barWidth/2
+				drawText(img, center-textWidth(label)/2, plot.Max.Y+18, label, colorText)
+			}
+		}
+	}
+
+	// Оси.
+	fillRect(img, image.Rect(plot.Min.X, plot.Min.Y, plot.Min.X+1, plot.Max.Y+1), colorAxis)
+	fillRect(img, image.Rect(plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y+1), colorAxis)
+
+	// Заголовок и легенда.
+	drawText(img, marginLeft, 24, title, colorText)
+	legendX := width - marginRight - 190
+	fillRect(img, image.Rect(legendX, 14, legendX+12, 26), colorDownload)
+	drawText(img, legendX+18, 24, "Download", colorText)
+	fillRect(img, image.Rect(legendX+100, 14, legendX+112, 26), colorUpload)
+	drawText(img, legendX+118, 24, "Upload", colorText)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pickUnit выбирает единицу измерения оси Y по максимальному значению.
+func pickUnit(maxBytes int64) (float64, string) {
+	switch {
+	case maxBytes >= 1<<30:
+		return 1 << 30, "GB"
+	case maxBytes >= 1<<20:
+		return 1 << 20, "MB"
+	default:
+		return 1 << 10, "KB"
+	}
+}
+
+// niceCeil округляет максимум шкалы вверх до "круглого" числа: 1, 2, 2.5 или 5, умноженных на степень 10.
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := 1.0
+	for magnitude*10 <= v {
+		magnitude *= 10
+	}
+	for magnitude > v {
+		magnitude /= 10
+	}
+	for _, step := range []float64{1, 2, 2.5, 5, 10} {
+		if step*magnitude >= v {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// formatTick форматирует подпись деления оси Y без лишних нулей.
+func formatTick(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+// barHeight переводит значение в высоту столбца в пикселях.
+func barHeight(value int64, unit, scaleMax float64, plotHeight int) int {
+	return int(float64(value) / unit / scaleMax * float64(plotHeight))
+}
+
+func fillRect(img draw.Image, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+func drawText(img draw.Image, x, y int, text string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+func textWidth(text string) int {
+	return font.MeasureString(basicfont.Face7x13, text).Ceil()
+}
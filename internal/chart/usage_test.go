@@ -0,0 +1,75 @@
+package chart
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderUsage(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var days []DailyUsage
+	for i := 0; i < 30; i++ {
+		days = append(days, DailyUsage{
+			Day:  start.AddDate(0, 0, i),
+			Up:   int64(i) * 10 * 1024 * 1024,
+			Down: int64(i) * 100 * 1024 * 1024,
+		})
+	}
+	// Самый большой день ровно 10 GB - столбец занимает всю высоту шкалы.
+	days[len(days)-1] = DailyUsage{Day: start.AddDate(0, 0, 29), Up: 1 << 30, Down: 9 << 30}
+
+	data, err := RenderUsage("test@example.com - last 30 days (UTC)", days)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, width, img.Bounds().Dx())
+	assert.Equal(t, height, img.Bounds().Dy())
+
+	// Низ последнего столбца - download, верх - upload.
+	x := width - marginRight - (width-marginLeft-marginRight)/len(days)/2
+	assertColor(t, colorDownload, img.At(x, height-marginBottom-2))
+	assertColor(t, colorUpload, img.At(x, marginTop+20))
+}
+
+func assertColor(t *testing.T, expected, actual color.Color) {
+	t.Helper()
+	er, eg, eb, _ := expected.RGBA()
+	ar, ag, ab, _ := actual.RGBA()
+	assert.Equal(t, [3]uint32{er, eg, eb}, [3]uint32{ar, ag, ab})
+}
+
+func TestRenderUsage_Empty(t *testing.T) {
+	data, err := RenderUsage("empty", nil)
+	require.NoError(t, err)
+
+	_, err = png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+}
+
+func TestNiceCeil(t *testing.T) {
+	testCases := []struct {
+		value    float64
+		expected float64
+	}{
+		{0, 1},
+		{0.3, 0.5},
+		{1, 1},
+		{1.2, 2},
+		{2.2, 2.5},
+		{3, 5},
+		{7, 10},
+		{42, 50},
+		{180, 200},
+	}
+
+	for _, tc := range testCases {
+		assert.InDelta(t, tc.expected, niceCeil(tc.value), 1e-9, "value %v", tc.value)
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
@@ -11,13 +13,32 @@ import (
 	"github.com/spf13/viper"
 )
 
+const (
+	// UpdatesModeWebhook - Telegram присылает обновления на BASE_URL.
+	UpdatesModeWebhook = "webhook"
+	// UpdatesModePolling - бот сам забирает обновления через getUpdates.
+	UpdatesModePolling = "polling"
+)
+
 type Config struct {
 	Host     string `mapstructure:"HOST" validate:"required"`
 	Port     string `mapstructure:"PORT"     validate:"required"`
 	LogLevel string `mapstructure:"LOG_LEVEL" validate:"required"`
 
 	TelegramToken string `mapstructure:"TELEGRAM_TOKEN" validate:"required"`
-	BaseURL       string `mapstructure:"BASE_URL"        validate:"required,url"`
+	BaseURL       string `mapstructure:"BASE_URL"        validate:"required_if=UpdatesMode webhook,omitempty,url"`
+	// UpdatesMode выбирает способ получения обновлений: webhook (по умолчанию) или polling.
+	UpdatesMode        string `mapstructure:"UPDATES_MODE"         validate:"oneof=webhook polling"`
+	PollingTimeoutSecs int    `mapstructure:"POLLING_TIMEOUT_SECONDS" validate:"gte=0,lte=50"`
+	// WebhookSecret передается Telegram как secret_token и сверяется с заголовком
+	// X-Telegram-Bot-Api-Secret-Token. Если не задан, генерируется при запуске.
+	WebhookSecret string `mapstructure:"WEBHOOK_SECRET" validate:"omitempty,max=256,webhook_secret"`
+
+	// AdminTelegramIDs - Telegram ID администраторов бота через запятую. Администраторы
+	// могут смотреть любых клиентов, остальные пользователи - только привязанных к ним.
+	AdminTelegramIDs []int64 `mapstructure:"ADMIN_TELEGRAM_IDS" validate:"dive,gt=0"`
+	// SupportChatID - чат операторов, куда пересылаются сообщения пользователей. 0 отключает поддержку.
+	SupportChatID int64 `mapstructure:"SUPPORT_CHAT_ID"`
 
 	DBHost     string `mapstructure:"DB_HOST"     validate:"required"`
 	DBPort     string `mapstructure:"DB_PORT"     validate:"required"`
@@ -28,11 +49,58 @@ type Config struct {
 	RateLimitRequests      int    `mapstructure:"RATE_LIMIT_REQUESTS"       validate:"required,gte=0"`
 	RateLimitWindowMinutes int    `mapstructure:"RATE_LIMIT_WINDOW_MINUTES" validate:"required,gte=1"`
 	JWTSecretKey           string `mapstructure:"JWT_SECRET_KEY"       validate:"required,min=32"`
-	JWTExpiresIn           int    `mapstructure:"JWT_EXPIRES_IN_HOURS"   validate:"gte=1"`
+	// Access-токен администратора живет JWT_ACCESS_TTL_MINUTES, сессия - JWT_REFRESH_TTL_HOURS
+	// с последнего обновления refresh-токеном, но не дольше JWT_SESSION_MAX_AGE_HOURS со входа.
+	JWTAccessTTLMinutes   int `mapstructure:"JWT_ACCESS_TTL_MINUTES"   validate:"gte=1"`
+	JWTRefreshTTLHours    int `mapstructure:"JWT_REFRESH_TTL_HOURS"    validate:"gte=1"`
+	JWTSessionMaxAgeHours int `mapstructure:"JWT_SESSION_MAX_AGE_HOURS" validate:"gte=1"`
+
+	// Ограничение частоты команд бота, обращающихся к 3x-ui: не больше BOT_COMMAND_RATE_LIMIT
+	// команд за BOT_COMMAND_RATE_WINDOW_SECONDS на пользователя. 0 отключает ограничение.
+	BotCommandRateLimit      int `mapstructure:"BOT_COMMAND_RATE_LIMIT"          validate:"gte=0"`
+	BotCommandRateWindowSecs int `mapstructure:"BOT_COMMAND_RATE_WINDOW_SECONDS" validate:"gte=1"`
+	// BotInlineRateLimit - то же для inline-запросов, которые не нашлись в кэше, за то же окно.
+	// 0 отключает ограничение.
+	BotInlineRateLimit int `mapstructure:"BOT_INLINE_RATE_LIMIT" validate:"gte=0"`
+
+	// Очередь обновлений: количество воркеров, буфер на воркер и хранение в Postgres.
+	UpdateWorkers      int  `mapstructure:"UPDATE_WORKERS"       validate:"gte=1"`
+	UpdateQueueSize    int  `mapstructure:"UPDATE_QUEUE_SIZE"    validate:"gte=1"`
+	UpdateQueuePersist bool `mapstructure:"UPDATE_QUEUE_PERSIST"`
+	// ShutdownTimeoutSecs ограничивает время graceful shutdown, включая дообработку очереди.
+	ShutdownTimeoutSecs int `mapstructure:"SHUTDOWN_TIMEOUT_SECONDS" validate:"gte=1"`
+
+	// Тарифы Telegram Payments в формате "id:days:traffic_gb:price,...". Пусто - оплата отключена.
+	PaymentPlans         string `mapstructure:"PAYMENT_PLANS"`
+	PaymentCurrency      string `mapstructure:"PAYMENT_CURRENCY"       validate:"required,len=3"`
+	PaymentProviderToken string `mapstructure:"PAYMENT_PROVIDER_TOKEN" validate:"required_unless=PaymentCurrency XTR"`
+	// PaymentRetryMinutes - период повторного применения платежей, не примененных из-за
+	// временной ошибки. 0 отключает повтор, остается ручное применение через API.
+	PaymentRetryMinutes int `mapstructure:"PAYMENT_RETRY_INTERVAL_MINUTES" validate:"gte=0"`
+
+	// Пробный период через /trial. TRIAL_INBOUND_ID=0 отключает выдачу.
+	TrialInboundID int `mapstructure:"TRIAL_INBOUND_ID"  validate:"gte=0"`
+	TrialDays      int `mapstructure:"TRIAL_DAYS"        validate:"gte=1"`
+	TrialTrafficGB int `mapstructure:"TRIAL_TRAFFIC_GB"  validate:"gte=0"`
+	// TrialMaxTelegramID отсекает недавно созданные аккаунты: Telegram выдает ID по возрастанию.
+	// 0 отключает проверку.
+	TrialMaxTelegramID int64 `mapstructure:"TRIAL_MAX_TELEGRAM_ID" validate:"gte=0"`
+
+	// История трафика: период опроса 3x-ui (0 отключает запись) и сроки хранения
+	// снимков и почасовых данных (0 - бессрочно). Суточные данные хранятся всегда.
+	TrafficPollMinutes           int `mapstructure:"TRAFFIC_POLL_INTERVAL_MINUTES"   validate:"gte=0"`
+	TrafficSnapshotRetentionDays int `mapstructure:"TRAFFIC_SNAPSHOT_RETENTION_DAYS" validate:"gte=0"`
+	TrafficHourlyRetentionDays   int `mapstructure:"TRAFFIC_HOURLY_RETENTION_DAYS"   validate:"gte=0"`
+
+	// WebAppAuthMaxAgeHours - сколько часов действительна initData Telegram Mini App.
+	WebAppAuthMaxAgeHours int `mapstructure:"WEBAPP_AUTH_MAX_AGE_HOURS" validate:"gte=1"`
 
 	XUIURL      string `mapstructure:"XUI_URL"       validate:"required,url"`
 	XUIUsername string `mapstructure:"XUI_USERNAME"  validate:"required"`
 	XUIPassword string `mapstructure:"XUI_PASSWORD"  validate:"required"`
+	// XUISubURL - адрес сервиса подписок 3x-ui, к которому добавляется subId клиента,
+	// например https://example.com:2096/sub/. Нужен для выдачи ссылок пробным клиентам.
+	XUISubURL string `mapstructure:"XUI_SUB_URL" validate:"required_with=TrialInboundID,omitempty,url"`
 }
 
 var (
@@ -56,7 +124,12 @@ func bindEnvs() {
 	viper.BindEnv("PORT")
 	viper.BindEnv("LOG_LEVEL")
 	viper.BindEnv("TELEGRAM_TOKEN")
+	viper.BindEnv("ADMIN_TELEGRAM_IDS")
+	viper.BindEnv("SUPPORT_CHAT_ID")
 	viper.BindEnv("BASE_URL")
+	viper.BindEnv("UPDATES_MODE")
+	viper.BindEnv("POLLING_TIMEOUT_SECONDS")
+	viper.BindEnv("WEBHOOK_SECRET")
 	viper.BindEnv("DB_HOST")
 	viper.BindEnv("DB_PORT")
 	viper.BindEnv("DB_USER")
@@ -65,16 +138,58 @@ func bindEnvs() {
 	viper.BindEnv("RATE_LIMIT_REQUESTS")
 	viper.BindEnv("RATE_LIMIT_WINDOW_MINUTES")
 	viper.BindEnv("JWT_SECRET_KEY")
-	viper.BindEnv("JWT_EXPIRES_IN_HOURS")
+	viper.BindEnv("JWT_ACCESS_TTL_MINUTES")
+	viper.BindEnv("JWT_REFRESH_TTL_HOURS")
+	viper.BindEnv("JWT_SESSION_MAX_AGE_HOURS")
+	viper.BindEnv("BOT_COMMAND_RATE_LIMIT")
+	viper.BindEnv("BOT_COMMAND_RATE_WINDOW_SECONDS")
+	viper.BindEnv("BOT_INLINE_RATE_LIMIT")
+	viper.BindEnv("UPDATE_WORKERS")
+	viper.BindEnv("UPDATE_QUEUE_SIZE")
+	viper.BindEnv("UPDATE_QUEUE_PERSIST")
+	viper.BindEnv("SHUTDOWN_TIMEOUT_SECONDS")
+	viper.BindEnv("PAYMENT_PLANS")
+	viper.BindEnv("PAYMENT_CURRENCY")
+	viper.BindEnv("PAYMENT_PROVIDER_TOKEN")
+	viper.BindEnv("PAYMENT_RETRY_INTERVAL_MINUTES")
+	viper.BindEnv("TRIAL_INBOUND_ID")
+	viper.BindEnv("TRIAL_DAYS")
+	viper.BindEnv("TRIAL_TRAFFIC_GB")
+	viper.BindEnv("TRIAL_MAX_TELEGRAM_ID")
+	viper.BindEnv("TRAFFIC_POLL_INTERVAL_MINUTES")
+	viper.BindEnv("TRAFFIC_SNAPSHOT_RETENTION_DAYS")
+	viper.BindEnv("TRAFFIC_HOURLY_RETENTION_DAYS")
+	viper.BindEnv("WEBAPP_AUTH_MAX_AGE_HOURS")
 	viper.BindEnv("XUI_URL")
 	viper.BindEnv("XUI_USERNAME")
 	viper.BindEnv("XUI_PASSWORD")
+	viper.BindEnv("XUI_SUB_URL")
 	viper.BindEnv("XUI_SERVICE")
 }
 
 func Load() (*Config, error) {
 	viper.SetDefault("HOST", "0.0.0.0")
 	viper.SetDefault("PORT", "8080")
+	viper.SetDefault("UPDATES_MODE", UpdatesModeWebhook)
+	viper.SetDefault("POLLING_TIMEOUT_SECONDS", 30)
+	viper.SetDefault("UPDATE_WORKERS", 8)
+	viper.SetDefault("UPDATE_QUEUE_SIZE", 100)
+	viper.SetDefault("UPDATE_QUEUE_PERSIST", false)
+	viper.SetDefault("SHUTDOWN_TIMEOUT_SECONDS", 25)
+	viper.SetDefault("BOT_COMMAND_RATE_LIMIT", 5)
+	viper.SetDefault("BOT_COMMAND_RATE_WINDOW_SECONDS", 60)
+	viper.SetDefault("BOT_INLINE_RATE_LIMIT", 20)
+	viper.SetDefault("PAYMENT_CURRENCY", "XTR")
+	viper.SetDefault("PAYMENT_RETRY_INTERVAL_MINUTES", 5)
+	viper.SetDefault("TRIAL_DAYS", 3)
+	viper.SetDefault("TRIAL_TRAFFIC_GB", 5)
+	viper.SetDefault("TRAFFIC_POLL_INTERVAL_MINUTES", 5)
+	viper.SetDefault("TRAFFIC_SNAPSHOT_RETENTION_DAYS", 30)
+	viper.SetDefault("TRAFFIC_HOURLY_RETENTION_DAYS", 90)
+	viper.SetDefault("WEBAPP_AUTH_MAX_AGE_HOURS", 24)
+	viper.SetDefault("JWT_ACCESS_TTL_MINUTES", 15)
+	viper.SetDefault("JWT_REFRESH_TTL_HOURS", 720)
+	viper.SetDefault("JWT_SESSION_MAX_AGE_HOURS", 2160)
 
 	viper.SetConfigFile(".env")
 	viper.SetConfigType("env")
@@ -98,9 +213,42 @@ func Load() (*Config, error) {
 	}
 
 	validate := validator.New()
+	if err := validate.RegisterValidation("webhook_secret", validateWebhookSecret); err != nil {
+		return nil, fmt.Errorf("failed to register validation: %w", err)
+	}
 	if err := validate.Struct(&cfg); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
+	if cfg.WebhookSecret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		cfg.WebhookSecret = secret
+	}
+
 	return &cfg, nil
 }
+
+// validateWebhookSecret проверяет, что секрет состоит только из символов,
+// которые Telegram допускает в secret_token: A-Z, a-z, 0-9, _ и -.
+func validateWebhookSecret(fl validator.FieldLevel) bool {
+	for _, r := range fl.Field().String() {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// generateWebhookSecret создает случайный секрет для вебхука.
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
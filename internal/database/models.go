@@ -1,43 +1,383 @@
 package database
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
 // User represents a Telegram user
 type User struct {
-	ID         uint64 `gorm:"primaryKey"`
-	TelegramID int64  `gorm:"uniqueIndex:idx_users_telegram_id;not null"`
-	Username   string `gorm:"size:255"`
-	FirstName  string `gorm:"size:255"`
-	LastName   string `gorm:"size:255"`
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID         uint64     `gorm:"primaryKey" json:"id"`
+	TelegramID int64      `gorm:"uniqueIndex:idx_users_telegram_id;not null" json:"telegram_id"`
+	Username   string     `gorm:"size:255" json:"username"`
+	FirstName  string     `gorm:"size:255" json:"first_name"`
+	LastName   string     `gorm:"size:255" json:"last_name"`
+	TimeZone   string     `gorm:"size:64;not null;default:''" json:"time_zone"` // IANA, пустая строка - UTC
+	IsActive   bool       `gorm:"not null;default:true" json:"is_active"`       // false - пользователь заблокировал бота
+	BlockedAt  *time.Time `json:"blocked_at,omitempty"`                         // когда пользователь заблокировал бота
+	Notes      string     `gorm:"type:text;not null;default:''" json:"notes"`
+	Tags       StringList `gorm:"type:jsonb;not null;default:'[]'" json:"tags"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
-// Message represents a user message
+// StringList is a list of strings stored as a JSONB array
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for StringList: %T", value)
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// Message directions
+const (
+	MessageIncoming = "in"  // from the user to the bot
+	MessageOutgoing = "out" // from an operator to the user
+)
+
+// Message represents a message of the conversation with a user
 type Message struct {
+	ID                uint64    `gorm:"primaryKey" json:"id"`
+	UserID            uint64    `gorm:"index" json:"user_id"`
+	Direction         string    `gorm:"size:8;not null" json:"direction"`
+	ChatID            int64     `gorm:"not null" json:"chat_id"`
+	TelegramMessageID int       `gorm:"not null" json:"telegram_message_id"`
+	MessageText       string    `gorm:"type:text" json:"text"`
+	MessageType       string    `gorm:"size:50" json:"type"`
+	AdminID           *uint64   `json:"admin_id,omitempty"`    // admin who sent the message via the admin API
+	OperatorID        *int64    `json:"operator_id,omitempty"` // operator who replied from the support chat
+	CreatedAt         time.Time `json:"created_at"`
+	User              *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// ClientLink links a Telegram user to a client of the 3x-ui panel
+type ClientLink struct {
+	ID         uint64    `gorm:"primaryKey" json:"id"`
+	TelegramID int64     `gorm:"index;not null" json:"telegram_id"`
+	Email      string    `gorm:"size:255;uniqueIndex;not null" json:"email"`
+	InboundID  int       `gorm:"not null" json:"inbound_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Payment statuses
+const (
+	PaymentStatusPending = "pending"
+	PaymentStatusApplied = "applied"
+	PaymentStatusFailed  = "failed"
+)
+
+// Payment represents a Telegram payment for a subscription plan
+type Payment struct {
+	ID                      uint64     `gorm:"primaryKey" json:"id"`
+	TelegramID              int64      `gorm:"index;not null" json:"telegram_id"`
+	ClientEmail             string     `gorm:"size:255;not null" json:"client_email"`
+	PlanID                  string     `gorm:"size:64;not null" json:"plan_id"`
+	Currency                string     `gorm:"size:8;not null" json:"currency"`
+	Amount                  int        `gorm:"not null" json:"amount"`
+	Days                    int        `gorm:"not null" json:"days"`
+	TrafficGB               int        `gorm:"not null" json:"traffic_gb"`
+	InvoicePayload          string     `gorm:"size:128;not null" json:"invoice_payload"`
+	TelegramPaymentChargeID string     `gorm:"size:255;uniqueIndex;not null" json:"telegram_payment_charge_id"`
+	ProviderPaymentChargeID string     `gorm:"size:255" json:"provider_payment_charge_id,omitempty"`
+	Status                  string     `gorm:"size:16;not null" json:"status"`
+	Error                   string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	AppliedAt               *time.Time `json:"applied_at,omitempty"`
+}
+
+// Trial is a trial subscription issued to a Telegram user, at most one per user
+type Trial struct {
 	ID          uint64 `gorm:"primaryKey"`
-	UserID      uint64 `gorm:"index"`
-	MessageText string `gorm:"type:text"`
-	MessageType string `gorm:"size:50"`
+	TelegramID  int64  `gorm:"uniqueIndex;not null"`
+	ClientEmail string `gorm:"size:255;not null"`
+	InboundID   int    `gorm:"not null"`
 	CreatedAt   time.Time
 }
 
-// Admin represents an administrator with security features
+// Referral events that can reward the referrer
+const (
+	ReferralEventTrial   = "trial"
+	ReferralEventPayment = "payment"
+)
+
+// Referral records that a Telegram user was invited by another one
+type Referral struct {
+	ID              uint64 `gorm:"primaryKey"`
+	ReferrerID      int64  `gorm:"index;not null"`
+	ReferredID      int64  `gorm:"uniqueIndex;not null"`
+	CreatedAt       time.Time
+	RewardedAt      *time.Time
+	RewardEvent     string `gorm:"size:16"`
+	RewardDays      int    `gorm:"not null;default:0"`
+	RewardTrafficGB int    `gorm:"not null;default:0"`
+	RewardError     string `gorm:"type:text"`
+}
+
+// ReferralSettings configures referral rewards, the table always has a single row
+type ReferralSettings struct {
+	ID              int       `gorm:"primaryKey" json:"-"`
+	Enabled         bool      `gorm:"not null" json:"enabled"`
+	RewardDays      int       `gorm:"not null" json:"reward_days"`
+	RewardTrafficGB int       `gorm:"not null" json:"reward_traffic_gb"`
+	RewardOnTrial   bool      `gorm:"not null" json:"reward_on_trial"`
+	RewardOnPayment bool      `gorm:"not null" json:"reward_on_payment"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for ReferralSettings
+func (ReferralSettings) TableName() string {
+	return "referral_settings"
+}
+
+// Ticket statuses
+const (
+	TicketStatusOpen   = "open"
+	TicketStatusClosed = "closed"
+)
+
+// Ticket message directions
+const (
+	TicketMessageIncoming = "in"  // from the user to operators
+	TicketMessageOutgoing = "out" // from an operator to the user
+)
+
+// Ticket is a support conversation between a Telegram user and operators
+type Ticket struct {
+	ID         uint64          `gorm:"primaryKey" json:"id"`
+	TelegramID int64           `gorm:"index;not null" json:"telegram_id"`
+	Status     string          `gorm:"size:16;not null" json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	ClosedAt   *time.Time      `json:"closed_at,omitempty"`
+	Messages   []TicketMessage `json:"messages,omitempty"`
+}
+
+// TicketMessage is a message of a support ticket
+type TicketMessage struct {
+	ID               uint64    `gorm:"primaryKey" json:"id"`
+	TicketID         uint64    `gorm:"index;not null" json:"ticket_id"`
+	Direction        string    `gorm:"size:8;not null" json:"direction"`
+	UserMessageID    int       `json:"user_message_id,omitempty"`
+	SupportMessageID int       `gorm:"index" json:"support_message_id,omitempty"`
+	OperatorID       int64     `json:"operator_id,omitempty"`
+	ContentType      string    `gorm:"size:32;not null" json:"content_type"`
+	Text             string    `gorm:"type:text" json:"text,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// TrafficSnapshot is a recorded state of the cumulative traffic counters of a 3x-ui client
+type TrafficSnapshot struct {
+	ID         uint64 `gorm:"primaryKey"`
+	Panel      string `gorm:"size:255;not null"`
+	Email      string `gorm:"size:255;not null"`
+	InboundID  int    `gorm:"not null"`
+	Up         int64  `gorm:"not null"`
+	Down       int64  `gorm:"not null"`
+	Total      int64  `gorm:"not null"`
+	Enable     bool   `gorm:"not null"`
+	UpDelta    int64  `gorm:"not null"`
+	DownDelta  int64  `gorm:"not null"`
+	Reset      bool   `gorm:"not null"`
+	CapturedAt time.Time
+}
+
+// TrafficHourly is the traffic of a client within an hour (UTC)
+type TrafficHourly struct {
+	Panel  string    `gorm:"primaryKey;size:255"`
+	Email  string    `gorm:"primaryKey;size:255"`
+	Bucket time.Time `gorm:"primaryKey"`
+	Up     int64     `gorm:"not null"`
+	Down   int64     `gorm:"not null"`
+}
+
+// TableName specifies the table name for TrafficHourly
+func (TrafficHourly) TableName() string {
+	return "traffic_hourly"
+}
+
+// TrafficDaily is the traffic of a client within a day (UTC)
+type TrafficDaily struct {
+	Panel string    `gorm:"primaryKey;size:255"`
+	Email string    `gorm:"primaryKey;size:255"`
+	Day   time.Time `gorm:"primaryKey;type:date"`
+	Up    int64     `gorm:"not null"`
+	Down  int64     `gorm:"not null"`
+}
+
+// TableName specifies the table name for TrafficDaily
+func (TrafficDaily) TableName() string {
+	return "traffic_daily"
+}
+
+// Chat is a group or channel the bot was added to
+type Chat struct {
+	ID        uint64 `gorm:"primaryKey"`
+	ChatID    int64  `gorm:"uniqueIndex;not null"`
+	Type      string `gorm:"size:16;not null"`
+	Title     string `gorm:"size:255;not null"`
+	Username  string `gorm:"size:255;not null"`
+	Status    string `gorm:"size:16;not null"` // status of the bot: member, administrator, left, kicked...
+	AddedBy   *int64 // Telegram ID of the user who added the bot
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Ban blocks updates from a Telegram user or chat
+type Ban struct {
+	ID           uint64     `gorm:"primaryKey" json:"id"`
+	TelegramID   int64      `gorm:"uniqueIndex;not null" json:"telegram_id"`
+	Reason       string     `gorm:"type:text;not null" json:"reason"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // nil means permanent
+	BannedByType string     `gorm:"size:16;not null" json:"banned_by_type"`
+	BannedBy     int64      `gorm:"not null" json:"banned_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Active reports whether the ban is in effect at the given time
+func (b *Ban) Active(now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(now)
+}
+
+// Audit actor types
+const (
+	AuditActorTelegram = "telegram"
+	AuditActorAdmin    = "admin"
+)
+
+// AuditLog records an action performed by an administrator
+type AuditLog struct {
+	ID        uint64          `gorm:"primaryKey" json:"id"`
+	ActorType string          `gorm:"size:16;not null" json:"actor_type"`
+	ActorID   int64           `gorm:"not null" json:"actor_id"`
+	Action    string          `gorm:"size:64;not null" json:"action"`
+	Target    string          `gorm:"size:255;not null" json:"target"`
+	Details   json.RawMessage `gorm:"type:jsonb" json:"details,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Admin represents an administrator with security features. TOTPSecret is set on 2FA setup
+// and used for login only once TOTPEnabled is confirmed with a code.
 type Admin struct {
-	ID                  uint64 `gorm:"primaryKey"`
-	Login               string `gorm:"type:citext;uniqueIndex;not null"`
-	HashedPassword      string `gorm:"size:255;not null"`
-	IsActive            bool   `gorm:"default:true"`
-	LastLoginAt         *time.Time
-	FailedLoginAttempts int `gorm:"default:0"`
-	LockedUntil         *time.Time
-	CreatedAt           time.Time
-	UpdatedAt           time.Time
+	ID                  uint64     `gorm:"primaryKey" json:"id"`
+	Login               string     `gorm:"type:citext;uniqueIndex;not null" json:"login"`
+	HashedPassword      string     `gorm:"size:255;not null" json:"-"`
+	IsActive            bool       `gorm:"default:true" json:"is_active"`
+	RoleID              uint64     `gorm:"not null" json:"role_id"`
+	Role                *Role      `json:"role,omitempty"`
+	LastLoginAt         *time.Time `json:"last_login_at,omitempty"`
+	FailedLoginAttempts int        `gorm:"default:0" json:"failed_login_attempts"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	TOTPSecret          *string    `gorm:"column:totp_secret;size:64" json:"-"`
+	TOTPEnabled         bool       `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	TOTPLastStep        int64      `gorm:"column:totp_last_step;not null;default:0" json:"-"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 // TableName specifies the table name for Admin
 func (Admin) TableName() string {
 	return "admins"
 }
+
+// Role is a named set of admin permissions, see auth.Permissions
+type Role struct {
+	ID          uint64     `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"size:32;uniqueIndex;not null" json:"name"`
+	Description string     `gorm:"not null;default:''" json:"description"`
+	Permissions StringList `gorm:"type:jsonb;not null;default:'[]'" json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AdminSession is a login session of an admin. The refresh token is stored as a SHA-256 hash
+// and rotated on every refresh, AccessJTI is the jti of the current access token.
+type AdminSession struct {
+	ID               uint64     `gorm:"primaryKey" json:"id"`
+	AdminID          uint64     `gorm:"not null;index" json:"admin_id"`
+	RefreshTokenHash string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	AccessJTI        string     `gorm:"column:access_jti;size:64;uniqueIndex;not null" json:"-"`
+	UserAgent        string     `gorm:"size:255;not null;default:''" json:"user_agent"`
+	IP               string     `gorm:"size:64;not null;default:''" json:"ip"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// AdminSessionRotatedToken is the SHA-256 hash of a refresh token replaced by a refresh.
+// A rotated token presented again means it was stolen, and its session is revoked.
+type AdminSessionRotatedToken struct {
+	TokenHash string    `gorm:"type:char(64);primaryKey"`
+	SessionID uint64    `gorm:"not null;index"`
+	RotatedAt time.Time `gorm:"not null"`
+}
+
+// AdminRecoveryCode is a one-time code that replaces a TOTP code when the admin has lost the device.
+// Only the SHA-256 of the code is stored.
+type AdminRecoveryCode struct {
+	ID        uint64     `gorm:"primaryKey" json:"id"`
+	AdminID   uint64     `gorm:"not null;index" json:"admin_id"`
+	CodeHash  string     `gorm:"type:char(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// APIKey is a named key for machine access to the admin API on behalf of an admin. It grants its
+// Permissions, limited to those of the admin's role. Only Prefix is stored in plain text.
+type APIKey struct {
+	ID          uint64     `gorm:"primaryKey" json:"id"`
+	AdminID     uint64     `gorm:"not null;index" json:"admin_id"`
+	Admin       *Admin     `json:"-"`
+	Name        string     `gorm:"size:64;not null" json:"name"`
+	Prefix      string     `gorm:"size:16;uniqueIndex;not null" json:"prefix"`
+	KeyHash     string     `gorm:"type:char(64);not null" json:"-"`
+	Permissions StringList `gorm:"type:jsonb;not null;default:'[]'" json:"permissions"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// TelegramUpdate is a persisted Telegram update waiting in the processing queue
+type TelegramUpdate struct {
+	UpdateID    int64  `gorm:"primaryKey;autoIncrement:false"`
+	Payload     []byte `gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time
+	ProcessedAt *time.Time
+}
+
+// TableName specifies the table name for TelegramUpdate
+func (TelegramUpdate) TableName() string {
+	return "telegram_updates"
+}
@@ -0,0 +1,10 @@
+// Package metrics содержит счетчики приложения, публикуемые через expvar.
+// Значения доступны в JSON по адресу /debug/vars.
+package metrics
+
+import "expvar"
+
+var (
+	// WebhookRejected считает запросы к вебхуку, отклоненные из-за неверного secret token.
+	WebhookRejected = expvar.NewInt("webhook_rejected_total")
+)
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-bot/internal/database"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// processedRetention - сколько хранить обработанные обновления для отсечения дублей.
+// Telegram хранит недоставленные обновления не дольше 24 часов.
+const processedRetention = 24 * time.Hour
+
+// PostgresStore хранит очередь обновлений в таблице telegram_updates.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore creates a new PostgresStore.
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Save сохраняет обновление, если его update_id еще не встречался.
+func (s *PostgresStore) Save(ctx context.Context, update tgbotapi.Update) (bool, error) {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal update: %w", err)
+	}
+
+	row := database.TelegramUpdate{
+		UpdateID: int64(update.UpdateID),
+		Payload:  payload,
+	}
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete удаляет обновление из очереди.
+func (s *PostgresStore) Delete(ctx context.Context, updateID int) error {
+	return s.db.WithContext(ctx).Delete(&database.TelegramUpdate{}, int64(updateID)).Error
+}
+
+// MarkDone отмечает обновление обработанным. Обработанные записи остаются на время
+// processedRetention, чтобы повторная доставка того же update_id отсекалась.
+func (s *PostgresStore) MarkDone(ctx context.Context, updateID int) error {
+	return s.db.WithContext(ctx).
+		Model(&database.TelegramUpdate{}).
+		Where("update_id = ?", int64(updateID)).
+		Update("processed_at", time.Now()).Error
+}
+
+// Pending удаляет устаревшие обработанные записи и возвращает необработанные обновления.
+func (s *PostgresStore) Pending(ctx context.Context) ([]tgbotapi.Update, error) {
+	db := s.db.WithContext(ctx)
+
+	if err := db.Where("processed_at < ?", time.Now().Add(-processedRetention)).
+		Delete(&database.TelegramUpdate{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to prune processed updates: %w", err)
+	}
+
+	var rows []database.TelegramUpdate
+	if err := db.Where("processed_at IS NULL").Order("update_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	updates := make([]tgbotapi.Update, 0, len(rows))
+	for _, row := range rows {
+		var update tgbotapi.Update
+		if err := json.Unmarshal(row.Payload, &update); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal update %d: %w", row.UpdateID, err)
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
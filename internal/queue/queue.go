@@ -0,0 +1,319 @@
+// Package queue реализует ограниченную очередь обновлений Telegram с пулом воркеров.
+//
+// Обновления распределяются по воркерам по ID чата, поэтому обновления одного чата
+// обрабатываются строго по порядку, а разные чаты - параллельно. Повторные update_id
+// отбрасываются. При наличии Store очередь переживает рестарт: необработанные
+// обновления восстанавливаются при запуске.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DefaultWorkers - количество воркеров по умолчанию.
+	DefaultWorkers = 8
+	// DefaultQueueSize - размер буфера одного воркера по умолчанию.
+	DefaultQueueSize = 100
+	// DefaultProcessTimeout - ограничение времени обработки одного обновления по умолчанию.
+	DefaultProcessTimeout = 2 * time.Minute
+	// markDoneTimeout - время на отметку обновления обработанным. Отметка делается со своим
+	// контекстом: контекст обработки к этому моменту может быть уже отменен по таймауту.
+	markDoneTimeout = 10 * time.Second
+	// dedupCapacity - сколько последних update_id помнить для отсечения дублей.
+	dedupCapacity = 10000
+)
+
+var (
+	// ErrQueueFull возвращается, если обновление не удалось поставить в очередь вовремя.
+	ErrQueueFull = errors.New("update queue is full")
+	// ErrQueueClosed возвращается после начала остановки очереди.
+	ErrQueueClosed = errors.New("update queue is closed")
+	// ErrDuplicate возвращается для уже полученного update_id.
+	ErrDuplicate = errors.New("duplicate update")
+)
+
+// Processor обрабатывает одно обновление. Ему удовлетворяет services.WebhookServiceInterface.
+type Processor interface {
+	ProcessUpdate(ctx context.Context, update tgbotapi.Update) error
+}
+
+// Store сохраняет обновления, чтобы они пережили рестарт приложения.
+type Store interface {
+	// Save сохраняет обновление. Возвращает false, если update_id уже сохранен.
+	Save(ctx context.Context, update tgbotapi.Update) (bool, error)
+	// Delete удаляет обновление, которое не удалось поставить в очередь.
+	Delete(ctx context.Context, updateID int) error
+	// MarkDone отмечает обновление обработанным.
+	MarkDone(ctx context.Context, updateID int) error
+	// Pending возвращает необработанные обновления в порядке update_id.
+	Pending(ctx context.Context) ([]tgbotapi.Update, error)
+}
+
+// Options задает параметры очереди. Нулевые значения заменяются значениями по умолчанию.
+type Options struct {
+	Workers        int
+	QueueSize      int
+	ProcessTimeout time.Duration
+	// Store - необязательное постоянное хранилище. Если nil, очередь хранится только в памяти.
+	Store  Store
+	Logger *slog.Logger
+}
+
+// UpdateQueue - очередь обновлений с пулом воркеров.
+type UpdateQueue struct {
+	processor      Processor
+	store          Store
+	logger         *slog.Logger
+	processTimeout time.Duration
+
+	shards []chan tgbotapi.Update
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex // защищает closed и отправку в shards
+	closed bool
+
+	seen *recentIDs
+}
+
+// New создает очередь. Воркеры запускаются методом Start.
+func New(processor Processor, opts Options) *UpdateQueue {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.ProcessTimeout <= 0 {
+		opts.ProcessTimeout = DefaultProcessTimeout
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	shards := make([]chan tgbotapi.Update, opts.Workers)
+	for i := range shards {
+		shards[i] = make(chan tgbotapi.Update, opts.QueueSize)
+	}
+
+	return &UpdateQueue{
+		processor:      processor,
+		store:          opts.Store,
+		logger:         opts.Logger,
+		processTimeout: opts.ProcessTimeout,
+		shards:         shards,
+		seen:           newRecentIDs(dedupCapacity),
+	}
+}
+
+// Start запускает воркеры и восстанавливает необработанные обновления из хранилища.
+func (q *UpdateQueue) Start(ctx context.Context) error {
+	for _, shard := range q.shards {
+		q.wg.Add(1)
+		go q.worker(shard)
+	}
+
+	if q.store == nil {
+		return nil
+	}
+
+	pending, err := q.store.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pending updates: %w", err)
+	}
+	for _, update := range pending {
+		q.seen.Add(update.UpdateID)
+		if err := q.push(ctx, update); err != nil {
+			return fmt.Errorf("failed to restore update %d: %w", update.UpdateID, err)
+		}
+	}
+	if len(pending) > 0 {
+		q.logger.Info("Restored pending updates", "count", len(pending))
+	}
+	return nil
+}
+
+// Enqueue ставит обновление в очередь. Если очередь заполнена, ждет освобождения места
+// до отмены ctx и возвращает ErrQueueFull. Повторный update_id дает ErrDuplicate.
+func (q *UpdateQueue) Enqueue(ctx context.Context, update tgbotapi.Update) error {
+	if !q.seen.Add(update.UpdateID) {
+		return ErrDuplicate
+	}
+
+	if q.store != nil {
+		saved, err := q.store.Save(ctx, update)
+		if err != nil {
+			q.seen.Remove(update.UpdateID)
+			return fmt.Errorf("failed to persist update: %w", err)
+		}
+		if !saved {
+			return ErrDuplicate
+		}
+	}
+
+	if err := q.push(ctx, update); err != nil {
+		// Даем Telegram доставить обновление повторно.
+		q.seen.Remove(update.UpdateID)
+		if q.store != nil {
+			if delErr := q.store.Delete(context.WithoutCancel(ctx), update.UpdateID); delErr != nil {
+				q.logger.Error("Failed to delete rejected update", "error", delErr, "update_id", update.UpdateID)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// Shutdown прекращает прием обновлений и ждет, пока воркеры обработают уже принятые.
+// Если ctx отменяется раньше, возвращает ошибку; необработанные обновления
+// остаются в хранилище и будут обработаны после рестарта.
+func (q *UpdateQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		for _, shard := range q.shards {
+			close(shard)
+		}
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("update queue drain interrupted: %w", ctx.Err())
+	}
+}
+
+// push отправляет обновление в очередь воркера, отвечающего за его чат.
+func (q *UpdateQueue) push(ctx context.Context, update tgbotapi.Update) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	shard := q.shards[shardIndex(routingKey(update), len(q.shards))]
+	select {
+	case shard <- update:
+		return nil
+	case <-ctx.Done():
+		return ErrQueueFull
+	}
+}
+
+func (q *UpdateQueue) worker(updates <-chan tgbotapi.Update) {
+	defer q.wg.Done()
+	for update := range updates {
+		q.process(update)
+	}
+}
+
+func (q *UpdateQueue) process(update tgbotapi.Update) {
+	// Контекст обработки не связан ни с HTTP-запросом, ни с остановкой приложения:
+	// начатое обновление всегда доводится до конца.
+	ctx, cancel := context.WithTimeout(context.Background(), q.processTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			q.logger.Error("Panic while processing update", "panic", r, "update_id", update.UpdateID)
+		}
+		if q.store != nil {
+			doneCtx, cancel := context.WithTimeout(context.Background(), markDoneTimeout)
+			defer cancel()
+			if err := q.store.MarkDone(doneCtx, update.UpdateID); err != nil {
+				q.logger.Error("Failed to mark update as done", "error", err, "update_id", update.UpdateID)
+			}
+		}
+	}()
+
+	if err := q.processor.ProcessUpdate(ctx, update); err != nil {
+		q.logger.Error("Failed to process update", "error", err, "update_id", update.UpdateID)
+	}
+}
+
+// routingKey возвращает ID чата (или пользователя), определяющий порядок обработки.
+func routingKey(update tgbotapi.Update) int64 {
+	switch {
+	case update.CallbackQuery != nil:
+		// У callback от inline-сообщений нет Message, и FromChat() на них паникует.
+		if update.CallbackQuery.Message != nil && update.CallbackQuery.Message.Chat != nil {
+			return update.CallbackQuery.Message.Chat.ID
+		}
+	case update.MyChatMember != nil:
+		return update.MyChatMember.Chat.ID
+	case update.ChatMember != nil:
+		return update.ChatMember.Chat.ID
+	default:
+		if chat := update.FromChat(); chat != nil {
+			return chat.ID
+		}
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	return int64(update.UpdateID)
+}
+
+func shardIndex(key int64, shards int) int {
+	if key < 0 {
+		key = -key
+	}
+	return int(key % int64(shards))
+}
+
+// recentIDs помнит ограниченное количество последних update_id.
+type recentIDs struct {
+	mu    sync.Mutex
+	ids   map[int]struct{}
+	order []int
+	next  int
+}
+
+func newRecentIDs(capacity int) *recentIDs {
+	return &recentIDs{
+		ids:   make(map[int]struct{}, capacity),
+		order: make([]int, 0, capacity),
+	}
+}
+
+// Add запоминает id и возвращает false, если он уже был.
+func (r *recentIDs) Add(id int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ids[id]; ok {
+		return false
+	}
+	if len(r.order) < cap(r.order) {
+		r.order = append(r.order, id)
+	} else {
+		delete(r.ids, r.order[r.next])
+		r.order[r.next] = id
+		r.next = (r.next + 1) % len(r.order)
+	}
+	r.ids[id] = struct{}{}
+	return true
+}
+
+// Remove забывает id, чтобы его можно было принять снова.
+func (r *recentIDs) Remove(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.ids, id)
+}
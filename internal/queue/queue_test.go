@@ -0,0 +1,236 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingProcessor records processed update IDs per chat.
+type recordingProcessor struct {
+	mu     sync.Mutex
+	byChat map[int64][]int
+	delay  time.Duration
+	block  chan struct{}
+	// untilDeadline makes processing run until the processing context expires.
+	untilDeadline bool
+}
+
+func newRecordingProcessor() *recordingProcessor {
+	return &recordingProcessor{byChat: make(map[int64][]int)}
+}
+
+func (p *recordingProcessor) ProcessUpdate(ctx context.Context, update tgbotapi.Update) error {
+	if p.block != nil {
+		<-p.block
+	}
+	if p.delay > 0 {
+		time.Sleep(p.delay)
+	}
+	if p.untilDeadline {
+		<-ctx.Done()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	chatID := update.Message.Chat.ID
+	p.byChat[chatID] = append(p.byChat[chatID], update.UpdateID)
+	return nil
+}
+
+func (p *recordingProcessor) total() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, ids := range p.byChat {
+		n += len(ids)
+	}
+	return n
+}
+
+// memoryStore is an in-memory Store implementation for tests.
+type memoryStore struct {
+	mu      sync.Mutex
+	updates map[int]tgbotapi.Update
+	done    map[int]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{updates: make(map[int]tgbotapi.Update), done: make(map[int]bool)}
+}
+
+func (s *memoryStore) Save(ctx context.Context, update tgbotapi.Update) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.updates[update.UpdateID]; ok {
+		return false, nil
+	}
+	s.updates[update.UpdateID] = update
+	return true, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, updateID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.updates, updateID)
+	return nil
+}
+
+func (s *memoryStore) MarkDone(ctx context.Context, updateID int) error {
+	// Like a database call, marking fails with a cancelled context.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done[updateID] = true
+	return nil
+}
+
+func (s *memoryStore) Pending(ctx context.Context) ([]tgbotapi.Update, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []tgbotapi.Update
+	for id, update := range s.updates {
+		if !s.done[id] {
+			pending = append(pending, update)
+		}
+	}
+	return pending, nil
+}
+
+func messageUpdate(updateID int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: updateID,
+		Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: chatID},
+			Text: "hello",
+		},
+	}
+}
+
+func silentLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestUpdateQueue_DeduplicatesUpdateIDs(t *testing.T) {
+	processor := newRecordingProcessor()
+	q := New(processor, Options{Workers: 2, Logger: silentLogger()})
+	require.NoError(t, q.Start(context.Background()))
+
+	require.NoError(t, q.Enqueue(context.Background(), messageUpdate(1, 100)))
+	err := q.Enqueue(context.Background(), messageUpdate(1, 100))
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	require.NoError(t, q.Shutdown(context.Background()))
+	assert.Equal(t, 1, processor.total())
+}
+
+func TestUpdateQueue_PreservesPerChatOrder(t *testing.T) {
+	processor := newRecordingProcessor()
+	processor.delay = time.Millisecond
+	q := New(processor, Options{Workers: 4, QueueSize: 50, Logger: silentLogger()})
+	require.NoError(t, q.Start(context.Background()))
+
+	chats := []int64{1, 2, 3, -1001234567890}
+	updateID := 0
+	for i := 0; i < 10; i++ {
+		for _, chatID := range chats {
+			updateID++
+			require.NoError(t, q.Enqueue(context.Background(), messageUpdate(updateID, chatID)))
+		}
+	}
+
+	require.NoError(t, q.Shutdown(context.Background()))
+
+	for _, chatID := range chats {
+		ids := processor.byChat[chatID]
+		require.Len(t, ids, 10, "chat %d", chatID)
+		assert.IsIncreasing(t, ids, "updates of chat %d must be processed in order", chatID)
+	}
+}
+
+func TestUpdateQueue_ShutdownDrainsAcceptedUpdates(t *testing.T) {
+	processor := newRecordingProcessor()
+	processor.delay = 5 * time.Millisecond
+	q := New(processor, Options{Workers: 1, QueueSize: 20, Logger: silentLogger()})
+	require.NoError(t, q.Start(context.Background()))
+
+	for i := 1; i <= 10; i++ {
+		require.NoError(t, q.Enqueue(context.Background(), messageUpdate(i, 7)))
+	}
+
+	require.NoError(t, q.Shutdown(context.Background()))
+	assert.Equal(t, 10, processor.total())
+
+	err := q.Enqueue(context.Background(), messageUpdate(11, 7))
+	assert.ErrorIs(t, err, ErrQueueClosed)
+}
+
+func TestUpdateQueue_FullQueueRejectsAndForgetsUpdate(t *testing.T) {
+	processor := newRecordingProcessor()
+	processor.block = make(chan struct{})
+	store := newMemoryStore()
+	q := New(processor, Options{Workers: 1, QueueSize: 1, Store: store, Logger: silentLogger()})
+	require.NoError(t, q.Start(context.Background()))
+
+	// The first update is taken by the blocked worker, the second fills the buffer.
+	require.NoError(t, q.Enqueue(context.Background(), messageUpdate(1, 7)))
+	require.Eventually(t, func() bool { return len(q.shards[0]) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, q.Enqueue(context.Background(), messageUpdate(2, 7)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := q.Enqueue(ctx, messageUpdate(3, 7))
+	require.True(t, errors.Is(err, ErrQueueFull), "expected ErrQueueFull, got %v", err)
+
+	// The rejected update is removed from the store, so Telegram's retry is accepted.
+	_, stored := store.updates[3]
+	assert.False(t, stored)
+
+	close(processor.block)
+	require.NoError(t, q.Enqueue(context.Background(), messageUpdate(3, 7)))
+	require.NoError(t, q.Shutdown(context.Background()))
+	assert.Equal(t, []int{1, 2, 3}, processor.byChat[7])
+}
+
+func TestUpdateQueue_RestoresPendingUpdatesFromStore(t *testing.T) {
+	store := newMemoryStore()
+	_, _ = store.Save(context.Background(), messageUpdate(5, 9))
+	_, _ = store.Save(context.Background(), messageUpdate(6, 9))
+	_ = store.MarkDone(context.Background(), 5)
+
+	processor := newRecordingProcessor()
+	q := New(processor, Options{Workers: 2, Store: store, Logger: silentLogger()})
+	require.NoError(t, q.Start(context.Background()))
+
+	// Redelivery of a restored update is treated as a duplicate.
+	assert.ErrorIs(t, q.Enqueue(context.Background(), messageUpdate(6, 9)), ErrDuplicate)
+
+	require.NoError(t, q.Shutdown(context.Background()))
+	assert.Equal(t, []int{6}, processor.byChat[9])
+	assert.True(t, store.done[6])
+}
+
+func TestUpdateQueue_MarksDoneAfterProcessTimeout(t *testing.T) {
+	processor := newRecordingProcessor()
+	processor.untilDeadline = true
+	store := newMemoryStore()
+	q := New(processor, Options{Workers: 1, QueueSize: 1, Store: store, ProcessTimeout: 10 * time.Millisecond, Logger: silentLogger()})
+	require.NoError(t, q.Start(context.Background()))
+
+	require.NoError(t, q.Enqueue(context.Background(), messageUpdate(1, 7)))
+	require.NoError(t, q.Shutdown(context.Background()))
+
+	// An update that hit its deadline is still marked done and isn't replayed after a restart.
+	pending, err := store.Pending(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
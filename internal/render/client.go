@@ -0,0 +1,55 @@
+package render
+
+import (
+	"fmt"
+	"time"
+
+	"go-bot/internal/xui"
+)
+
+// Expiry описывает срок действия клиента для текста сообщения: дата выводится в часовом
+// поясе пользователя loc (nil - UTC). В 3x-ui ExpiryTime 0 - бессрочно, отрицательное
+// значение - срок в днях, отсчитываемый с первого подключения.
+func Expiry(expiryTime int64, loc *time.Location, now time.Time) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	switch {
+	case expiryTime == 0:
+		return "бессрочно"
+	case expiryTime < 0:
+		return fmt.Sprintf("%d дн. с первого подключения", delayedDays(expiryTime))
+	}
+	expiry := time.UnixMilli(expiryTime).In(loc)
+	if !expiry.After(now) {
+		return "истек " + expiry.Format("02.01.2006")
+	}
+	return "до " + expiry.Format("02.01.2006")
+}
+
+// TrafficLeft описывает остаток трафика клиента. Total 0 в 3x-ui - без ограничений.
+func TrafficLeft(client xui.ClientTraffic) string {
+	used := float64(client.Up+client.Down) / bytesPerGB
+	if client.Total <= 0 {
+		return fmt.Sprintf("%.2f GB использовано, без ограничений", used)
+	}
+	total := float64(client.Total) / bytesPerGB
+	return fmt.Sprintf("осталось %.2f из %.2f GB", max(total-used, 0), total)
+}
+
+// ClientActive сообщает, может ли клиент сейчас подключиться: он включен, срок не истек и
+// трафик не исчерпан. Total <= 0 считается безлимитом, как в TrafficLeft.
+func ClientActive(client xui.ClientTraffic, now time.Time) bool {
+	if !client.Enable {
+		return false
+	}
+	if client.ExpiryTime > 0 && !time.UnixMilli(client.ExpiryTime).After(now) {
+		return false
+	}
+	return client.Total <= 0 || client.Up+client.Down < client.Total
+}
+
+// GB форматирует объем трафика в байтах.
+func GB(bytes int64) string {
+	return fmt.Sprintf("%.2f GB", float64(bytes)/bytesPerGB)
+}
@@ -0,0 +1,165 @@
+// Package render форматирует данные клиентов 3x-ui для отправки в Telegram.
+package render
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-bot/internal/xui"
+)
+
+const (
+	// MaxMessageLength - ограничение Telegram на длину текста сообщения.
+	MaxMessageLength = 4096
+
+	bytesPerGB = 1024 * 1024 * 1024
+
+	emailWidth    = 20
+	progressWidth = 10
+	tableWidth    = 56
+
+	// partSuffixReserve - место под номер части " (NN/NN)" в заголовке.
+	partSuffixReserve = 8
+)
+
+// TableOptions настраивают ClientTable.
+type TableOptions struct {
+	// Location - часовой пояс пользователя для дат. nil - UTC.
+	Location *time.Location
+	// Now - текущее время для расчета оставшихся дней. Нулевое значение - time.Now().
+	Now time.Time
+	// MaxLength - максимальная длина одного сообщения. 0 - MaxMessageLength.
+	MaxLength int
+}
+
+// ClientTable форматирует трафик клиентов в HTML-таблицу. Если таблица не помещается
+// в одно сообщение, она делится по строкам на несколько сообщений, каждое со своим заголовком.
+func ClientTable(email string, clients []xui.ClientTraffic, opts TableOptions) []string {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = MaxMessageLength
+	}
+
+	title := fmt.Sprintf("<b>Данные для клиента:</b> <code>%s</code>", html.EscapeString(email))
+	tableHeader := fmt.Sprintf("%-22s | %-13s | %-10s | %s\n", "Email", "Usage (GB)", "Expiry", "Status") +
+		strings.Repeat("-", tableWidth) + "\n"
+	footer := "</pre>\nЧасовой пояс: " + html.EscapeString(opts.Location.String())
+
+	// Длина части без строк клиентов.
+	frame := utf8.RuneCountInString(title) + partSuffixReserve + len("\n<pre>") +
+		utf8.RuneCountInString(tableHeader) + utf8.RuneCountInString(footer)
+
+	var parts [][]string
+	var current []string
+	length := frame
+	for _, client := range clients {
+		row := clientRow(client, opts)
+		rowLength := utf8.RuneCountInString(row)
+		if len(current) > 0 && length+rowLength > opts.MaxLength {
+			parts = append(parts, current)
+			current, length = nil, frame
+		}
+		current = append(current, row)
+		length += rowLength
+	}
+	parts = append(parts, current)
+
+	messages := make([]string, 0, len(parts))
+	for i, rows := range parts {
+		var sb strings.Builder
+		sb.WriteString(title)
+		if len(parts) > 1 {
+			sb.WriteString(fmt.Sprintf(" (%d/%d)", i+1, len(parts)))
+		}
+		sb.WriteString("\n<pre>")
+		sb.WriteString(tableHeader)
+		for _, row := range rows {
+			sb.WriteString(row)
+		}
+		sb.WriteString(footer)
+		messages = append(messages, sb.String())
+	}
+	return messages
+}
+
+// clientRow форматирует строку таблицы и строку с расходом трафика и сроком под ней.
+func clientRow(client xui.ClientTraffic, opts TableOptions) string {
+	status := "❌"
+	if client.Enable {
+		status = "✅"
+	}
+
+	row := fmt.Sprintf("%-22s | %-13s | %-10s | %s\n",
+		truncateRunes(client.Email, emailWidth), formatUsage(client), formatExpiryDate(client.ExpiryTime, opts.Location), status)
+	details := fmt.Sprintf("  %s · %s\n", formatProgress(client), remainingDays(client.ExpiryTime, opts.Now))
+	return html.EscapeString(row + details)
+}
+
+// formatUsage возвращает использованный и доступный трафик в GB. Total 0 в 3x-ui - без ограничений.
+func formatUsage(client xui.ClientTraffic) string {
+	used := float64(client.Up+client.Down) / bytesPerGB
+	if client.Total <= 0 {
+		return fmt.Sprintf("%.2f/∞", used)
+	}
+	return fmt.Sprintf("%.2f/%.2f", used, float64(client.Total)/bytesPerGB)
+}
+
+// formatExpiryDate возвращает дату окончания для колонки Expiry. В 3x-ui ExpiryTime 0 -
+// бессрочно, отрицательное значение - срок в днях, отсчитываемый с первого подключения.
+func formatExpiryDate(expiryTime int64, loc *time.Location) string {
+	switch {
+	case expiryTime == 0:
+		return "∞"
+	case expiryTime < 0:
+		return fmt.Sprintf("+%d дн.", delayedDays(expiryTime))
+	}
+	return time.UnixMilli(expiryTime).In(loc).Format("02.01.2006")
+}
+
+// formatProgress рисует шкалу израсходованного трафика.
+func formatProgress(client xui.ClientTraffic) string {
+	if client.Total <= 0 {
+		return "без ограничений"
+	}
+	ratio := min(float64(client.Up+client.Down)/float64(client.Total), 1)
+	filled := int(ratio*progressWidth + 0.5)
+	return fmt.Sprintf("%s%s %3.0f%%", strings.Repeat("█", filled), strings.Repeat("░", progressWidth-filled), ratio*100)
+}
+
+// remainingDays описывает, сколько осталось до окончания срока клиента.
+func remainingDays(expiryTime int64, now time.Time) string {
+	switch {
+	case expiryTime == 0:
+		return "бессрочно"
+	case expiryTime < 0:
+		return fmt.Sprintf("%d дн. с первого подключения", delayedDays(expiryTime))
+	}
+	left := time.UnixMilli(expiryTime).Sub(now)
+	if left <= 0 {
+		return "истек"
+	}
+	days := int((left + 24*time.Hour - 1) / (24 * time.Hour))
+	return fmt.Sprintf("осталось %d дн.", days)
+}
+
+// delayedDays переводит отрицательный ExpiryTime 3x-ui в число дней.
+func delayedDays(expiryTime int64) int64 {
+	return int64(time.Duration(-expiryTime) * time.Millisecond / (24 * time.Hour))
+}
+
+// truncateRunes обрезает строку до limit символов (а не байт), заменяя хвост на "..".
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-2]) + ".."
+}
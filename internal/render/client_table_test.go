@@ -0,0 +1,167 @@
+package render
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-bot/internal/xui"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Обновить эталоны: go test ./internal/render -update
+var update = flag.Bool("update", false, "update golden files")
+
+// partSeparator разделяет сообщения в эталонных файлах.
+const partSeparator = "\n===== next message =====\n"
+
+const gb = 1024 * 1024 * 1024
+
+func TestClientTable_Golden(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	var many []xui.ClientTraffic
+	for i := 0; i < 6; i++ {
+		many = append(many, xui.ClientTraffic{
+			Email: "client-" + string(rune('a'+i)) + "@example.com", Enable: i%2 == 0,
+			Down: int64(i) * gb, Total: 10 * gb, ExpiryTime: now.AddDate(0, 0, i+1).UnixMilli(),
+		})
+	}
+
+	testCases := []struct {
+		name    string
+		email   string
+		clients []xui.ClientTraffic
+		opts    TableOptions
+	}{
+		{
+			name:  "basic",
+			email: "test@example.com",
+			clients: []xui.ClientTraffic{
+				{Email: "test@example.com", Enable: true, Up: 1 * gb, Down: 2 * gb, Total: 10 * gb, ExpiryTime: 1736942400000}, // 15.01.2025 12:00 UTC
+			},
+		},
+		{
+			name:  "unlimited",
+			email: "free@example.com",
+			clients: []xui.ClientTraffic{
+				{Email: "free@example.com", Enable: true, Up: gb / 2, Down: 5 * gb, Total: 0, ExpiryTime: 0},
+			},
+		},
+		{
+			name:  "delayed_start",
+			email: "new@example.com",
+			clients: []xui.ClientTraffic{
+				{Email: "new@example.com", Enable: true, Total: 30 * gb, ExpiryTime: -30 * 24 * time.Hour.Milliseconds()},
+			},
+		},
+		{
+			name:  "expired_over_limit",
+			email: "old@example.com",
+			clients: []xui.ClientTraffic{
+				{Email: "old@example.com", Enable: false, Up: 3 * gb, Down: 9 * gb, Total: 10 * gb, ExpiryTime: 1735603200000}, // 31.12.2024 00:00 UTC
+			},
+		},
+		{
+			name:  "long_unicode_email",
+			email: "пользователь-с-длинным-именем@пример.рф",
+			clients: []xui.ClientTraffic{
+				{Email: "пользователь-с-длинным-именем@пример.рф", Enable: true, Down: gb, Total: 4 * gb, ExpiryTime: 1736942400000},
+			},
+		},
+		{
+			// Ровно emailWidth символов: email выводится целиком.
+			name:  "email_at_limit",
+			email: "abcdefgh@examples.io",
+			clients: []xui.ClientTraffic{
+				{Email: "abcdefgh@examples.io", Enable: true, Down: gb, Total: 4 * gb, ExpiryTime: 1736942400000},
+			},
+		},
+		{
+			// На символ длиннее: email обрезается до emailWidth вместе с "..".
+			name:  "email_past_limit",
+			email: "abcdefghi@examples.io",
+			clients: []xui.ClientTraffic{
+				{Email: "abcdefghi@examples.io", Enable: true, Down: gb, Total: 4 * gb, ExpiryTime: 1736942400000},
+			},
+		},
+		{
+			name:  "html_escape",
+			email: "<b>&co",
+			clients: []xui.ClientTraffic{
+				{Email: "<b>&co", Enable: true, Total: 0, ExpiryTime: 0},
+			},
+		},
+		{
+			name:  "time_zone",
+			email: "night@example.com",
+			clients: []xui.ClientTraffic{
+				// 01.01.2025 22:00 UTC - в Москве уже 02.01.2025.
+				{Email: "night@example.com", Enable: true, Total: 0, ExpiryTime: 1735768800000},
+			},
+			opts: TableOptions{Location: moscow},
+		},
+		{
+			name:    "split",
+			email:   "example.com",
+			clients: many,
+			opts:    TableOptions{MaxLength: 600},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Now = now
+			messages := ClientTable(tc.email, tc.clients, tc.opts)
+			limit := tc.opts.MaxLength
+			if limit == 0 {
+				limit = MaxMessageLength
+			}
+			for _, message := range messages {
+				assert.LessOrEqual(t, len([]rune(message)), limit)
+			}
+
+			got := strings.Join(messages, partSeparator)
+			path := filepath.Join("testdata", tc.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestClientTable_SplitKeepsAllRows(t *testing.T) {
+	var clients []xui.ClientTraffic
+	for i := 0; i < 200; i++ {
+		clients = append(clients, xui.ClientTraffic{Email: "user@example.com", Total: 10 * gb, ExpiryTime: 1736942400000})
+	}
+
+	messages := ClientTable("example.com", clients, TableOptions{})
+
+	require.Greater(t, len(messages), 1)
+	rows := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, len([]rune(message)), MaxMessageLength)
+		assert.True(t, strings.HasSuffix(message, "Часовой пояс: UTC"))
+		rows += strings.Count(message, "user@example.com")
+	}
+	assert.Equal(t, len(clients), rows)
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "short", truncateRunes("short", 20))
+	assert.Equal(t, "абвгдежзийклмнопрс..", truncateRunes("абвгдежзийклмнопрстуфхцч", 20))
+	assert.Equal(t, "абвгдежзийклмнопрсту", truncateRunes("абвгдежзийклмнопрсту", 20))
+	assert.Equal(t, "абвгдежзийклмнопрс..", truncateRunes("абвгдежзийклмнопрсту1", 20))
+	assert.Len(t, []rune(truncateRunes("абвгдежзийклмнопрсту1", 20)), 20)
+}
@@ -0,0 +1,51 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"go-bot/internal/xui"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "бессрочно", Expiry(0, nil, now))
+	assert.Equal(t, "30 дн. с первого подключения", Expiry(-(30*24*time.Hour).Milliseconds(), nil, now))
+	assert.Equal(t, "до 01.07.2025", Expiry(now.AddDate(0, 1, 0).UnixMilli(), nil, now))
+	assert.Equal(t, "истек 31.05.2025", Expiry(now.AddDate(0, 0, -1).UnixMilli(), nil, now))
+}
+
+func TestExpiry_UserTimeZone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	expiry := time.Date(2025, 6, 30, 22, 0, 0, 0, time.UTC).UnixMilli()
+
+	assert.Equal(t, "до 30.06.2025", Expiry(expiry, time.UTC, now))
+	assert.Equal(t, "до 01.07.2025", Expiry(expiry, moscow, now), "In UTC+3 the client expires on the next day")
+}
+
+func TestTrafficLeft(t *testing.T) {
+	assert.Equal(t, "осталось 7.00 из 10.00 GB", TrafficLeft(xui.ClientTraffic{Up: 1 * bytesPerGB, Down: 2 * bytesPerGB, Total: 10 * bytesPerGB}))
+	assert.Equal(t, "осталось 0.00 из 1.00 GB", TrafficLeft(xui.ClientTraffic{Down: 2 * bytesPerGB, Total: 1 * bytesPerGB}))
+	assert.Equal(t, "1.50 GB использовано, без ограничений", TrafficLeft(xui.ClientTraffic{Down: 3 * bytesPerGB / 2}))
+	assert.Equal(t, "50.00 GB", GB(50*bytesPerGB))
+}
+
+func TestClientActive(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := now.Add(24 * time.Hour).UnixMilli()
+	past := now.Add(-time.Hour).UnixMilli()
+
+	assert.True(t, ClientActive(xui.ClientTraffic{Enable: true}, now), "Unlimited client")
+	assert.True(t, ClientActive(xui.ClientTraffic{Enable: true, ExpiryTime: future, Total: 10, Down: 5}, now))
+	assert.True(t, ClientActive(xui.ClientTraffic{Enable: true, ExpiryTime: -86400000}, now), "Not started yet")
+	assert.False(t, ClientActive(xui.ClientTraffic{Enable: false}, now), "Disabled")
+	assert.False(t, ClientActive(xui.ClientTraffic{Enable: true, ExpiryTime: past}, now), "Expired")
+	assert.False(t, ClientActive(xui.ClientTraffic{Enable: true, Total: 10, Up: 4, Down: 6}, now), "Traffic exhausted")
+	assert.True(t, ClientActive(xui.ClientTraffic{Enable: true, Total: -1, Down: 5}, now), "Negative total is unlimited")
+}
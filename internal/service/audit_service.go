@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// AuditService records administrator actions.
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService creates a new AuditService.
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record stores an action. details is marshaled to JSON and may be nil.
+func (s *AuditService) Record(ctx context.Context, actorType string, actorID int64, action, target string, details any) error {
+	entry := &database.AuditLog{
+		ActorType: actorType,
+		ActorID:   actorID,
+		Action:    action,
+		Target:    target,
+	}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		entry.Details = raw
+	}
+
+	if err := s.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to save audit log: %w", err)
+	}
+	return nil
+}
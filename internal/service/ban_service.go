@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// banCacheTTL is how long active bans are cached. Bans made through the same BanService drop
+// the cache at once; bans made by another process take effect within this time.
+const banCacheTTL = 30 * time.Second
+
+// ErrBanNotFound is returned when unbanning an ID that isn't banned.
+var ErrBanNotFound = errors.New("ban not found")
+
+// BanService manages banned Telegram users and chats. Active bans are cached in
+// memory, since they are checked for every incoming update.
+type BanService struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	active   map[int64]database.Ban
+	loadedAt time.Time
+}
+
+// NewBanService creates a new BanService.
+func NewBanService(db *gorm.DB) *BanService {
+	return &BanService{db: db}
+}
+
+// Ban bans a Telegram user or chat. Banning an already banned ID replaces the ban.
+func (s *BanService) Ban(ctx context.Context, ban database.Ban) (*database.Ban, error) {
+	ban.ID = 0
+	ban.CreatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "telegram_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "expires_at", "banned_by_type", "banned_by", "created_at"}),
+	}, clause.Returning{}).Create(&ban).Error; err != nil {
+		return nil, fmt.Errorf("failed to save ban: %w", err)
+	}
+
+	s.invalidate()
+	return &ban, nil
+}
+
+// Get returns the ban of a Telegram user or chat, including an expired one.
+func (s *BanService) Get(ctx context.Context, telegramID int64) (*database.Ban, error) {
+	var ban database.Ban
+	if err := s.db.WithContext(ctx).Where("telegram_id = ?", telegramID).First(&ban).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBanNotFound
+		}
+		return nil, fmt.Errorf("failed to get ban: %w", err)
+	}
+	return &ban, nil
+}
+
+// Unban lifts the ban of a Telegram user or chat.
+func (s *BanService) Unban(ctx context.Context, telegramID int64) error {
+	result := s.db.WithContext(ctx).Where("telegram_id = ?", telegramID).Delete(&database.Ban{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete ban: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBanNotFound
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// List returns bans, newest first, and the total count. With activeOnly expired bans are skipped.
+func (s *BanService) List(ctx context.Context, activeOnly bool, limit, offset int) ([]database.Ban, int64, error) {
+	query := s.db.WithContext(ctx).Model(&database.Ban{})
+	if activeOnly {
+		query = query.Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count bans: %w", err)
+	}
+
+	var bans []database.Ban
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&bans).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list bans: %w", err)
+	}
+	return bans, total, nil
+}
+
+// Check returns the active ban of the first banned ID among ids, or nil if none is banned.
+// Zero IDs are ignored. If the bans can't be loaded, the previously cached bans are used.
+func (s *BanService) Check(ctx context.Context, ids ...int64) (*database.Ban, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var loadErr error
+	if now.Sub(s.loadedAt) >= banCacheTTL {
+		loadErr = s.load(ctx, now)
+	}
+
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if ban, ok := s.active[id]; ok && ban.Active(now) {
+			return &ban, loadErr
+		}
+	}
+	return nil, loadErr
+}
+
+// load refreshes the cache of active bans. The caller must hold s.mu.
+// A failed load is retried after banCacheTTL, not on every update.
+func (s *BanService) load(ctx context.Context, now time.Time) error {
+	var bans []database.Ban
+	if err := s.db.WithContext(ctx).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Find(&bans).Error; err != nil {
+		s.loadedAt = now
+		return fmt.Errorf("failed to load bans: %w", err)
+	}
+
+	s.active = make(map[int64]database.Ban, len(bans))
+	for _, ban := range bans {
+		s.active[ban.TelegramID] = ban
+	}
+	s.loadedAt = now
+	return nil
+}
+
+// invalidate makes the next Check reload the bans.
+func (s *BanService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChatService keeps track of the groups and channels the bot was added to.
+type ChatService struct {
+	db *gorm.DB
+}
+
+// NewChatService creates a new ChatService.
+func NewChatService(db *gorm.DB) *ChatService {
+	return &ChatService{db: db}
+}
+
+// Save creates or updates a chat by its Telegram ID. AddedBy is kept when the update has none.
+func (s *ChatService) Save(ctx context.Context, chat database.Chat) error {
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chat_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"type":       gorm.Expr("EXCLUDED.type"),
+			"title":      gorm.Expr("EXCLUDED.title"),
+			"username":   gorm.Expr("EXCLUDED.username"),
+			"status":     gorm.Expr("EXCLUDED.status"),
+			"added_by":   gorm.Expr("COALESCE(EXCLUDED.added_by, chats.added_by)"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&chat).Error
+	if err != nil {
+		return fmt.Errorf("failed to save chat: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrClientNotLinked is returned when a Telegram user has no linked 3x-ui client.
+var ErrClientNotLinked = errors.New("no client linked to the user")
+
+// ClientLinkService manages links between Telegram users and 3x-ui clients.
+type ClientLinkService struct {
+	db *gorm.DB
+}
+
+// NewClientLinkService creates a new ClientLinkService.
+func NewClientLinkService(db *gorm.DB) *ClientLinkService {
+	return &ClientLinkService{db: db}
+}
+
+// ForUser returns all clients linked to a Telegram user, newest first.
+func (s *ClientLinkService) ForUser(ctx context.Context, telegramID int64) ([]database.ClientLink, error) {
+	var links []database.ClientLink
+	if err := s.db.WithContext(ctx).
+		Where("telegram_id = ?", telegramID).
+		Order("created_at DESC, id DESC").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to get client links: %w", err)
+	}
+	return links, nil
+}
+
+// Latest returns the most recently linked client of a Telegram user.
+func (s *ClientLinkService) Latest(ctx context.Context, telegramID int64) (*database.ClientLink, error) {
+	links, err := s.ForUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, ErrClientNotLinked
+	}
+	return &links[0], nil
+}
+
+// Get returns a link by ID if it belongs to the given Telegram user.
+func (s *ClientLinkService) Get(ctx context.Context, id uint64, telegramID int64) (*database.ClientLink, error) {
+	var link database.ClientLink
+	err := s.db.WithContext(ctx).Where("id = ? AND telegram_id = ?", id, telegramID).First(&link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotLinked
+		}
+		return nil, fmt.Errorf("failed to get client link: %w", err)
+	}
+	return &link, nil
+}
+
+// Link links an existing 3x-ui client to a Telegram user, e.g. a client created in the panel
+// before the bot. A client already linked to another user is moved to this one.
+func (s *ClientLinkService) Link(ctx context.Context, telegramID int64, email string, inboundID int) (*database.ClientLink, error) {
+	link := &database.ClientLink{
+		TelegramID: telegramID,
+		Email:      email,
+		InboundID:  inboundID,
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"telegram_id", "inbound_id"}),
+	}).Create(link).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to link client: %w", err)
+	}
+	return link, nil
+}
+
+// Create links a client to a Telegram user.
+func (s *ClientLinkService) Create(ctx context.Context, telegramID int64, email string, inboundID int) (*database.ClientLink, error) {
+	link := &database.ClientLink{
+		TelegramID: telegramID,
+		Email:      email,
+		InboundID:  inboundID,
+	}
+	if err := s.db.WithContext(ctx).Create(link).Error; err != nil {
+		return nil, fmt.Errorf("failed to create client link: %w", err)
+	}
+	return link, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned for a pagination cursor that wasn't issued by List.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MessageService stores and searches the conversation history with users.
+type MessageService struct {
+	db *gorm.DB
+}
+
+// NewMessageService creates a new MessageService.
+func NewMessageService(db *gorm.DB) *MessageService {
+	return &MessageService{db: db}
+}
+
+// SaveIncoming stores a message written by a Telegram user. The user must already be saved.
+func (s *MessageService) SaveIncoming(ctx context.Context, telegramID int64, message database.Message) error {
+	message.Direction = database.MessageIncoming
+	if err := s.db.WithContext(ctx).Exec(`INSERT INTO messages
+			(user_id, direction, chat_id, telegram_message_id, message_text, message_type, created_at)
+		SELECT id, ?, ?, ?, ?, ?, ? FROM users WHERE telegram_id = ?`,
+		message.Direction, message.ChatID, message.TelegramMessageID, message.MessageText, message.MessageType,
+		time.Now(), telegramID).Error; err != nil {
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+	return nil
+}
+
+// SaveOutgoing stores a message sent to a user by an operator.
+func (s *MessageService) SaveOutgoing(ctx context.Context, message *database.Message) error {
+	message.Direction = database.MessageOutgoing
+	if err := s.db.WithContext(ctx).Create(message).Error; err != nil {
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+	return nil
+}
+
+// SaveOutgoingTo stores a message sent to a Telegram user by an operator, looking the user up by Telegram ID.
+func (s *MessageService) SaveOutgoingTo(ctx context.Context, telegramID int64, message database.Message) error {
+	var user database.User
+	if err := s.db.WithContext(ctx).Select("id").Where("telegram_id = ?", telegramID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	message.UserID = user.ID
+	return s.SaveOutgoing(ctx, &message)
+}
+
+// MessageFilter selects messages for List. Zero values don't filter.
+type MessageFilter struct {
+	UserID    uint64
+	Query     string   // full-text search, web search syntax: words, "phrases", -excluded
+	Types     []string // message types, e.g. text, photo, command
+	Direction string   // database.MessageIncoming or database.MessageOutgoing
+	From      *time.Time
+	To        *time.Time
+	// Cursor continues a previous List call, see MessagePage.NextCursor.
+	Cursor string
+	Limit  int
+	// WithUser preloads the user of each message.
+	WithUser bool
+}
+
+// MessagePage is a page of messages, newest first.
+type MessagePage struct {
+	Messages []database.Message `json:"messages"`
+	// NextCursor fetches the next (older) page, empty when there are no more messages.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// List returns messages matching the filter, newest first. Pagination is keyset-based,
+// so pages don't shift when new messages arrive.
+func (s *MessageService) List(ctx context.Context, filter MessageFilter) (*MessagePage, error) {
+	q := s.db.WithContext(ctx).Model(&database.Message{})
+	if filter.UserID != 0 {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if query := strings.TrimSpace(filter.Query); query != "" {
+		q = q.Where("search_vector @@ websearch_to_tsquery('simple', ?)", query)
+	}
+	if len(filter.Types) > 0 {
+		q = q.Where("message_type IN ?", filter.Types)
+	}
+	if filter.Direction != "" {
+		q = q.Where("direction = ?", filter.Direction)
+	}
+	if filter.From != nil {
+		q = q.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("created_at < ?", *filter.To)
+	}
+	if filter.Cursor != "" {
+		beforeID, err := parseMessageCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("id < ?", beforeID)
+	}
+	if filter.WithUser {
+		q = q.Preload("User")
+	}
+
+	// One extra row tells whether there is a next page.
+	limit := max(filter.Limit, 1)
+	var messages []database.Message
+	if err := q.Order("id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	page := &MessagePage{Messages: messages}
+	if len(messages) > limit {
+		page.Messages = messages[:limit]
+		page.NextCursor = formatMessageCursor(page.Messages[limit-1].ID)
+	}
+	return page, nil
+}
+
+func formatMessageCursor(id uint64) string {
+	return strconv.FormatUint(id, 36)
+}
+
+func parseMessageCursor(cursor string) (uint64, error) {
+	id, err := strconv.ParseUint(cursor, 36, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageCursor(t *testing.T) {
+	for _, id := range []uint64{1, 42, 1 << 40} {
+		id2, err := parseMessageCursor(formatMessageCursor(id))
+		require.NoError(t, err)
+		assert.Equal(t, id, id2)
+	}
+
+	for _, cursor := range []string{"0", "-5", "not a cursor!", "zzzzzzzzzzzzzzzzzzzz"} {
+		_, err := parseMessageCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+	}
+}
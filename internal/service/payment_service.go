@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/xui"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CurrencyStars is the currency code of Telegram Stars. Invoices in Stars are sent without a provider token.
+const CurrencyStars = "XTR"
+
+// bytesPerGB converts plan traffic from gigabytes to bytes, as 3x-ui stores limits in bytes.
+const bytesPerGB = 1024 * 1024 * 1024
+
+// invoicePayloadPrefix marks invoice payloads created by the bot.
+const invoicePayloadPrefix = "sub"
+
+const (
+	// paymentRetryDelay is the minimum age of a pending payment picked up by RetryPending.
+	paymentRetryDelay = time.Minute
+	// paymentRetryBatch limits the number of payments applied by one RetryPending call.
+	paymentRetryBatch = 50
+)
+
+var (
+	// ErrUnknownPlan is returned for an invoice with a plan that is not configured (anymore).
+	ErrUnknownPlan = errors.New("unknown plan")
+	// ErrPriceMismatch is returned when the paid amount or currency doesn't match the plan.
+	ErrPriceMismatch = errors.New("price mismatch")
+	// ErrInvalidPayload is returned for an invoice payload that wasn't created by the bot.
+	ErrInvalidPayload = errors.New("invalid invoice payload")
+	// ErrPaymentNotFound is returned for an unknown payment ID.
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrPaymentApplied is returned when applying a payment that has already extended the client.
+	ErrPaymentApplied = errors.New("payment already applied")
+)
+
+// Plan is a subscription plan that can be bought through Telegram Payments.
+type Plan struct {
+	ID        string
+	Days      int
+	TrafficGB int // 0 adds no traffic
+	Price     int // in the smallest units of the currency (Stars for XTR)
+}
+
+// ParsePlans parses plans from the "id:days:traffic_gb:price" list separated by commas,
+// e.g. "1m:30:100:250,3m:90:300:650". An empty spec means payments are disabled.
+func ParsePlans(spec string) ([]Plan, error) {
+	var plans []Plan
+	seen := make(map[string]bool)
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 4 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid plan %q: expected id:days:traffic_gb:price", item)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("duplicate plan id %q", parts[0])
+		}
+
+		var numbers [3]int
+		for i, part := range parts[1:] {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid plan %q: %q is not a non-negative number", item, part)
+			}
+			numbers[i] = n
+		}
+
+		plan := Plan{ID: parts[0], Days: numbers[0], TrafficGB: numbers[1], Price: numbers[2]}
+		if plan.Price == 0 {
+			return nil, fmt.Errorf("invalid plan %q: price must be positive", item)
+		}
+		if plan.Days == 0 && plan.TrafficGB == 0 {
+			return nil, fmt.Errorf("invalid plan %q: plan must add days or traffic", item)
+		}
+
+		seen[plan.ID] = true
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// PaymentDetails describes a successful payment received from Telegram.
+type PaymentDetails struct {
+	TelegramID              int64
+	Currency                string
+	Amount                  int
+	InvoicePayload          string
+	TelegramPaymentChargeID string
+	ProviderPaymentChargeID string
+}
+
+// PaymentService sells subscription plans and applies them to linked 3x-ui clients.
+type PaymentService struct {
+	db            *gorm.DB
+	xui           ClientManager
+	links         *ClientLinkService
+	plans         []Plan
+	currency      string
+	providerToken string
+	logger        *slog.Logger
+}
+
+// NewPaymentService creates a new PaymentService.
+func NewPaymentService(db *gorm.DB, xui ClientManager, plans []Plan, currency, providerToken string, logger *slog.Logger) *PaymentService {
+	return &PaymentService{
+		db:            db,
+		xui:           xui,
+		links:         NewClientLinkService(db),
+		plans:         plans,
+		currency:      currency,
+		providerToken: providerToken,
+		logger:        logger,
+	}
+}
+
+// Enabled reports whether any plans are configured.
+func (s *PaymentService) Enabled() bool {
+	return len(s.plans) > 0
+}
+
+// Plans returns the configured plans.
+func (s *PaymentService) Plans() []Plan {
+	return s.plans
+}
+
+// Plan returns a plan by ID.
+func (s *PaymentService) Plan(id string) (Plan, bool) {
+	for _, plan := range s.plans {
+		if plan.ID == id {
+			return plan, true
+		}
+	}
+	return Plan{}, false
+}
+
+// Currency returns the currency of invoices.
+func (s *PaymentService) Currency() string {
+	return s.currency
+}
+
+// ProviderToken returns the payment provider token, empty for Telegram Stars.
+func (s *PaymentService) ProviderToken() string {
+	if s.currency == CurrencyStars {
+		return ""
+	}
+	return s.providerToken
+}
+
+// InvoicePayload builds the payload of an invoice for a plan applied to a linked client.
+func (s *PaymentService) InvoicePayload(planID string, linkID uint64) string {
+	return fmt.Sprintf("%s:%s:%d", invoicePayloadPrefix, planID, linkID)
+}
+
+// ValidateCheckout checks a pre-checkout query: the plan exists, the amount and currency
+// match the plan, and the client from the payload is still linked to the payer.
+func (s *PaymentService) ValidateCheckout(ctx context.Context, telegramID int64, payload, currency string, amount int) (Plan, *database.ClientLink, error) {
+	planID, linkID, err := parseInvoicePayload(payload)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+
+	plan, ok := s.Plan(planID)
+	if !ok {
+		return Plan{}, nil, ErrUnknownPlan
+	}
+	if currency != s.currency || amount != plan.Price {
+		return Plan{}, nil, ErrPriceMismatch
+	}
+
+	link, err := s.links.Get(ctx, linkID, telegramID)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	return plan, link, nil
+}
+
+// CompletePayment records a successful payment and extends the linked client.
+// It is idempotent on telegram_payment_charge_id: a repeated payment is returned as recorded
+// and never applied twice. The user has already been charged, so the payment is recorded before
+// it is applied. A payment that can't be applied is returned with the reason: failed when a retry
+// can't help (see permanentPaymentError), pending otherwise, to be applied by RetryPending or
+// ApplyPayment later.
+func (s *PaymentService) CompletePayment(ctx context.Context, details PaymentDetails) (*database.Payment, error) {
+	payment := &database.Payment{
+		TelegramID:              details.TelegramID,
+		Currency:                details.Currency,
+		Amount:                  details.Amount,
+		InvoicePayload:          details.InvoicePayload,
+		TelegramPaymentChargeID: details.TelegramPaymentChargeID,
+		ProviderPaymentChargeID: details.ProviderPaymentChargeID,
+		Status:                  database.PaymentStatusPending,
+	}
+
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(payment)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to record payment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var existing database.Payment
+		if err := s.db.WithContext(ctx).
+			Where("telegram_payment_charge_id = ?", details.TelegramPaymentChargeID).
+			First(&existing).Error; err != nil {
+			return nil, fmt.Errorf("failed to load recorded payment: %w", err)
+		}
+		s.logger.Warn("payment already recorded, skipping", "charge_id", details.TelegramPaymentChargeID, "status", existing.Status)
+		return &existing, nil
+	}
+
+	return s.ApplyPayment(ctx, payment.ID)
+}
+
+// ApplyPayment extends the client of a recorded payment that hasn't been applied yet: a pending
+// payment after a temporary failure, or a failed one after its cause was fixed, e.g. the client
+// was linked again. The payment row is locked while it's applied, so concurrent attempts never
+// extend the client twice. ErrPaymentApplied is returned for an already applied payment.
+func (s *PaymentService) ApplyPayment(ctx context.Context, id uint64) (*database.Payment, error) {
+	var payment database.Payment
+	// Продление в 3x-ui нельзя откатить, поэтому транзакция не прерывается отменой контекста:
+	// иначе выполненное продление осталось бы без отметки о применении.
+	err := s.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return fmt.Errorf("failed to load payment: %w", err)
+		}
+		if payment.Status == database.PaymentStatusApplied {
+			return ErrPaymentApplied
+		}
+
+		s.apply(ctx, &payment)
+		if err := tx.Model(&payment).
+			Select("client_email", "plan_id", "days", "traffic_gb", "status", "error", "applied_at").
+			Updates(&payment).Error; err != nil {
+			s.logger.Error("failed to update payment status", "error", err, "payment_id", payment.ID, "status", payment.Status)
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// RetryPending applies pending payments, oldest first, and returns the ones that were applied.
+// Payments younger than paymentRetryDelay are skipped, as CompletePayment is still applying them.
+func (s *PaymentService) RetryPending(ctx context.Context) ([]database.Payment, error) {
+	var ids []uint64
+	if err := s.db.WithContext(ctx).Model(&database.Payment{}).
+		Where("status = ? AND created_at < ?", database.PaymentStatusPending, time.Now().Add(-paymentRetryDelay)).
+		Order("id").
+		Limit(paymentRetryBatch).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pending payments: %w", err)
+	}
+
+	var applied []database.Payment
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return applied, err
+		}
+		payment, err := s.ApplyPayment(ctx, id)
+		if errors.Is(err, ErrPaymentApplied) {
+			continue
+		}
+		if err != nil {
+			return applied, err
+		}
+		if payment.Status == database.PaymentStatusApplied {
+			applied = append(applied, *payment)
+		}
+	}
+	return applied, nil
+}
+
+// PaymentFilter selects payments for the admin API.
+type PaymentFilter struct {
+	Status     string // empty means any status
+	TelegramID int64  // 0 means any user
+	Limit      int
+	Offset     int
+}
+
+// List returns payments matching the filter, newest first, and the total number of matches.
+func (s *PaymentService) List(ctx context.Context, filter PaymentFilter) ([]database.Payment, int64, error) {
+	query := s.db.WithContext(ctx).Model(&database.Payment{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.TelegramID != 0 {
+		query = query.Where("telegram_id = ?", filter.TelegramID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count payments: %w", err)
+	}
+
+	var payments []database.Payment
+	if err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&payments).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list payments: %w", err)
+	}
+	return payments, total, nil
+}
+
+// apply resolves the plan and the client of a payment and extends the client. The outcome is
+// stored in the payment: applied, failed for an error a retry can't fix, pending otherwise.
+func (s *PaymentService) apply(ctx context.Context, payment *database.Payment) {
+	// Цены могли измениться после выставления счета, поэтому сверяем только
+	// существование плана, а сумма в платеже остается фактической.
+	plan, link, err := s.resolvePayment(ctx, payment)
+	if err == nil {
+		_, err = s.xui.ExtendClient(ctx, link.Email, plan.Days, int64(plan.TrafficGB)*bytesPerGB)
+	}
+	if err == nil {
+		now := time.Now()
+		payment.Status = database.PaymentStatusApplied
+		payment.Error = ""
+		payment.AppliedAt = &now
+		return
+	}
+
+	payment.Status = database.PaymentStatusPending
+	if permanentPaymentError(err) {
+		payment.Status = database.PaymentStatusFailed
+	}
+	payment.Error = err.Error()
+	s.logger.Error("failed to apply payment", "error", err, "payment_id", payment.ID,
+		"charge_id", payment.TelegramPaymentChargeID, "status", payment.Status)
+}
+
+// permanentPaymentError reports whether a payment can't be applied by retrying it: the invoice
+// or its plan is invalid, or the client is no longer linked or present in 3x-ui. Other errors,
+// e.g. the database or 3x-ui being unavailable, are temporary.
+func permanentPaymentError(err error) bool {
+	return errors.Is(err, ErrInvalidPayload) ||
+		errors.Is(err, ErrUnknownPlan) ||
+		errors.Is(err, ErrClientNotLinked) ||
+		errors.Is(err, xui.ErrClientNotFound)
+}
+
+// resolvePayment finds the plan and the client link of a paid invoice and fills in what it
+// found in the payment, so that a failed payment keeps as much as is known.
+func (s *PaymentService) resolvePayment(ctx context.Context, payment *database.Payment) (Plan, *database.ClientLink, error) {
+	planID, linkID, err := parseInvoicePayload(payment.InvoicePayload)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	payment.PlanID = planID
+
+	plan, ok := s.Plan(planID)
+	if !ok {
+		return Plan{}, nil, ErrUnknownPlan
+	}
+	payment.Days = plan.Days
+	payment.TrafficGB = plan.TrafficGB
+
+	link, err := s.links.Get(ctx, linkID, payment.TelegramID)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	payment.ClientEmail = link.Email
+	return plan, link, nil
+}
+
+// parseInvoicePayload extracts the plan and link IDs from an invoice payload.
+func parseInvoicePayload(payload string) (string, uint64, error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != invoicePayloadPrefix || parts[1] == "" {
+		return "", 0, ErrInvalidPayload
+	}
+	linkID, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidPayload
+	}
+	return parts[1], linkID, nil
+}
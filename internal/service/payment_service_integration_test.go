@@ -0,0 +1,137 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-bot/internal/config"
+	"go-bot/internal/database"
+	"go-bot/internal/xui"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyClientManager fails ExtendClient with the queued errors, then succeeds.
+type flakyClientManager struct {
+	ClientManager
+	errs    []error
+	extends int
+}
+
+func (m *flakyClientManager) ExtendClient(ctx context.Context, email string, days int, extraBytes int64) (*xui.InboundClient, error) {
+	m.extends++
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return nil, err
+	}
+	return &xui.InboundClient{Email: email}, nil
+}
+
+// TestCompletePayment_TransientExtendError_Integration проверяет, что временная ошибка 3x-ui
+// оставляет оплаченный платеж в статусе pending, а повтор применяет его ровно один раз.
+// Нужны переменные DB_* в deploy/.env и примененные миграции.
+func TestCompletePayment_TransientExtendError_Integration(t *testing.T) {
+	err := godotenv.Load("../../deploy/.env")
+	require.NoError(t, err, "Failed to load .env file")
+	cfg, err := config.Load()
+	require.NoError(t, err, "Failed to load .env config")
+
+	db := database.Init(cfg)
+	t.Cleanup(func() { database.Close(db) })
+
+	suffix := time.Now().UnixNano()
+	telegramID := 920000000 + suffix%1000000
+	email := fmt.Sprintf("it-pay-%d@example.com", suffix)
+	chargeID := fmt.Sprintf("it-charge-%d", suffix)
+	t.Cleanup(func() {
+		db.Where("telegram_payment_charge_id = ?", chargeID).Delete(&database.Payment{})
+		db.Where("email = ?", email).Delete(&database.ClientLink{})
+	})
+
+	link, err := NewClientLinkService(db).Create(context.Background(), telegramID, email, 1)
+	require.NoError(t, err)
+
+	manager := &flakyClientManager{errs: []error{fmt.Errorf("XUIService error: %w", errors.New("connection refused"))}}
+	plans := []Plan{{ID: "1m", Days: 30, TrafficGB: 100, Price: 250}}
+	s := NewPaymentService(db, manager, plans, CurrencyStars, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	details := PaymentDetails{
+		TelegramID:              telegramID,
+		Currency:                CurrencyStars,
+		Amount:                  250,
+		InvoicePayload:          s.InvoicePayload("1m", link.ID),
+		TelegramPaymentChargeID: chargeID,
+	}
+
+	// --- Act & Assert ---
+	payment, err := s.CompletePayment(context.Background(), details)
+	require.NoError(t, err)
+	assert.Equal(t, database.PaymentStatusPending, payment.Status)
+	assert.Contains(t, payment.Error, "connection refused")
+	assert.Equal(t, email, payment.ClientEmail)
+
+	// Повторная доставка того же платежа не применяет его
+	repeated, err := s.CompletePayment(context.Background(), details)
+	require.NoError(t, err)
+	assert.Equal(t, database.PaymentStatusPending, repeated.Status)
+	assert.Equal(t, 1, manager.extends)
+
+	applied, err := s.ApplyPayment(context.Background(), payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.PaymentStatusApplied, applied.Status)
+	assert.Empty(t, applied.Error)
+	assert.NotNil(t, applied.AppliedAt)
+	assert.Equal(t, 2, manager.extends)
+
+	_, err = s.ApplyPayment(context.Background(), payment.ID)
+	assert.ErrorIs(t, err, ErrPaymentApplied)
+	assert.Equal(t, 2, manager.extends, "An applied payment must not extend the client again")
+
+	var stored database.Payment
+	require.NoError(t, db.First(&stored, payment.ID).Error)
+	assert.Equal(t, database.PaymentStatusApplied, stored.Status)
+	assert.Equal(t, details.InvoicePayload, stored.InvoicePayload)
+}
+
+// TestCompletePayment_UnlinkedClient_Integration проверяет, что платеж за отвязанного клиента
+// сразу получает статус failed: повтор ему не поможет.
+func TestCompletePayment_UnlinkedClient_Integration(t *testing.T) {
+	err := godotenv.Load("../../deploy/.env")
+	require.NoError(t, err, "Failed to load .env file")
+	cfg, err := config.Load()
+	require.NoError(t, err, "Failed to load .env config")
+
+	db := database.Init(cfg)
+	t.Cleanup(func() { database.Close(db) })
+
+	chargeID := fmt.Sprintf("it-charge-%d", time.Now().UnixNano())
+	t.Cleanup(func() { db.Where("telegram_payment_charge_id = ?", chargeID).Delete(&database.Payment{}) })
+
+	manager := &flakyClientManager{}
+	plans := []Plan{{ID: "1m", Days: 30, TrafficGB: 100, Price: 250}}
+	s := NewPaymentService(db, manager, plans, CurrencyStars, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// --- Act ---
+	payment, err := s.CompletePayment(context.Background(), PaymentDetails{
+		TelegramID:              930000001,
+		Currency:                CurrencyStars,
+		Amount:                  250,
+		InvoicePayload:          s.InvoicePayload("1m", 1<<62),
+		TelegramPaymentChargeID: chargeID,
+	})
+
+	// --- Assert ---
+	require.NoError(t, err)
+	assert.Equal(t, database.PaymentStatusFailed, payment.Status)
+	assert.Equal(t, ErrClientNotLinked.Error(), payment.Error)
+	assert.Zero(t, manager.extends)
+}
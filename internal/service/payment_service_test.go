@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/xui"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlans(t *testing.T) {
+	testCases := []struct {
+		name        string
+		spec        string
+		expected    []Plan
+		expectError bool
+	}{
+		{
+			name:     "Empty Spec Disables Payments",
+			spec:     "",
+			expected: nil,
+		},
+		{
+			name: "Multiple Plans",
+			spec: "1m:30:100:250, 3m:90:0:650",
+			expected: []Plan{
+				{ID: "1m", Days: 30, TrafficGB: 100, Price: 250},
+				{ID: "3m", Days: 90, TrafficGB: 0, Price: 650},
+			},
+		},
+		{
+			name:     "Traffic Only Plan",
+			spec:     "extra50:0:50:100",
+			expected: []Plan{{ID: "extra50", Days: 0, TrafficGB: 50, Price: 100}},
+		},
+		{name: "Missing Field", spec: "1m:30:250", expectError: true},
+		{name: "Negative Number", spec: "1m:-30:100:250", expectError: true},
+		{name: "Zero Price", spec: "free:30:100:0", expectError: true},
+		{name: "Nothing Added", spec: "empty:0:0:100", expectError: true},
+		{name: "Duplicate ID", spec: "1m:30:100:250,1m:60:100:450", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plans, err := ParsePlans(tc.spec)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, plans)
+		})
+	}
+}
+
+func TestParseInvoicePayload(t *testing.T) {
+	s := &PaymentService{}
+	planID, linkID, err := parseInvoicePayload(s.InvoicePayload("3m", 42))
+	require.NoError(t, err)
+	assert.Equal(t, "3m", planID)
+	assert.Equal(t, uint64(42), linkID)
+
+	for _, payload := range []string{"", "sub:3m", "other:3m:42", "sub::42", "sub:3m:abc"} {
+		_, _, err := parseInvoicePayload(payload)
+		assert.ErrorIs(t, err, ErrInvalidPayload, "payload %q", payload)
+	}
+}
+
+func TestResolvePayment_KeepsWhatIsKnown(t *testing.T) {
+	s := &PaymentService{plans: []Plan{{ID: "1m", Days: 30, TrafficGB: 100, Price: 250}}}
+
+	// План удален после выставления счета: платеж записывается с ID плана из счета
+	payment := database.Payment{InvoicePayload: s.InvoicePayload("3m", 42)}
+	_, _, err := s.resolvePayment(context.Background(), &payment)
+	assert.ErrorIs(t, err, ErrUnknownPlan)
+	assert.Equal(t, "3m", payment.PlanID)
+	assert.Zero(t, payment.Days)
+
+	payment = database.Payment{InvoicePayload: "other"}
+	_, _, err = s.resolvePayment(context.Background(), &payment)
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+	assert.Empty(t, payment.PlanID)
+}
+
+func TestPermanentPaymentError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{name: "Invalid Payload", err: ErrInvalidPayload, permanent: true},
+		{name: "Unknown Plan", err: ErrUnknownPlan, permanent: true},
+		{name: "Client Not Linked", err: ErrClientNotLinked, permanent: true},
+		{name: "Client Deleted From Panel", err: fmt.Errorf("XUIService error: %w: a@b.c", xui.ErrClientNotFound), permanent: true},
+		{name: "Panel Unavailable", err: fmt.Errorf("XUIService error: %w", errors.New("connection refused"))},
+		{name: "Database Unavailable", err: fmt.Errorf("failed to get client link: %w", errors.New("connection reset"))},
+		{name: "Context Canceled", err: context.Canceled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.permanent, permanentPaymentError(tc.err))
+		})
+	}
+}
+
+func TestExtendLimits(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := (24 * time.Hour).Milliseconds()
+	gb := int64(bytesPerGB)
+
+	testCases := []struct {
+		name           string
+		expiry, total  int64
+		days           int
+		extraBytes     int64
+		expectedExpiry int64
+		expectedTotal  int64
+	}{
+		{
+			name:           "Active Client Extends From Expiry",
+			expiry:         now.UnixMilli() + 5*day,
+			total:          100 * gb,
+			days:           30,
+			extraBytes:     50 * gb,
+			expectedExpiry: now.UnixMilli() + 35*day,
+			expectedTotal:  150 * gb,
+		},
+		{
+			name:           "Expired Client Extends From Now",
+			expiry:         now.UnixMilli() - 10*day,
+			total:          100 * gb,
+			days:           30,
+			expectedExpiry: now.UnixMilli() + 30*day,
+			expectedTotal:  100 * gb,
+		},
+		{
+			name:           "Never Expiring And Unlimited Stay Unchanged",
+			expiry:         0,
+			total:          0,
+			days:           30,
+			extraBytes:     50 * gb,
+			expectedExpiry: 0,
+			expectedTotal:  0,
+		},
+		{
+			name:           "Delayed Start Duration Grows",
+			expiry:         -7 * day,
+			total:          10 * gb,
+			days:           30,
+			expectedExpiry: -37 * day,
+			expectedTotal:  10 * gb,
+		},
+		{
+			name:           "Traffic Only Keeps Expired Date",
+			expiry:         now.UnixMilli() - day,
+			total:          10 * gb,
+			days:           0,
+			extraBytes:     5 * gb,
+			expectedExpiry: now.UnixMilli() - day,
+			expectedTotal:  15 * gb,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expiry, total := ExtendLimits(tc.expiry, tc.total, tc.days, tc.extraBytes, now)
+			assert.Equal(t, tc.expectedExpiry, expiry)
+			assert.Equal(t, tc.expectedTotal, total)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// referralPayloadPrefix marks /start deep link payloads of referral links.
+const referralPayloadPrefix = "ref_"
+
+// ErrSelfReferral is returned when a user follows their own referral link.
+var ErrSelfReferral = errors.New("user can't refer themselves")
+
+// ReferralStats summarizes the invitations of a user.
+type ReferralStats struct {
+	Invited        int64
+	Rewarded       int64
+	BonusDays      int64
+	BonusTrafficGB int64
+}
+
+// ReferralNode is a user in the referral tree with the users they invited.
+type ReferralNode struct {
+	TelegramID int64           `json:"telegram_id"`
+	Username   string          `json:"username,omitempty"`
+	InvitedAt  *time.Time      `json:"invited_at,omitempty"`
+	RewardedAt *time.Time      `json:"rewarded_at,omitempty"`
+	Referred   []*ReferralNode `json:"referred,omitempty"`
+}
+
+// ReferralService tracks referrals and rewards referrers on their 3x-ui clients.
+type ReferralService struct {
+	db     *gorm.DB
+	xui    ClientManager
+	links  *ClientLinkService
+	logger *slog.Logger
+}
+
+// NewReferralService creates a new ReferralService.
+func NewReferralService(db *gorm.DB, xui ClientManager, logger *slog.Logger) *ReferralService {
+	return &ReferralService{
+		db:     db,
+		xui:    xui,
+		links:  NewClientLinkService(db),
+		logger: logger,
+	}
+}
+
+// ReferralPayload returns the /start payload of a user's referral link.
+func ReferralPayload(telegramID int64) string {
+	return referralPayloadPrefix + strconv.FormatInt(telegramID, 10)
+}
+
+// ParseReferralPayload extracts the referrer ID from a /start payload.
+func ParseReferralPayload(payload string) (int64, bool) {
+	raw, ok := strings.CutPrefix(strings.TrimSpace(payload), referralPayloadPrefix)
+	if !ok {
+		return 0, false
+	}
+	referrerID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || referrerID <= 0 {
+		return 0, false
+	}
+	return referrerID, true
+}
+
+// Record stores that referredID was invited by referrerID. Referrals of unknown
+// referrers are ignored, and a user can be referred only once.
+func (s *ReferralService) Record(ctx context.Context, referrerID, referredID int64) error {
+	if referrerID == referredID {
+		return ErrSelfReferral
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&database.User{}).Where("telegram_id = ?", referrerID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check referrer: %w", err)
+	}
+	if count == 0 {
+		s.logger.Warn("referral from unknown user ignored", "referrer_id", referrerID, "referred_id", referredID)
+		return nil
+	}
+
+	referral := &database.Referral{ReferrerID: referrerID, ReferredID: referredID}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(referral).Error; err != nil {
+		return fmt.Errorf("failed to record referral: %w", err)
+	}
+	return nil
+}
+
+// Reward credits the referrer of a user after the user activated a trial or paid.
+// The reward is given at most once per referred user and only for events enabled in the settings.
+// If the referrer has no linked client yet, the reward is left for the next event of the user.
+func (s *ReferralService) Reward(ctx context.Context, referredID int64, event string) error {
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled || (settings.RewardDays == 0 && settings.RewardTrafficGB == 0) {
+		return nil
+	}
+	if (event == database.ReferralEventTrial && !settings.RewardOnTrial) ||
+		(event == database.ReferralEventPayment && !settings.RewardOnPayment) {
+		return nil
+	}
+
+	// Claim the reward before extending, so concurrent events can't grant it twice.
+	var referral database.Referral
+	result := s.db.WithContext(ctx).Model(&referral).
+		Clauses(clause.Returning{}).
+		Where("referred_id = ? AND rewarded_at IS NULL", referredID).
+		Updates(map[string]interface{}{
+			"rewarded_at":       time.Now(),
+			"reward_event":      event,
+			"reward_days":       settings.RewardDays,
+			"reward_traffic_gb": settings.RewardTrafficGB,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to claim referral reward: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	rewardErr := s.applyReward(ctx, referral.ReferrerID, settings)
+	if errors.Is(rewardErr, ErrClientNotLinked) {
+		// The referrer has nothing to extend yet: release the claim so that a later event
+		// grants the reward instead of losing it.
+		s.releaseReward(ctx, referral.ID)
+		s.logger.Info("referral reward postponed, referrer has no client", "referrer_id", referral.ReferrerID, "referred_id", referredID)
+		return nil
+	}
+	if rewardErr != nil {
+		s.logger.Error("failed to reward referrer", "error", rewardErr, "referrer_id", referral.ReferrerID, "referred_id", referredID)
+		if err := s.db.WithContext(context.WithoutCancel(ctx)).Model(&referral).
+			Update("reward_error", rewardErr.Error()).Error; err != nil {
+			s.logger.Error("failed to save referral reward error", "error", err, "referral_id", referral.ID)
+		}
+		return rewardErr
+	}
+
+	s.logger.Info("referrer rewarded", "referrer_id", referral.ReferrerID, "referred_id", referredID, "event", event,
+		"days", settings.RewardDays, "traffic_gb", settings.RewardTrafficGB)
+	return nil
+}
+
+// releaseReward undoes the claim of a reward that couldn't be applied, so that it can be claimed again.
+func (s *ReferralService) releaseReward(ctx context.Context, referralID uint64) {
+	err := s.db.WithContext(context.WithoutCancel(ctx)).Model(&database.Referral{}).
+		Where("id = ?", referralID).
+		Updates(map[string]interface{}{
+			"rewarded_at":       nil,
+			"reward_event":      nil,
+			"reward_days":       0,
+			"reward_traffic_gb": 0,
+		}).Error
+	if err != nil {
+		s.logger.Error("failed to release referral reward", "error", err, "referral_id", referralID)
+	}
+}
+
+// applyReward extends the latest client linked to the referrer.
+func (s *ReferralService) applyReward(ctx context.Context, referrerID int64, settings *database.ReferralSettings) error {
+	link, err := s.links.Latest(ctx, referrerID)
+	if err != nil {
+		return err
+	}
+	_, err = s.xui.ExtendClient(ctx, link.Email, settings.RewardDays, int64(settings.RewardTrafficGB)*bytesPerGB)
+	return err
+}
+
+// Stats returns the invitation statistics of a user.
+func (s *ReferralService) Stats(ctx context.Context, referrerID int64) (*ReferralStats, error) {
+	var stats ReferralStats
+	err := s.db.WithContext(ctx).Model(&database.Referral{}).
+		Select(`COUNT(*) AS invited,
+			COUNT(rewarded_at) FILTER (WHERE reward_error IS NULL) AS rewarded,
+			COALESCE(SUM(reward_days) FILTER (WHERE rewarded_at IS NOT NULL AND reward_error IS NULL), 0) AS bonus_days,
+			COALESCE(SUM(reward_traffic_gb) FILTER (WHERE rewarded_at IS NOT NULL AND reward_error IS NULL), 0) AS bonus_traffic_gb`).
+		Where("referrer_id = ?", referrerID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// Settings returns the referral program settings.
+func (s *ReferralService) Settings(ctx context.Context) (*database.ReferralSettings, error) {
+	var settings database.ReferralSettings
+	if err := s.db.WithContext(ctx).First(&settings, 1).Error; err != nil {
+		return nil, fmt.Errorf("failed to get referral settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// UpdateSettings replaces the referral program settings.
+func (s *ReferralService) UpdateSettings(ctx context.Context, settings database.ReferralSettings) (*database.ReferralSettings, error) {
+	settings.ID = 1
+	settings.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Save(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to update referral settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// Tree returns the referral tree. With rootID 0 the whole forest is returned: every
+// user who invited someone but wasn't invited themselves is a root.
+func (s *ReferralService) Tree(ctx context.Context, rootID int64) ([]*ReferralNode, error) {
+	var rows []struct {
+		database.Referral
+		Username string
+	}
+	if err := s.db.WithContext(ctx).Model(&database.Referral{}).
+		Select("referrals.*, COALESCE(users.username, '') AS username").
+		Joins("LEFT JOIN users ON users.telegram_id = referrals.referred_id").
+		Order("referrals.created_at, referrals.id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get referrals: %w", err)
+	}
+
+	nodes := make(map[int64]*ReferralNode)
+	node := func(telegramID int64) *ReferralNode {
+		n, ok := nodes[telegramID]
+		if !ok {
+			n = &ReferralNode{TelegramID: telegramID}
+			nodes[telegramID] = n
+		}
+		return n
+	}
+
+	referred := make(map[int64]bool)
+	var referrers []int64
+	for _, row := range rows {
+		child := node(row.ReferredID)
+		child.Username = row.Username
+		child.InvitedAt = &row.CreatedAt
+		child.RewardedAt = row.RewardedAt
+		referred[row.ReferredID] = true
+
+		parent, seen := nodes[row.ReferrerID]
+		if !seen {
+			parent = node(row.ReferrerID)
+			referrers = append(referrers, row.ReferrerID)
+		}
+		parent.Referred = append(parent.Referred, child)
+	}
+
+	if rootID != 0 {
+		root, ok := nodes[rootID]
+		if !ok {
+			return []*ReferralNode{}, nil
+		}
+		if err := s.fillUsernames(ctx, []*ReferralNode{root}); err != nil {
+			return nil, err
+		}
+		return []*ReferralNode{root}, nil
+	}
+
+	roots := []*ReferralNode{}
+	for _, id := range referrers {
+		if !referred[id] {
+			roots = append(roots, nodes[id])
+		}
+	}
+	if err := s.fillUsernames(ctx, roots); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// fillUsernames sets usernames of root nodes, which are not loaded with the referrals.
+func (s *ReferralService) fillUsernames(ctx context.Context, roots []*ReferralNode) error {
+	if len(roots) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(roots))
+	for _, root := range roots {
+		ids = append(ids, root.TelegramID)
+	}
+
+	var users []database.User
+	if err := s.db.WithContext(ctx).Select("telegram_id", "username").Where("telegram_id IN ?", ids).Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to get referrers: %w", err)
+	}
+	usernames := make(map[int64]string, len(users))
+	for _, user := range users {
+		usernames[user.TelegramID] = user.Username
+	}
+	for _, root := range roots {
+		root.Username = usernames[root.TelegramID]
+	}
+	return nil
+}
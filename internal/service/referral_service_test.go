@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReferralPayload(t *testing.T) {
+	testCases := []struct {
+		payload    string
+		expectedID int64
+		expectedOK bool
+	}{
+		{payload: ReferralPayload(123456789), expectedID: 123456789, expectedOK: true},
+		{payload: " ref_42 ", expectedID: 42, expectedOK: true},
+		{payload: "", expectedOK: false},
+		{payload: "ref_", expectedOK: false},
+		{payload: "ref_abc", expectedOK: false},
+		{payload: "ref_-5", expectedOK: false},
+		{payload: "promo_42", expectedOK: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.payload, func(t *testing.T) {
+			id, ok := ParseReferralPayload(tc.payload)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedID, id)
+		})
+	}
+}
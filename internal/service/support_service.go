@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTicketNotFound is returned when a ticket doesn't exist.
+var ErrTicketNotFound = errors.New("ticket not found")
+
+// TicketFilter selects tickets for listing.
+type TicketFilter struct {
+	Status     string // empty means any status
+	TelegramID int64  // 0 means any user
+	Limit      int
+	Offset     int
+}
+
+// SupportService stores support tickets relayed between users and the operator chat.
+type SupportService struct {
+	db *gorm.DB
+}
+
+// NewSupportService creates a new SupportService.
+func NewSupportService(db *gorm.DB) *SupportService {
+	return &SupportService{db: db}
+}
+
+// OpenTicket returns the open ticket of a user or opens a new one.
+// The second result reports whether the ticket was just opened.
+func (s *SupportService) OpenTicket(ctx context.Context, telegramID int64) (*database.Ticket, bool, error) {
+	ticket := &database.Ticket{TelegramID: telegramID, Status: database.TicketStatusOpen}
+	// The partial unique index allows a single open ticket per user, so concurrent
+	// messages of the same user end up in the same ticket.
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "telegram_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "status", Value: database.TicketStatusOpen}}},
+		DoNothing:   true,
+	}).Create(ticket)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("failed to open ticket: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return ticket, true, nil
+	}
+
+	var existing database.Ticket
+	if err := s.db.WithContext(ctx).
+		Where("telegram_id = ? AND status = ?", telegramID, database.TicketStatusOpen).
+		First(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to get open ticket: %w", err)
+	}
+	return &existing, false, nil
+}
+
+// AddMessage stores a message of a ticket and bumps the ticket's update time.
+func (s *SupportService) AddMessage(ctx context.Context, message *database.TicketMessage) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return fmt.Errorf("failed to save ticket message: %w", err)
+		}
+		if err := tx.Model(&database.Ticket{ID: message.TicketID}).Update("updated_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("failed to update ticket: %w", err)
+		}
+		return nil
+	})
+}
+
+// FindBySupportMessage returns the ticket a message in the operator chat belongs to.
+func (s *SupportService) FindBySupportMessage(ctx context.Context, supportMessageID int) (*database.Ticket, error) {
+	var ticket database.Ticket
+	err := s.db.WithContext(ctx).
+		Joins("JOIN ticket_messages ON ticket_messages.ticket_id = tickets.id").
+		Where("ticket_messages.support_message_id = ?", supportMessageID).
+		Order("tickets.id DESC").
+		First(&ticket).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, fmt.Errorf("failed to find ticket: %w", err)
+	}
+	return &ticket, nil
+}
+
+// FindIncomingMessage returns the relayed message of a user by its ID in the user's chat.
+func (s *SupportService) FindIncomingMessage(ctx context.Context, telegramID int64, userMessageID int) (*database.TicketMessage, error) {
+	var message database.TicketMessage
+	err := s.db.WithContext(ctx).
+		Joins("JOIN tickets ON tickets.id = ticket_messages.ticket_id").
+		Where("tickets.telegram_id = ? AND ticket_messages.user_message_id = ? AND ticket_messages.direction = ?",
+			telegramID, userMessageID, database.TicketMessageIncoming).
+		Order("ticket_messages.id DESC").
+		First(&message).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, fmt.Errorf("failed to find ticket message: %w", err)
+	}
+	return &message, nil
+}
+
+// Close closes a ticket. Closing an already closed ticket is not an error.
+func (s *SupportService) Close(ctx context.Context, ticketID uint64) (*database.Ticket, error) {
+	ticket, err := s.Get(ctx, ticketID, false)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status == database.TicketStatusClosed {
+		return ticket, nil
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(ticket).Updates(map[string]interface{}{
+		"status":    database.TicketStatusClosed,
+		"closed_at": now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to close ticket: %w", err)
+	}
+	ticket.Status = database.TicketStatusClosed
+	ticket.ClosedAt = &now
+	return ticket, nil
+}
+
+// Get returns a ticket by ID, optionally with its messages.
+func (s *SupportService) Get(ctx context.Context, ticketID uint64, withMessages bool) (*database.Ticket, error) {
+	query := s.db.WithContext(ctx)
+	if withMessages {
+		query = query.Preload("Messages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("ticket_messages.created_at, ticket_messages.id")
+		})
+	}
+
+	var ticket database.Ticket
+	if err := query.First(&ticket, ticketID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return &ticket, nil
+}
+
+// List returns tickets matching the filter, most recently updated first, and the total count.
+func (s *SupportService) List(ctx context.Context, filter TicketFilter) ([]database.Ticket, int64, error) {
+	query := s.db.WithContext(ctx).Model(&database.Ticket{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.TelegramID != 0 {
+		query = query.Where("telegram_id = ?", filter.TelegramID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count tickets: %w", err)
+	}
+
+	var tickets []database.Ticket
+	if err := query.Order("updated_at DESC, id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&tickets).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list tickets: %w", err)
+	}
+	return tickets, total, nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/xui"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTrialDisabled is returned when no trial inbound is configured.
+	ErrTrialDisabled = errors.New("trials are disabled")
+	// ErrTrialUsed is returned when the user has already received a trial.
+	ErrTrialUsed = errors.New("trial already used")
+	// ErrTrialNotEligible is returned when the account doesn't pass the abuse checks.
+	ErrTrialNotEligible = errors.New("account is not eligible for a trial")
+	// ErrAlreadySubscribed is returned when the user already has a linked client.
+	ErrAlreadySubscribed = errors.New("user already has a subscription")
+)
+
+// TrialSettings configures trial subscriptions.
+type TrialSettings struct {
+	InboundID int // 0 disables trials
+	Days      int
+	TrafficGB int // 0 means unlimited traffic
+	// MaxTelegramID rejects accounts with a greater ID. Telegram assigns IDs in ascending
+	// order, so this is a cheap account age check against throwaway accounts. 0 disables it.
+	MaxTelegramID int64
+	// SubURL is the base URL of the 3x-ui subscription service, the client subId is appended to it.
+	SubURL string
+}
+
+// TrialResult describes an issued trial subscription.
+type TrialResult struct {
+	Email           string
+	SubscriptionURL string
+	ExpiresAt       time.Time
+	TrafficGB       int
+}
+
+// TrialService issues trial subscriptions, one per Telegram user.
+type TrialService struct {
+	db       *gorm.DB
+	xui      ClientManager
+	links    *ClientLinkService
+	settings TrialSettings
+	logger   *slog.Logger
+}
+
+// NewTrialService creates a new TrialService.
+func NewTrialService(db *gorm.DB, xui ClientManager, settings TrialSettings, logger *slog.Logger) *TrialService {
+	return &TrialService{
+		db:       db,
+		xui:      xui,
+		links:    NewClientLinkService(db),
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// Enabled reports whether trials are configured.
+func (s *TrialService) Enabled() bool {
+	return s.settings.InboundID > 0
+}
+
+// CheckEligibility applies the abuse checks that don't need the database.
+func (s *TrialService) CheckEligibility(telegramID int64) error {
+	if s.settings.MaxTelegramID > 0 && telegramID > s.settings.MaxTelegramID {
+		return ErrTrialNotEligible
+	}
+	return nil
+}
+
+// Start issues a trial to a Telegram user: creates a limited client in the trial inbound
+// and links it to the user. The trial is reserved before the client is created, so
+// concurrent requests of the same user can't receive two trials.
+func (s *TrialService) Start(ctx context.Context, telegramID int64) (*TrialResult, error) {
+	if !s.Enabled() {
+		return nil, ErrTrialDisabled
+	}
+	if err := s.CheckEligibility(telegramID); err != nil {
+		return nil, err
+	}
+
+	links, err := s.links.ForUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) > 0 {
+		return nil, ErrAlreadySubscribed
+	}
+
+	trial := &database.Trial{
+		TelegramID:  telegramID,
+		ClientEmail: trialEmail(telegramID),
+		InboundID:   s.settings.InboundID,
+	}
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(trial)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to reserve trial: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTrialUsed
+	}
+
+	subID, err := randomToken(8)
+	if err != nil {
+		s.releaseTrial(trial)
+		return nil, err
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, s.settings.Days)
+	client, err := s.xui.CreateClient(ctx, s.settings.InboundID, xui.InboundClient{
+		Email:      trial.ClientEmail,
+		TotalGB:    int64(s.settings.TrafficGB) * bytesPerGB,
+		ExpiryTime: expiresAt.UnixMilli(),
+		Enable:     true,
+		SubID:      subID,
+		Comment:    fmt.Sprintf("trial, telegram id %d", telegramID),
+	})
+	if err != nil {
+		// The client wasn't created, release the reservation so the user can try again.
+		s.releaseTrial(trial)
+		return nil, err
+	}
+
+	if _, err := s.links.Create(context.WithoutCancel(ctx), telegramID, client.Email, s.settings.InboundID); err != nil {
+		// The client already exists in 3x-ui, so the link is still returned and the operator links it by hand.
+		s.logger.Error("failed to link trial client", "error", err, "telegram_id", telegramID, "email", client.Email)
+	}
+
+	s.logger.Info("trial issued", "telegram_id", telegramID, "email", client.Email, "expires_at", expiresAt)
+	return &TrialResult{
+		Email:           client.Email,
+		SubscriptionURL: SubscriptionURL(s.settings.SubURL, client.SubID),
+		ExpiresAt:       expiresAt,
+		TrafficGB:       s.settings.TrafficGB,
+	}, nil
+}
+
+// releaseTrial removes a trial reservation after a failed attempt.
+func (s *TrialService) releaseTrial(trial *database.Trial) {
+	if err := s.db.Delete(trial).Error; err != nil {
+		s.logger.Error("failed to release trial reservation", "error", err, "telegram_id", trial.TelegramID)
+	}
+}
+
+// SubscriptionURL builds the subscription link of a client from the base URL and its subId.
+func SubscriptionURL(baseURL, subID string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + subID
+}
+
+// trialEmail returns the 3x-ui client email of a user's trial. 3x-ui requires emails
+// to be unique, so a deterministic name also guards against duplicate trial clients.
+func trialEmail(telegramID int64) string {
+	return fmt.Sprintf("trial-%d", telegramID)
+}
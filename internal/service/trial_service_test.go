@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrialService_CheckEligibility(t *testing.T) {
+	s := NewTrialService(nil, nil, TrialSettings{InboundID: 1, MaxTelegramID: 7_000_000_000}, nil)
+
+	assert.NoError(t, s.CheckEligibility(123456789))
+	assert.NoError(t, s.CheckEligibility(7_000_000_000))
+	assert.ErrorIs(t, s.CheckEligibility(7_000_000_001), ErrTrialNotEligible)
+
+	unlimited := NewTrialService(nil, nil, TrialSettings{InboundID: 1}, nil)
+	assert.NoError(t, unlimited.CheckEligibility(8_000_000_000))
+}
+
+func TestTrialService_StartDisabled(t *testing.T) {
+	s := NewTrialService(nil, nil, TrialSettings{}, nil)
+
+	assert.False(t, s.Enabled())
+	_, err := s.Start(context.Background(), 123456789)
+	assert.ErrorIs(t, err, ErrTrialDisabled)
+}
+
+func TestSubscriptionURL(t *testing.T) {
+	assert.Equal(t, "https://example.com:2096/sub/abc123", SubscriptionURL("https://example.com:2096/sub/", "abc123"))
+	assert.Equal(t, "https://example.com:2096/sub/abc123", SubscriptionURL("https://example.com:2096/sub", "abc123"))
+}
+
+func TestNewUUID(t *testing.T) {
+	id, err := newUUID()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+
+	other, err := newUUID()
+	require.NoError(t, err)
+	assert.NotEqual(t, id, other)
+}
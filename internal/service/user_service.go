@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserNotFound is returned when a user doesn't exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidSort is returned for an unknown sort field.
+	ErrInvalidSort = errors.New("invalid sort")
+)
+
+// UserStats summarizes the users of the bot.
+type UserStats struct {
+	Total    int64
+	NewToday int64
+	Linked   int64
+	Blocked  int64 // users who blocked the bot
+}
+
+// UserService reads the users who talked to the bot.
+type UserService struct {
+	db *gorm.DB
+}
+
+// NewUserService creates a new UserService.
+func NewUserService(db *gorm.DB) *UserService {
+	return &UserService{db: db}
+}
+
+// Stats returns user counts. "Today" starts at midnight of the given time's location.
+func (s *UserService) Stats(ctx context.Context, now time.Time) (*UserStats, error) {
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var stats UserStats
+	if err := s.db.WithContext(ctx).Model(&database.User{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE created_at >= ?) AS new_today,
+			COUNT(*) FILTER (WHERE NOT is_active) AS blocked`, dayStart).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Model(&database.ClientLink{}).
+		Distinct("telegram_id").
+		Count(&stats.Linked).Error; err != nil {
+		return nil, fmt.Errorf("failed to count linked users: %w", err)
+	}
+	return &stats, nil
+}
+
+// SetActive marks a user as active or as having blocked the bot, creating the user if needed.
+// The bot has no broadcasts or reminders yet; when added, they must select only active users,
+// as UserFilter.Active does for the admin API.
+func (s *UserService) SetActive(ctx context.Context, user database.User, active bool, now time.Time) error {
+	var blockedAt *time.Time
+	if !active {
+		blockedAt = &now
+	}
+	// Raw SQL: gorm skips zero-value fields with a default, so is_active = false would not be inserted.
+	if err := s.db.WithContext(ctx).Exec(`INSERT INTO users
+			(telegram_id, username, first_name, last_name, is_active, blocked_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (telegram_id) DO UPDATE SET
+			username = EXCLUDED.username, first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name,
+			is_active = EXCLUDED.is_active, blocked_at = EXCLUDED.blocked_at, updated_at = EXCLUDED.updated_at`,
+		user.TelegramID, user.Username, user.FirstName, user.LastName, active, blockedAt, now, now).Error; err != nil {
+		return fmt.Errorf("failed to update user activity: %w", err)
+	}
+	return nil
+}
+
+// Search returns users whose username or name contains the query, or whose Telegram ID
+// equals it, newest first, and the total number of matches. An empty query matches everyone.
+func (s *UserService) Search(ctx context.Context, query string, limit, offset int) ([]database.User, int64, error) {
+	return s.List(ctx, UserFilter{Query: query, Limit: limit, Offset: offset})
+}
+
+// UserSortFields are the fields users can be sorted by.
+var UserSortFields = []string{"created_at", "updated_at", "telegram_id", "username"}
+
+// UserFilter selects users for List. Zero values don't filter.
+type UserFilter struct {
+	// Query matches the username or name (substring) or the Telegram ID (exact).
+	Query       string
+	Active      *bool
+	Linked      *bool // has linked 3x-ui clients
+	Tag         string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// Sort is one of UserSortFields, "-" prefix sorts descending. Empty means newest first.
+	Sort   string
+	Limit  int
+	Offset int
+}
+
+// List returns users matching the filter and the total number of matches.
+func (s *UserService) List(ctx context.Context, filter UserFilter) ([]database.User, int64, error) {
+	order, err := userOrder(filter.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	q := s.db.WithContext(ctx).Model(&database.User{})
+	if query := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(filter.Query), "@")); query != "" {
+		pattern := "%" + escapeLike(query) + "%"
+		cond := s.db.Where("username ILIKE ? OR first_name ILIKE ? OR last_name ILIKE ?", pattern, pattern, pattern)
+		if id, err := strconv.ParseInt(query, 10, 64); err == nil {
+			cond = cond.Or("telegram_id = ?", id)
+		}
+		q = q.Where(cond)
+	}
+	if filter.Active != nil {
+		q = q.Where("is_active = ?", *filter.Active)
+	}
+	if filter.Linked != nil {
+		exists := "EXISTS (SELECT 1 FROM client_links WHERE client_links.telegram_id = users.telegram_id)"
+		if !*filter.Linked {
+			exists = "NOT " + exists
+		}
+		q = q.Where(exists)
+	}
+	if filter.Tag != "" {
+		q = q.Where("tags @> ?", database.StringList{strings.ToLower(strings.TrimSpace(filter.Tag))})
+	}
+	if filter.CreatedFrom != nil {
+		q = q.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q = q.Where("created_at < ?", *filter.CreatedTo)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	var users []database.User
+	if err := q.Order(order).Limit(filter.Limit).Offset(filter.Offset).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// Get returns a user by ID.
+func (s *UserService) Get(ctx context.Context, id uint64) (*database.User, error) {
+	var user database.User
+	if err := s.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// UserUpdate holds the fields of a user editable by admins. Nil fields are left unchanged.
+type UserUpdate struct {
+	Notes *string
+	Tags  []string
+}
+
+// Update changes the notes and tags of a user. Tags are trimmed, lowercased and deduplicated.
+func (s *UserService) Update(ctx context.Context, id uint64, update UserUpdate) (*database.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{}
+	if update.Notes != nil {
+		changes["notes"] = *update.Notes
+	}
+	if update.Tags != nil {
+		changes["tags"] = NormalizeTags(update.Tags)
+	}
+	if len(changes) == 0 {
+		return user, nil
+	}
+
+	if err := s.db.WithContext(ctx).Model(user).Updates(changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// NormalizeTags trims and lowercases tags, dropping empty ones and duplicates.
+func NormalizeTags(tags []string) database.StringList {
+	normalized := make(database.StringList, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// userOrder converts a sort parameter into an ORDER BY clause.
+func userOrder(sort string) (string, error) {
+	if sort == "" {
+		return "created_at DESC, id DESC", nil
+	}
+	field, direction := sort, "ASC"
+	if strings.HasPrefix(sort, "-") {
+		field, direction = sort[1:], "DESC"
+	}
+	if !slices.Contains(UserSortFields, field) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSort, sort)
+	}
+	return fmt.Sprintf("%s %s, id %s", field, direction, direction), nil
+}
+
+// escapeLike escapes LIKE wildcards in user input.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"go-bot/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOrder(t *testing.T) {
+	order, err := userOrder("")
+	require.NoError(t, err)
+	assert.Equal(t, "created_at DESC, id DESC", order)
+
+	order, err = userOrder("username")
+	require.NoError(t, err)
+	assert.Equal(t, "username ASC, id ASC", order)
+
+	order, err = userOrder("-telegram_id")
+	require.NoError(t, err)
+	assert.Equal(t, "telegram_id DESC, id DESC", order)
+
+	_, err = userOrder("password; DROP TABLE users")
+	assert.ErrorIs(t, err, ErrInvalidSort)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, database.StringList{"vip", "reseller"}, NormalizeTags([]string{" VIP ", "reseller", "", "vip"}))
+	assert.Equal(t, database.StringList{}, NormalizeTags([]string{}))
+}
+
+func TestStringList(t *testing.T) {
+	value, err := database.StringList{"vip", "spam"}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `["vip","spam"]`, value)
+
+	value, err = database.StringList(nil).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "[]", value)
+
+	var list database.StringList
+	require.NoError(t, list.Scan([]byte(`["a","b"]`)))
+	assert.Equal(t, database.StringList{"a", "b"}, list)
+	require.NoError(t, list.Scan(`[]`))
+	assert.Empty(t, list)
+	assert.Error(t, list.Scan(42))
+}
@@ -2,8 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
+	"time"
 
 	"go-bot/internal/config"
 	"go-bot/internal/xui"
@@ -15,10 +21,23 @@ type ClientTrafficProvider interface {
 	GetClientTraffics(ctx context.Context, email string) ([]xui.ClientTraffic, error)
 }
 
+// ClientManager extends ClientTrafficProvider with operations that modify clients.
+type ClientManager interface {
+	ClientTrafficProvider
+	ExtendClient(ctx context.Context, email string, days int, extraBytes int64) (*xui.InboundClient, error)
+	CreateClient(ctx context.Context, inboundID int, client xui.InboundClient) (*xui.InboundClient, error)
+	SearchClients(ctx context.Context, query string, limit int) ([]xui.ClientTraffic, error)
+	ListClientTraffics(ctx context.Context) ([]xui.ClientTraffic, error)
+	SetClientEnabled(ctx context.Context, email string, enable bool) (*xui.InboundClient, error)
+}
+
 // XUIService provides a high-level interface for interacting with the 3x-ui API.
 type XUIService struct {
 	client *xui.Client
 	logger *slog.Logger
+	// writeMu serializes read-modify-write operations on clients, so concurrent
+	// updates of the same client don't overwrite each other.
+	writeMu sync.Mutex
 }
 
 // NewXUIService creates a new XUIService.
@@ -44,3 +63,219 @@ func (s *XUIService) GetClientTraffics(ctx context.Context, email string) ([]xui
 	}
 	return traffics, nil
 }
+
+// ListClientTraffics returns traffic data of all clients across all inbounds.
+func (s *XUIService) ListClientTraffics(ctx context.Context) ([]xui.ClientTraffic, error) {
+	inbounds, err := s.client.ListInbounds(ctx)
+	if err != nil {
+		wrappedErr := fmt.Errorf("XUIService error: %w", err)
+		s.logger.Error("failed to list inbounds from X-UI API", "error", wrappedErr)
+		return nil, wrappedErr
+	}
+
+	var traffics []xui.ClientTraffic
+	for _, inbound := range inbounds {
+		traffics = append(traffics, inbound.ClientStats...)
+	}
+	return traffics, nil
+}
+
+// SearchClients returns traffic data of clients whose email contains the query (case-insensitive)
+// across all inbounds, at most limit entries.
+func (s *XUIService) SearchClients(ctx context.Context, query string, limit int) ([]xui.ClientTraffic, error) {
+	traffics, err := s.ListClientTraffics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	var found []xui.ClientTraffic
+	for _, traffic := range traffics {
+		if !strings.Contains(strings.ToLower(traffic.Email), query) {
+			continue
+		}
+		found = append(found, traffic)
+		if len(found) == limit {
+			break
+		}
+	}
+	return found, nil
+}
+
+// ExtendClient prolongs a client by the given number of days and adds traffic to its limit.
+// The client is enabled again, since 3x-ui disables clients that ran out of time or traffic.
+func (s *XUIService) ExtendClient(ctx context.Context, email string, days int, extraBytes int64) (*xui.InboundClient, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	inbound, client, err := s.findClient(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	client.ExpiryTime, client.TotalGB = ExtendLimits(client.ExpiryTime, client.TotalGB, days, extraBytes, time.Now())
+	client.Enable = true
+
+	if err := s.client.UpdateClient(ctx, inbound.ID, inbound.ClientKey(*client), *client); err != nil {
+		wrappedErr := fmt.Errorf("XUIService error: %w", err)
+		s.logger.Error("failed to update client in X-UI API", "error", wrappedErr, "email", email)
+		return nil, wrappedErr
+	}
+
+	s.logger.Info("client extended", "email", email, "days", days, "extra_bytes", extraBytes,
+		"expiry_time", client.ExpiryTime, "total", client.TotalGB)
+	return client, nil
+}
+
+// SetClientEnabled enables or disables a client without changing its limits.
+func (s *XUIService) SetClientEnabled(ctx context.Context, email string, enable bool) (*xui.InboundClient, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	inbound, client, err := s.findClient(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Enable = enable
+	if err := s.client.UpdateClient(ctx, inbound.ID, inbound.ClientKey(*client), *client); err != nil {
+		wrappedErr := fmt.Errorf("XUIService error: %w", err)
+		s.logger.Error("failed to update client in X-UI API", "error", wrappedErr, "email", email)
+		return nil, wrappedErr
+	}
+
+	s.logger.Info("client enable changed", "email", email, "enable", enable)
+	return client, nil
+}
+
+// CreateClient adds a new client to an inbound. A credential required by the inbound
+// protocol is generated if it is not set: the UUID for vmess/vless and the password for
+// trojan/shadowsocks.
+func (s *XUIService) CreateClient(ctx context.Context, inboundID int, client xui.InboundClient) (*xui.InboundClient, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	inbound, err := s.client.GetInbound(ctx, inboundID)
+	if err != nil {
+		wrappedErr := fmt.Errorf("XUIService error: %w", err)
+		s.logger.Error("failed to get inbound from X-UI API", "error", wrappedErr, "inbound_id", inboundID)
+		return nil, wrappedErr
+	}
+
+	switch inbound.Protocol {
+	case "trojan":
+		if client.Password == "" {
+			if client.Password, err = randomToken(16); err != nil {
+				return nil, err
+			}
+		}
+	case "shadowsocks":
+		// Shadowsocks 2022 expects a base64 key; 32 bytes also work for the classic methods.
+		if client.Password == "" {
+			key := make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("failed to generate client password: %w", err)
+			}
+			client.Password = base64.StdEncoding.EncodeToString(key)
+		}
+	default:
+		if client.ID == "" {
+			if client.ID, err = newUUID(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.client.AddClient(ctx, inboundID, client); err != nil {
+		wrappedErr := fmt.Errorf("XUIService error: %w", err)
+		s.logger.Error("failed to add client in X-UI API", "error", wrappedErr, "email", client.Email, "inbound_id", inboundID)
+		return nil, wrappedErr
+	}
+
+	s.logger.Info("client created", "email", client.Email, "inbound_id", inboundID,
+		"expiry_time", client.ExpiryTime, "total", client.TotalGB)
+	return &client, nil
+}
+
+// GetClient returns the settings of a client by email, e.g. to build its subscription link.
+func (s *XUIService) GetClient(ctx context.Context, email string) (*xui.InboundClient, error) {
+	_, client, err := s.findClient(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// findClient locates the inbound and the settings of a client by email.
+func (s *XUIService) findClient(ctx context.Context, email string) (*xui.Inbound, *xui.InboundClient, error) {
+	traffics, err := s.GetClientTraffics(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inboundID := 0
+	for _, traffic := range traffics {
+		if traffic.Email == email {
+			inboundID = traffic.InboundID
+			break
+		}
+	}
+	if inboundID == 0 {
+		return nil, nil, fmt.Errorf("XUIService error: %w: %s", xui.ErrClientNotFound, email)
+	}
+
+	inbound, err := s.client.GetInbound(ctx, inboundID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("XUIService error: %w", err)
+	}
+
+	client, err := inbound.FindClient(email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("XUIService error: %w: %s", err, email)
+	}
+	return inbound, client, nil
+}
+
+// ExtendLimits calculates new expiry time (Unix ms) and traffic limit (bytes) of a client
+// following 3x-ui semantics:
+//   - expiry 0 means the client never expires and stays unchanged;
+//   - a negative expiry is a duration counted from the first connection, it grows by the given days;
+//   - an expired client is extended from now, an active one from its current expiry;
+//   - a traffic limit of 0 means unlimited and stays unchanged.
+func ExtendLimits(expiryTime, total int64, days int, extraBytes int64, now time.Time) (int64, int64) {
+	extension := int64(days) * (24 * time.Hour).Milliseconds()
+
+	switch {
+	case extension == 0:
+	case expiryTime < 0:
+		expiryTime -= extension
+	case expiryTime > 0:
+		base := max(expiryTime, now.UnixMilli())
+		expiryTime = base + extension
+	}
+
+	if total > 0 {
+		total += extraBytes
+	}
+	return expiryTime, total
+}
+
+// newUUID generates a random (version 4) UUID used as a client ID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate client ID: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// randomToken returns n random bytes encoded as hex, used for passwords and subscription IDs.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
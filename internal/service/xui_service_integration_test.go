@@ -1,3 +1,5 @@
+//go:build integration
+
 package service
 
 import (
@@ -8,6 +10,7 @@ import (
 
 	"go-bot/internal/config"
 
+	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,7 +24,9 @@ func TestXUIService_Integration_GetClientTraffics(t *testing.T) {
 	}
 
 	// Загружаем конфигурацию из .env
-	cfg, err := config.Load("../../deploy/.env")
+	err := godotenv.Load("../../deploy/.env")
+	require.NoError(t, err, "Failed to load .env file")
+	cfg, err := config.Load()
 	require.NoError(t, err, "Failed to load .env config")
 
 	// Инициализируем логгер
@@ -5,19 +5,65 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	"go-bot/internal/auth"
 	"go-bot/internal/database"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const (
+	// SuperadminRole is the built-in role with all permissions. It can't be changed through the API,
+	// so there is always a role able to manage admins.
+	SuperadminRole = auth.SuperadminRole
+
+	// MaxLoginAttempts is the number of consecutive failed logins after which an account is locked.
+	MaxLoginAttempts = 5
+	// LockoutDuration is how long an account stays locked after MaxLoginAttempts failed logins.
+	LockoutDuration = 15 * time.Minute
+)
+
+var (
+	ErrAdminNotFound      = errors.New("admin not found")
+	ErrAdminExists        = errors.New("admin already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrIncorrectPassword  = errors.New("incorrect old password")
+	ErrAccountLocked      = errors.New("account is locked")
+	ErrAccountInactive    = errors.New("account is inactive")
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrBuiltinRole        = errors.New("built-in role can't be changed")
+)
+
+// dummyPasswordHash is compared against when the login doesn't exist, so that unknown
+// and known logins take the same time to reject.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := auth.HashPassword("dummy-password-for-timing")
+	return hash
+})
+
 // AdminServiceInterface defines the contract for admin service operations.
 type AdminServiceInterface interface {
 	Authenticate(ctx context.Context, login, password string) (*database.Admin, error)
-	GetProfile(ctx context.Context, adminID uint) (*database.Admin, error)
-	ChangePassword(ctx context.Context, adminID uint, oldPassword, newPassword string) error
-	CreateAdmin(ctx context.Context, login, password string) (*database.Admin, error)
+	GetProfile(ctx context.Context, adminID uint64) (*database.Admin, error)
+	ChangePassword(ctx context.Context, adminID uint64, oldPassword, newPassword string) error
+	CreateAdmin(ctx context.Context, login, password, role string) (*database.Admin, error)
+	ListAdmins(ctx context.Context) ([]database.Admin, error)
+	SetPassword(ctx context.Context, adminID uint64, password string) error
+	SetRole(ctx context.Context, adminID uint64, role string) error
+	DeactivateAdmin(ctx context.Context, adminID uint64) error
+	ActivateAdmin(ctx context.Context, adminID uint64) error
+	UnlockAdmin(ctx context.Context, adminID uint64) error
+	ListRoles(ctx context.Context) ([]database.Role, error)
+	SaveRole(ctx context.Context, role *database.Role) error
+	SetupTOTP(ctx context.Context, adminID uint64) (*TOTPSetup, error)
+	EnableTOTP(ctx context.Context, adminID uint64, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, adminID uint64, password, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, adminID uint64, password, code string) ([]string, error)
+	VerifyTOTP(ctx context.Context, adminID uint64, code string) (*database.Admin, error)
+	ResetTOTP(ctx context.Context, adminID uint64) error
 }
 
 // AdminService provides operations for admin users.
@@ -35,50 +81,126 @@ func NewAdminService(db *gorm.DB, logger *slog.Logger) AdminServiceInterface {
 }
 
 // Authenticate checks admin credentials and returns the admin if successful.
+// Failed attempts are counted, and after MaxLoginAttempts the account is locked for
+// LockoutDuration: ErrAccountLocked is returned without checking the password.
+// Inactive accounts get ErrAccountInactive, but only with the correct password,
+// so the status of an account isn't disclosed to someone guessing passwords.
+// For an admin with two-factor authentication the login is completed by VerifyTOTP.
 func (s *AdminService) Authenticate(ctx context.Context, login, password string) (*database.Admin, error) {
 	var admin database.Admin
-	if err := s.db.WithContext(ctx).Where("login = ?", login).First(&admin).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invalid credentials")
+	// authErr rejects the login. It is kept apart from the transaction error, since
+	// a failed attempt has to be committed.
+	var authErr error
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row is locked so that concurrent failed attempts are all counted.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Role").Where("login = ?", login).First(&admin).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				auth.CheckPasswordHash(password, dummyPasswordHash())
+				authErr = ErrInvalidCredentials
+				return nil
+			}
+			s.logger.Error("failed to find admin by login", "error", err, "login", login)
+			return err
+		}
+
+		now := time.Now()
+		if admin.LockedUntil != nil && admin.LockedUntil.After(now) {
+			authErr = ErrAccountLocked
+			return nil
 		}
-		s.logger.Error("failed to find admin by login", "error", err, "login", login)
+
+		if !auth.CheckPasswordHash(password, admin.HashedPassword) {
+			authErr = ErrInvalidCredentials
+			return s.recordFailedLogin(tx, &admin, now)
+		}
+
+		if !admin.IsActive {
+			authErr = ErrAccountInactive
+			return nil
+		}
+		// Failed attempts are reset only after the second factor, otherwise someone who knows
+		// the password could guess codes without ever being locked out.
+		if admin.TOTPEnabled {
+			return nil
+		}
+
+		admin.FailedLoginAttempts = 0
+		admin.LockedUntil = nil
+		admin.LastLoginAt = &now
+		if err := tx.Model(&admin).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+			"last_login_at":         now,
+		}).Error; err != nil {
+			s.logger.Error("failed to record login", "error", err, "adminID", admin.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	if !auth.CheckPasswordHash(password, admin.HashedPassword) {
-		return nil, errors.New("invalid credentials")
+	if authErr != nil {
+		return nil, authErr
 	}
 
 	return &admin, nil
 }
 
-// GetProfile retrieves an admin's profile by their ID.
-func (s *AdminService) GetProfile(ctx context.Context, adminID uint) (*database.Admin, error) {
+// recordFailedLogin counts a failed login and locks the account once MaxLoginAttempts is reached.
+func (s *AdminService) recordFailedLogin(tx *gorm.DB, admin *database.Admin, now time.Time) error {
+	attempts := admin.FailedLoginAttempts + 1
+	if admin.LockedUntil != nil {
+		// The previous lockout has expired, counting starts over.
+		attempts = 1
+	}
+
+	var lockedUntil *time.Time
+	if attempts >= MaxLoginAttempts {
+		until := now.Add(LockoutDuration)
+		lockedUntil = &until
+		s.logger.Warn("admin account locked after failed logins", "adminID", admin.ID, "attempts", attempts)
+	}
+
+	if err := tx.Model(admin).Updates(map[string]interface{}{
+		"failed_login_attempts": attempts,
+		"locked_until":          lockedUntil,
+	}).Error; err != nil {
+		s.logger.Error("failed to record failed login", "error", err, "adminID", admin.ID)
+		return err
+	}
+	return nil
+}
+
+// GetProfile retrieves an admin's profile with the role by their ID.
+func (s *AdminService) GetProfile(ctx context.Context, adminID uint64) (*database.Admin, error) {
 	var admin database.Admin
-	if err := s.db.WithContext(ctx).First(&admin, adminID).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Role").First(&admin, adminID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("admin with ID %d not found", adminID)
+			return nil, ErrAdminNotFound
 		}
 		s.logger.Error("failed to find admin by ID", "error", err, "adminID", adminID)
 		return nil, err
 	}
+	resolveRolePermissions(admin.Role)
 	return &admin, nil
 }
 
 // ChangePassword updates an admin's password after verifying the old one.
-func (s *AdminService) ChangePassword(ctx context.Context, adminID uint, oldPassword, newPassword string) error {
+// All sessions of the admin, including the current one, are revoked.
+func (s *AdminService) ChangePassword(ctx context.Context, adminID uint64, oldPassword, newPassword string) error {
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var admin database.Admin
 		if err := tx.First(&admin, adminID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return fmt.Errorf("admin with ID %d not found", adminID)
+				return ErrAdminNotFound
 			}
 			s.logger.Error("failed to find admin by ID in transaction", "error", err, "adminID", adminID)
 			return err
 		}
 
 		if !auth.CheckPasswordHash(oldPassword, admin.HashedPassword) {
-			return errors.New("incorrect old password")
+			return ErrIncorrectPassword
 		}
 
 		hashedPassword, err := auth.HashPassword(newPassword)
@@ -92,12 +214,18 @@ func (s *AdminService) ChangePassword(ctx context.Context, adminID uint, oldPass
 			return errors.New("failed to update password")
 		}
 
-		return nil
+		return revokeSessions(tx, adminID)
 	})
 }
 
-// CreateAdmin creates a new admin user.
-func (s *AdminService) CreateAdmin(ctx context.Context, login, password string) (*database.Admin, error) {
+// CreateAdmin creates a new admin user with the named role. ErrAdminExists is returned if the login
+// is taken, ErrRoleNotFound if there is no such role.
+func (s *AdminService) CreateAdmin(ctx context.Context, login, password, role string) (*database.Admin, error) {
+	adminRole, err := s.findRole(s.db.WithContext(ctx), role)
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
 		return nil, fmt.Errorf("could not hash password: %w", err)
@@ -107,18 +235,153 @@ func (s *AdminService) CreateAdmin(ctx context.Context, login, password string)
 		Login:          login,
 		HashedPassword: hashedPassword,
 		IsActive:       true,
+		RoleID:         adminRole.ID,
 	}
 
-	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(admin).Error; err != nil {
-			return fmt.Errorf("could not create admin: %w", err)
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(admin)
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not create admin: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAdminExists
+	}
+
+	admin.Role = adminRole
+	return admin, nil
+}
+
+// ListAdmins returns all admins with their roles ordered by ID.
+func (s *AdminService) ListAdmins(ctx context.Context) ([]database.Admin, error) {
+	var admins []database.Admin
+	if err := s.db.WithContext(ctx).Preload("Role").Order("id").Find(&admins).Error; err != nil {
+		return nil, fmt.Errorf("could not list admins: %w", err)
+	}
+	for i := range admins {
+		resolveRolePermissions(admins[i].Role)
+	}
+	return admins, nil
+}
+
+// SetPassword replaces an admin's password without checking the old one and revokes all sessions of the admin.
+func (s *AdminService) SetPassword(ctx context.Context, adminID uint64, password string) error {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("could not hash password: %w", err)
+	}
+	return s.updateAdminAndRevokeSessions(ctx, adminID, map[string]interface{}{"hashed_password": hashedPassword})
+}
+
+// SetRole assigns the named role to an admin and revokes its sessions, so that tokens with
+// the old permissions stop working.
+func (s *AdminService) SetRole(ctx context.Context, adminID uint64, role string) error {
+	adminRole, err := s.findRole(s.db.WithContext(ctx), role)
+	if err != nil {
+		return err
+	}
+	return s.updateAdminAndRevokeSessions(ctx, adminID, map[string]interface{}{"role_id": adminRole.ID})
+}
+
+// DeactivateAdmin deactivates an admin account and revokes its sessions. Inactive admins can't log in.
+func (s *AdminService) DeactivateAdmin(ctx context.Context, adminID uint64) error {
+	return s.updateAdminAndRevokeSessions(ctx, adminID, map[string]interface{}{"is_active": false})
+}
+
+// ActivateAdmin activates an admin account.
+func (s *AdminService) ActivateAdmin(ctx context.Context, adminID uint64) error {
+	return s.updateAdmin(ctx, adminID, map[string]interface{}{"is_active": true})
+}
+
+// UnlockAdmin clears the failed login attempts and the lockout of an admin account.
+func (s *AdminService) UnlockAdmin(ctx context.Context, adminID uint64) error {
+	return s.updateAdmin(ctx, adminID, map[string]interface{}{
+		"failed_login_attempts": 0,
+		"locked_until":          nil,
+	})
+}
+
+// ListRoles returns all roles ordered by name.
+func (s *AdminService) ListRoles(ctx context.Context) ([]database.Role, error) {
+	var roles []database.Role
+	if err := s.db.WithContext(ctx).Order("name").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("could not list roles: %w", err)
+	}
+	for i := range roles {
+		resolveRolePermissions(&roles[i])
+	}
+	return roles, nil
+}
+
+// SaveRole creates a role or replaces the description and permissions of the role with the same name.
+// Sessions of admins with the role are revoked, so that tokens with the old permissions stop working.
+func (s *AdminService) SaveRole(ctx context.Context, role *database.Role) error {
+	if role.Name == SuperadminRole {
+		return ErrBuiltinRole
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description", "permissions", "updated_at"}),
+		}).Create(role).Error; err != nil {
+			return fmt.Errorf("could not save role: %w", err)
+		}
+
+		// On conflict the ID isn't returned by every driver, the role is reloaded by name.
+		if err := tx.Where("name = ?", role.Name).First(role).Error; err != nil {
+			return fmt.Errorf("could not get role: %w", err)
+		}
+
+		if err := tx.Model(&database.AdminSession{}).
+			Where("revoked_at IS NULL AND admin_id IN (?)", tx.Model(&database.Admin{}).Select("id").Where("role_id = ?", role.ID)).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("could not revoke sessions: %w", err)
 		}
 		return nil
 	})
+}
 
-	if err != nil {
-		return nil, err
+// resolveRolePermissions replaces the stored permissions of a loaded role with the ones it is
+// granted, so that the superadmin role is shown with all permissions.
+func resolveRolePermissions(role *database.Role) {
+	if role != nil {
+		role.Permissions = auth.RolePermissions(role)
 	}
+}
 
-	return admin, nil
+// findRole returns the role with the given name.
+func (s *AdminService) findRole(db *gorm.DB, name string) (*database.Role, error) {
+	var role database.Role
+	if err := db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("could not get role: %w", err)
+	}
+	return &role, nil
+}
+
+// updateAdminAndRevokeSessions updates columns of an admin and revokes all its sessions in one transaction.
+func (s *AdminService) updateAdminAndRevokeSessions(ctx context.Context, adminID uint64, updates map[string]interface{}) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateAdmin(tx, adminID, updates); err != nil {
+			return err
+		}
+		return revokeSessions(tx, adminID)
+	})
+}
+
+// updateAdmin updates columns of an admin, returning ErrAdminNotFound if there is no such admin.
+func (s *AdminService) updateAdmin(ctx context.Context, adminID uint64, updates map[string]interface{}) error {
+	return updateAdmin(s.db.WithContext(ctx), adminID, updates)
+}
+
+func updateAdmin(db *gorm.DB, adminID uint64, updates map[string]interface{}) error {
+	result := db.Model(&database.Admin{}).Where("id = ?", adminID).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("could not update admin: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAdminNotFound
+	}
+	return nil
 }
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTOTPNotSetUp       = errors.New("two-factor authentication is not set up")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+)
+
+// TOTPSetup is a new TOTP secret and its otpauth:// URI for an authenticator app.
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+// SetupTOTP generates a new TOTP secret for an admin. Two-factor authentication isn't required
+// at login until the secret is confirmed with EnableTOTP; a repeated setup replaces the secret.
+func (s *AdminService) SetupTOTP(ctx context.Context, adminID uint64) (*TOTPSetup, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	var admin database.Admin
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockAdmin(tx, &admin, adminID); err != nil {
+			return err
+		}
+		if admin.TOTPEnabled {
+			return ErrTOTPAlreadyEnabled
+		}
+		return tx.Model(&admin).Updates(map[string]interface{}{
+			"totp_secret":    secret,
+			"totp_last_step": 0,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    auth.TOTPURI(admin.Login, secret),
+	}, nil
+}
+
+// EnableTOTP turns on two-factor authentication once the admin proves with a code that the
+// authenticator app has the secret from SetupTOTP. It returns new recovery codes, they are
+// not stored in plain text and can't be shown again.
+func (s *AdminService) EnableTOTP(ctx context.Context, adminID uint64, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var admin database.Admin
+		if err := lockAdmin(tx, &admin, adminID); err != nil {
+			return err
+		}
+		if admin.TOTPEnabled {
+			return ErrTOTPAlreadyEnabled
+		}
+		if admin.TOTPSecret == nil {
+			return ErrTOTPNotSetUp
+		}
+
+		step, ok := auth.ValidateTOTP(*admin.TOTPSecret, code, time.Now(), admin.TOTPLastStep)
+		if !ok {
+			return ErrInvalidTOTPCode
+		}
+		if err := tx.Model(&admin).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return fmt.Errorf("could not enable totp: %w", err)
+		}
+
+		var err error
+		recoveryCodes, err = replaceRecoveryCodes(tx, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("admin enabled two-factor authentication", "adminID", adminID)
+	return recoveryCodes, nil
+}
+
+// DisableTOTP turns off two-factor authentication. Both the password and a TOTP or recovery code
+// are required, so a stolen session alone can't weaken the account.
+func (s *AdminService) DisableTOTP(ctx context.Context, adminID uint64, password, code string) error {
+	// authErr rejects the change. It is kept apart from the transaction error, since
+	// a failed attempt has to be committed.
+	var authErr error
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		admin, rejected, err := s.checkTOTPChange(tx, adminID, password, code)
+		if err != nil || rejected != nil {
+			authErr = rejected
+			return err
+		}
+		return clearTOTP(tx, admin.ID)
+	})
+	if err != nil {
+		return err
+	}
+	if authErr != nil {
+		return authErr
+	}
+
+	s.logger.Info("admin disabled two-factor authentication", "adminID", adminID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of an admin, e.g. when most are used up.
+// Like DisableTOTP it requires the password and a TOTP or recovery code.
+func (s *AdminService) RegenerateRecoveryCodes(ctx context.Context, adminID uint64, password, code string) ([]string, error) {
+	var recoveryCodes []string
+	var authErr error
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, rejected, err := s.checkTOTPChange(tx, adminID, password, code)
+		if err != nil || rejected != nil {
+			authErr = rejected
+			return err
+		}
+
+		recoveryCodes, err = replaceRecoveryCodes(tx, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if authErr != nil {
+		return nil, authErr
+	}
+	return recoveryCodes, nil
+}
+
+// VerifyTOTP completes the login of an admin with two-factor authentication, whose password
+// was accepted by Authenticate. The code is a TOTP code or an unused recovery code. Wrong codes
+// count as failed logins, so they lock the account like wrong passwords do.
+func (s *AdminService) VerifyTOTP(ctx context.Context, adminID uint64, code string) (*database.Admin, error) {
+	var admin database.Admin
+	// authErr rejects the login. It is kept apart from the transaction error, since
+	// a failed attempt has to be committed.
+	var authErr error
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Role").First(&admin, adminID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				authErr = ErrInvalidCredentials
+				return nil
+			}
+			s.logger.Error("failed to find admin by ID", "error", err, "adminID", adminID)
+			return err
+		}
+
+		now := time.Now()
+		if admin.LockedUntil != nil && admin.LockedUntil.After(now) {
+			authErr = ErrAccountLocked
+			return nil
+		}
+		// Two-factor authentication was reset after the password was accepted.
+		if !admin.TOTPEnabled {
+			authErr = ErrInvalidCredentials
+			return nil
+		}
+
+		ok, err := checkSecondFactor(tx, &admin, code, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			authErr = ErrInvalidTOTPCode
+			return s.recordFailedLogin(tx, &admin, now)
+		}
+
+		if !admin.IsActive {
+			authErr = ErrAccountInactive
+			return nil
+		}
+
+		admin.FailedLoginAttempts = 0
+		admin.LockedUntil = nil
+		admin.LastLoginAt = &now
+		if err := tx.Model(&admin).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+			"last_login_at":         now,
+		}).Error; err != nil {
+			s.logger.Error("failed to record login", "error", err, "adminID", admin.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	return &admin, nil
+}
+
+// ResetTOTP turns off two-factor authentication of an admin who lost the authenticator and the
+// recovery codes, and revokes its sessions.
+func (s *AdminService) ResetTOTP(ctx context.Context, adminID uint64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var admin database.Admin
+		if err := lockAdmin(tx, &admin, adminID); err != nil {
+			return err
+		}
+		if err := clearTOTP(tx, adminID); err != nil {
+			return err
+		}
+		return revokeSessions(tx, adminID)
+	})
+}
+
+// checkTOTPChange locks an admin with two-factor authentication enabled and checks the password
+// and the code required to change the two-factor settings. A wrong password or code is returned
+// as rejected and counted as a failed login, like in VerifyTOTP, so that a stolen session can't
+// be used to guess them; the caller must commit the transaction. A locked account is rejected.
+func (s *AdminService) checkTOTPChange(tx *gorm.DB, adminID uint64, password, code string) (admin *database.Admin, rejected, err error) {
+	admin = &database.Admin{}
+	if err := lockAdmin(tx, admin, adminID); err != nil {
+		return nil, nil, err
+	}
+	if !admin.TOTPEnabled {
+		return nil, nil, ErrTOTPNotEnabled
+	}
+
+	now := time.Now()
+	if admin.LockedUntil != nil && admin.LockedUntil.After(now) {
+		return nil, ErrAccountLocked, nil
+	}
+	if !auth.CheckPasswordHash(password, admin.HashedPassword) {
+		return nil, ErrIncorrectPassword, s.recordFailedLogin(tx, admin, now)
+	}
+
+	ok, err := checkSecondFactor(tx, admin, code, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTOTPCode, s.recordFailedLogin(tx, admin, now)
+	}
+	return admin, nil, nil
+}
+
+// checkSecondFactor accepts a TOTP code that wasn't used before or an unused recovery code,
+// which is marked as used.
+func checkSecondFactor(tx *gorm.DB, admin *database.Admin, code string, now time.Time) (bool, error) {
+	if admin.TOTPSecret != nil {
+		if step, ok := auth.ValidateTOTP(*admin.TOTPSecret, code, now, admin.TOTPLastStep); ok {
+			if err := tx.Model(admin).Update("totp_last_step", step).Error; err != nil {
+				return false, fmt.Errorf("could not save totp step: %w", err)
+			}
+			return true, nil
+		}
+	}
+
+	result := tx.Model(&database.AdminRecoveryCode{}).
+		Where("admin_id = ? AND code_hash = ? AND used_at IS NULL", admin.ID, auth.HashRecoveryCode(code)).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, fmt.Errorf("could not use recovery code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// replaceRecoveryCodes deletes the recovery codes of an admin and stores new ones.
+func replaceRecoveryCodes(tx *gorm.DB, adminID uint64) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(auth.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("admin_id = ?", adminID).Delete(&database.AdminRecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("could not delete recovery codes: %w", err)
+	}
+	records := make([]database.AdminRecoveryCode, len(codes))
+	for i, code := range codes {
+		records[i] = database.AdminRecoveryCode{AdminID: adminID, CodeHash: auth.HashRecoveryCode(code)}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("could not save recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// clearTOTP removes the TOTP secret and the recovery codes of an admin.
+func clearTOTP(tx *gorm.DB, adminID uint64) error {
+	if err := updateAdmin(tx, adminID, map[string]interface{}{
+		"totp_secret":    nil,
+		"totp_enabled":   false,
+		"totp_last_step": 0,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Where("admin_id = ?", adminID).Delete(&database.AdminRecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("could not delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// lockAdmin loads an admin for update, returning ErrAdminNotFound if there is no such admin.
+func lockAdmin(tx *gorm.DB, admin *database.Admin, adminID uint64) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(admin, adminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAdminNotFound
+		}
+		return fmt.Errorf("could not get admin: %w", err)
+	}
+	return nil
+}
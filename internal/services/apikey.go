@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval limits how often last_used_at of an API key is written, so that
+// a busy integration doesn't update the row on every request.
+const apiKeyTouchInterval = time.Minute
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIKey is returned for an unknown, revoked or expired API key and for keys
+	// of inactive admins.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// IssuedAPIKey is a newly created API key with its secret, which isn't stored and can't be shown again.
+type IssuedAPIKey struct {
+	database.APIKey
+	Key string
+}
+
+// APIKeyServiceInterface defines the contract for admin API key operations.
+type APIKeyServiceInterface interface {
+	Create(ctx context.Context, adminID uint64, name string, permissions []string, expiresAt time.Time) (*IssuedAPIKey, error)
+	List(ctx context.Context, adminID uint64) ([]database.APIKey, error)
+	Revoke(ctx context.Context, adminID, keyID uint64) error
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// APIKeyService manages API keys that give other systems access to the admin API on behalf of an admin.
+type APIKeyService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// NewAPIKeyService creates a new APIKeyService.
+func NewAPIKeyService(db *gorm.DB, logger *slog.Logger) APIKeyServiceInterface {
+	return &APIKeyService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create issues an API key of an admin with the given permissions.
+func (s *APIKeyService) Create(ctx context.Context, adminID uint64, name string, permissions []string, expiresAt time.Time) (*IssuedAPIKey, error) {
+	key, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := database.APIKey{
+		AdminID:     adminID,
+		Name:        name,
+		Prefix:      prefix,
+		KeyHash:     auth.HashAPIKey(key),
+		Permissions: permissions,
+		ExpiresAt:   expiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(&apiKey).Error; err != nil {
+		return nil, fmt.Errorf("could not create api key: %w", err)
+	}
+
+	s.logger.Info("api key created", "adminID", adminID, "prefix", prefix, "name", name)
+	return &IssuedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// List returns the API keys of an admin, newest first, including revoked and expired ones.
+func (s *APIKeyService) List(ctx context.Context, adminID uint64) ([]database.APIKey, error) {
+	var keys []database.APIKey
+	if err := s.db.WithContext(ctx).Where("admin_id = ?", adminID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("could not list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke revokes an API key of an admin. Revoking a revoked key succeeds and keeps the original time.
+func (s *APIKeyService) Revoke(ctx context.Context, adminID, keyID uint64) error {
+	result := s.db.WithContext(ctx).Model(&database.APIKey{}).
+		Where("id = ? AND admin_id = ?", keyID, adminID).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", time.Now()))
+	if result.Error != nil {
+		return fmt.Errorf("could not revoke api key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	s.logger.Info("api key revoked", "adminID", adminID, "keyID", keyID)
+	return nil
+}
+
+// Authenticate returns the principal an API key acts as. The key gets only those of its
+// permissions that the role of its admin still has, and stops working when the admin is deactivated.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	prefix, ok := auth.ParseAPIKey(key)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey database.APIKey
+	if err := s.db.WithContext(ctx).Preload("Admin.Role").Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("could not find api key: %w", err)
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(auth.HashAPIKey(key)), []byte(apiKey.KeyHash)) != 1 ||
+		apiKey.RevokedAt != nil || !apiKey.ExpiresAt.After(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	if apiKey.Admin == nil || !apiKey.Admin.IsActive || apiKey.Admin.Role == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	rolePermissions := auth.RolePermissions(apiKey.Admin.Role)
+	permissions := make([]string, 0, len(apiKey.Permissions))
+	for _, permission := range apiKey.Permissions {
+		if slices.Contains(rolePermissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		// The request is served even if the usage time can't be saved.
+		if err := s.db.WithContext(ctx).Model(&apiKey).Update("last_used_at", now).Error; err != nil {
+			s.logger.Error("failed to update api key last use", "error", err, "prefix", prefix)
+		}
+	}
+
+	return &auth.Principal{
+		AdminID:     apiKey.AdminID,
+		Login:       apiKey.Admin.Login,
+		Permissions: permissions,
+		APIKeyID:    apiKey.ID,
+	}, nil
+}
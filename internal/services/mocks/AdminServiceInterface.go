@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 	database "go-bot/internal/database"
+	services "go-bot/internal/services"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -14,6 +15,24 @@ type AdminServiceInterface struct {
 	mock.Mock
 }
 
+// ActivateAdmin provides a mock function with given fields: ctx, adminID
+func (_m *AdminServiceInterface) ActivateAdmin(ctx context.Context, adminID uint64) error {
+	ret := _m.Called(ctx, adminID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ActivateAdmin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, adminID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Authenticate provides a mock function with given fields: ctx, login, password
 func (_m *AdminServiceInterface) Authenticate(ctx context.Context, login string, password string) (*database.Admin, error) {
 	ret := _m.Called(ctx, login, password)
@@ -45,7 +64,7 @@ func (_m *AdminServiceInterface) Authenticate(ctx context.Context, login string,
 }
 
 // ChangePassword provides a mock function with given fields: ctx, adminID, oldPassword, newPassword
-func (_m *AdminServiceInterface) ChangePassword(ctx context.Context, adminID uint, oldPassword string, newPassword string) error {
+func (_m *AdminServiceInterface) ChangePassword(ctx context.Context, adminID uint64, oldPassword string, newPassword string) error {
 	ret := _m.Called(ctx, adminID, oldPassword, newPassword)
 
 	if len(ret) == 0 {
@@ -53,7 +72,7 @@ func (_m *AdminServiceInterface) ChangePassword(ctx context.Context, adminID uin
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string, string) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string, string) error); ok {
 		r0 = rf(ctx, adminID, oldPassword, newPassword)
 	} else {
 		r0 = ret.Error(0)
@@ -62,9 +81,9 @@ func (_m *AdminServiceInterface) ChangePassword(ctx context.Context, adminID uin
 	return r0
 }
 
-// CreateAdmin provides a mock function with given fields: ctx, login, password
-func (_m *AdminServiceInterface) CreateAdmin(ctx context.Context, login string, password string) (*database.Admin, error) {
-	ret := _m.Called(ctx, login, password)
+// CreateAdmin provides a mock function with given fields: ctx, login, password, role
+func (_m *AdminServiceInterface) CreateAdmin(ctx context.Context, login string, password string, role string) (*database.Admin, error) {
+	ret := _m.Called(ctx, login, password, role)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateAdmin")
@@ -72,19 +91,85 @@ func (_m *AdminServiceInterface) CreateAdmin(ctx context.Context, login string,
 
 	var r0 *database.Admin
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*database.Admin, error)); ok {
-		return rf(ctx, login, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*database.Admin, error)); ok {
+		return rf(ctx, login, password, role)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *database.Admin); ok {
-		r0 = rf(ctx, login, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *database.Admin); ok {
+		r0 = rf(ctx, login, password, role)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*database.Admin)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, login, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, login, password, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeactivateAdmin provides a mock function with given fields: ctx, adminID
+func (_m *AdminServiceInterface) DeactivateAdmin(ctx context.Context, adminID uint64) error {
+	ret := _m.Called(ctx, adminID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeactivateAdmin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, adminID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DisableTOTP provides a mock function with given fields: ctx, adminID, password, code
+func (_m *AdminServiceInterface) DisableTOTP(ctx context.Context, adminID uint64, password string, code string) error {
+	ret := _m.Called(ctx, adminID, password, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableTOTP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string, string) error); ok {
+		r0 = rf(ctx, adminID, password, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableTOTP provides a mock function with given fields: ctx, adminID, code
+func (_m *AdminServiceInterface) EnableTOTP(ctx context.Context, adminID uint64, code string) ([]string, error) {
+	ret := _m.Called(ctx, adminID, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableTOTP")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) ([]string, error)); ok {
+		return rf(ctx, adminID, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) []string); ok {
+		r0 = rf(ctx, adminID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, string) error); ok {
+		r1 = rf(ctx, adminID, code)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -93,7 +178,7 @@ func (_m *AdminServiceInterface) CreateAdmin(ctx context.Context, login string,
 }
 
 // GetProfile provides a mock function with given fields: ctx, adminID
-func (_m *AdminServiceInterface) GetProfile(ctx context.Context, adminID uint) (*database.Admin, error) {
+func (_m *AdminServiceInterface) GetProfile(ctx context.Context, adminID uint64) (*database.Admin, error) {
 	ret := _m.Called(ctx, adminID)
 
 	if len(ret) == 0 {
@@ -102,10 +187,10 @@ func (_m *AdminServiceInterface) GetProfile(ctx context.Context, adminID uint) (
 
 	var r0 *database.Admin
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint) (*database.Admin, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*database.Admin, error)); ok {
 		return rf(ctx, adminID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint) *database.Admin); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *database.Admin); ok {
 		r0 = rf(ctx, adminID)
 	} else {
 		if ret.Get(0) != nil {
@@ -113,7 +198,7 @@ func (_m *AdminServiceInterface) GetProfile(ctx context.Context, adminID uint) (
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
 		r1 = rf(ctx, adminID)
 	} else {
 		r1 = ret.Error(1)
@@ -122,6 +207,246 @@ func (_m *AdminServiceInterface) GetProfile(ctx context.Context, adminID uint) (
 	return r0, r1
 }
 
+// ListAdmins provides a mock function with given fields: ctx
+func (_m *AdminServiceInterface) ListAdmins(ctx context.Context) ([]database.Admin, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAdmins")
+	}
+
+	var r0 []database.Admin
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]database.Admin, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []database.Admin); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]database.Admin)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRoles provides a mock function with given fields: ctx
+func (_m *AdminServiceInterface) ListRoles(ctx context.Context) ([]database.Role, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRoles")
+	}
+
+	var r0 []database.Role
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]database.Role, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []database.Role); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]database.Role)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegenerateRecoveryCodes provides a mock function with given fields: ctx, adminID, password, code
+func (_m *AdminServiceInterface) RegenerateRecoveryCodes(ctx context.Context, adminID uint64, password string, code string) ([]string, error) {
+	ret := _m.Called(ctx, adminID, password, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegenerateRecoveryCodes")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string, string) ([]string, error)); ok {
+		return rf(ctx, adminID, password, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string, string) []string); ok {
+		r0 = rf(ctx, adminID, password, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, string, string) error); ok {
+		r1 = rf(ctx, adminID, password, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetTOTP provides a mock function with given fields: ctx, adminID
+func (_m *AdminServiceInterface) ResetTOTP(ctx context.Context, adminID uint64) error {
+	ret := _m.Called(ctx, adminID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetTOTP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, adminID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveRole provides a mock function with given fields: ctx, role
+func (_m *AdminServiceInterface) SaveRole(ctx context.Context, role *database.Role) error {
+	ret := _m.Called(ctx, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *database.Role) error); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPassword provides a mock function with given fields: ctx, adminID, password
+func (_m *AdminServiceInterface) SetPassword(ctx context.Context, adminID uint64, password string) error {
+	ret := _m.Called(ctx, adminID, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) error); ok {
+		r0 = rf(ctx, adminID, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRole provides a mock function with given fields: ctx, adminID, role
+func (_m *AdminServiceInterface) SetRole(ctx context.Context, adminID uint64, role string) error {
+	ret := _m.Called(ctx, adminID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) error); ok {
+		r0 = rf(ctx, adminID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetupTOTP provides a mock function with given fields: ctx, adminID
+func (_m *AdminServiceInterface) SetupTOTP(ctx context.Context, adminID uint64) (*services.TOTPSetup, error) {
+	ret := _m.Called(ctx, adminID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetupTOTP")
+	}
+
+	var r0 *services.TOTPSetup
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*services.TOTPSetup, error)); ok {
+		return rf(ctx, adminID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *services.TOTPSetup); ok {
+		r0 = rf(ctx, adminID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.TOTPSetup)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, adminID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnlockAdmin provides a mock function with given fields: ctx, adminID
+func (_m *AdminServiceInterface) UnlockAdmin(ctx context.Context, adminID uint64) error {
+	ret := _m.Called(ctx, adminID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlockAdmin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, adminID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyTOTP provides a mock function with given fields: ctx, adminID, code
+func (_m *AdminServiceInterface) VerifyTOTP(ctx context.Context, adminID uint64, code string) (*database.Admin, error) {
+	ret := _m.Called(ctx, adminID, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyTOTP")
+	}
+
+	var r0 *database.Admin
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) (*database.Admin, error)); ok {
+		return rf(ctx, adminID, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) *database.Admin); ok {
+		r0 = rf(ctx, adminID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*database.Admin)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, string) error); ok {
+		r1 = rf(ctx, adminID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewAdminServiceInterface creates a new instance of AdminServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewAdminServiceInterface(t interface {
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"go-bot/internal/auth"
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChallengeTTL is how long an admin with two-factor authentication has to enter the code
+// after the password was accepted.
+const ChallengeTTL = 5 * time.Minute
+
+var (
+	// ErrInvalidRefreshToken is returned for an unknown, rotated, expired or revoked refresh token.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrInvalidChallenge is returned for a malformed or expired login challenge token.
+	ErrInvalidChallenge = errors.New("invalid challenge token")
+)
+
+// SessionClient describes the client a session was opened from.
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenPair is a short-lived access token and the refresh token that renews it.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresIn  time.Duration
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// SessionServiceInterface defines the contract for admin session operations.
+type SessionServiceInterface interface {
+	Create(ctx context.Context, admin *database.Admin, client SessionClient) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client SessionClient) (*TokenPair, error)
+	IsActive(ctx context.Context, tokenID string) (bool, error)
+	Revoke(ctx context.Context, tokenID string) error
+	RevokeAll(ctx context.Context, adminID uint64) error
+	Challenge(admin *database.Admin) (string, error)
+	VerifyChallenge(challengeToken string) (uint64, error)
+}
+
+// SessionService issues access and refresh tokens for admin sessions stored in admin_sessions.
+// Every refresh rotates both tokens, so a refresh token can be used only once. A rotated token
+// used again is treated as stolen and revokes the whole session.
+type SessionService struct {
+	db          *gorm.DB
+	secretKey   string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	maxLifetime time.Duration
+	logger      *slog.Logger
+}
+
+// NewSessionService creates a new SessionService. Access tokens live accessTTL, a session
+// expires when it isn't refreshed for refreshTTL and at the latest maxLifetime after login.
+func NewSessionService(db *gorm.DB, secretKey string, accessTTL, refreshTTL, maxLifetime time.Duration, logger *slog.Logger) SessionServiceInterface {
+	return &SessionService{
+		db:          db,
+		secretKey:   secretKey,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+		maxLifetime: maxLifetime,
+		logger:      logger,
+	}
+}
+
+// Create opens a session for an authenticated admin.
+func (s *SessionService) Create(ctx context.Context, admin *database.Admin, client SessionClient) (*TokenPair, error) {
+	now := time.Now()
+	tokenID, refreshToken, err := newSessionTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &database.AdminSession{
+		AdminID:          admin.ID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		AccessJTI:        tokenID,
+		UserAgent:        truncate(client.UserAgent, 255),
+		IP:               truncate(client.IP, 64),
+		ExpiresAt:        s.expiresAt(now, now),
+		LastUsedAt:       now,
+		CreatedAt:        now,
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Expired and revoked sessions of the admin are no longer needed.
+		if err := tx.Where("admin_id = ? AND (expires_at <= ? OR revoked_at IS NOT NULL)", admin.ID, now).
+			Delete(&database.AdminSession{}).Error; err != nil {
+			return fmt.Errorf("could not delete old sessions: %w", err)
+		}
+		if err := tx.Create(session).Error; err != nil {
+			return fmt.Errorf("could not create session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokenPair(admin, tokenID, refreshToken, session.ExpiresAt)
+}
+
+// Refresh rotates the tokens of the session the refresh token belongs to. Sessions of inactive
+// admins are revoked instead. A refresh token that was already rotated revokes its session:
+// either the token or its replacement is in the hands of someone else.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string, client SessionClient) (*TokenPair, error) {
+	now := time.Now()
+	tokenID, newRefreshToken, err := newSessionTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenHash := hashRefreshToken(refreshToken)
+	var expiresAt time.Time
+	var admin database.Admin
+	var session database.AdminSession
+	// refreshErr rejects the refresh. It is kept apart from the transaction error, since
+	// revoking the session of an inactive admin or a reused token has to be committed.
+	var refreshErr error
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row is locked so that a refresh token can't be used twice concurrently.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token_hash = ?", tokenHash).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				refreshErr = ErrInvalidRefreshToken
+				return s.revokeReused(tx, tokenHash, now)
+			}
+			return fmt.Errorf("could not find session: %w", err)
+		}
+		if session.RevokedAt != nil || !session.ExpiresAt.After(now) || !session.CreatedAt.Add(s.maxLifetime).After(now) {
+			refreshErr = ErrInvalidRefreshToken
+			return nil
+		}
+
+		// The role is reloaded, so a refreshed token carries the current permissions.
+		if err := tx.Preload("Role").First(&admin, session.AdminID).Error; err != nil {
+			return fmt.Errorf("could not find admin: %w", err)
+		}
+		if !admin.IsActive {
+			refreshErr = ErrAccountInactive
+			return tx.Model(&session).Update("revoked_at", now).Error
+		}
+
+		expiresAt = s.expiresAt(session.CreatedAt, now)
+		updates := map[string]interface{}{
+			"refresh_token_hash": hashRefreshToken(newRefreshToken),
+			"access_jti":         tokenID,
+			"expires_at":         expiresAt,
+			"last_used_at":       now,
+		}
+		if client.UserAgent != "" {
+			updates["user_agent"] = truncate(client.UserAgent, 255)
+		}
+		if client.IP != "" {
+			updates["ip"] = truncate(client.IP, 64)
+		}
+		if err := tx.Model(&session).Updates(updates).Error; err != nil {
+			return fmt.Errorf("could not rotate session tokens: %w", err)
+		}
+		rotated := &database.AdminSessionRotatedToken{TokenHash: tokenHash, SessionID: session.ID, RotatedAt: now}
+		if err := tx.Create(rotated).Error; err != nil {
+			return fmt.Errorf("could not save rotated refresh token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refreshErr != nil {
+		return nil, refreshErr
+	}
+
+	return s.tokenPair(&admin, tokenID, newRefreshToken, expiresAt)
+}
+
+// revokeReused revokes the session of a refresh token that was already rotated. Unknown
+// tokens are ignored.
+func (s *SessionService) revokeReused(tx *gorm.DB, tokenHash string, now time.Time) error {
+	var rotated database.AdminSessionRotatedToken
+	if err := tx.Where("token_hash = ?", tokenHash).First(&rotated).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("could not find rotated refresh token: %w", err)
+	}
+
+	if err := tx.Model(&database.AdminSession{}).
+		Where("id = ? AND revoked_at IS NULL", rotated.SessionID).
+		Update("revoked_at", now).Error; err != nil {
+		return fmt.Errorf("could not revoke session: %w", err)
+	}
+	s.logger.Warn("rotated refresh token reused, session revoked", "sessionID", rotated.SessionID)
+	return nil
+}
+
+// expiresAt returns when a session created at createdAt expires if it isn't refreshed after now.
+func (s *SessionService) expiresAt(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(s.refreshTTL)
+	if deadline := createdAt.Add(s.maxLifetime); deadline.Before(expiresAt) {
+		return deadline
+	}
+	return expiresAt
+}
+
+// IsActive reports whether the session of an access token, identified by its jti, is neither
+// revoked nor expired. Access tokens replaced by a refresh are no longer active.
+func (s *SessionService) IsActive(ctx context.Context, tokenID string) (bool, error) {
+	if tokenID == "" {
+		return false, nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&database.AdminSession{}).
+		Where("access_jti = ? AND revoked_at IS NULL AND expires_at > ?", tokenID, time.Now()).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("could not check session: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Revoke ends the session of an access token, identified by its jti.
+func (s *SessionService) Revoke(ctx context.Context, tokenID string) error {
+	if err := s.db.WithContext(ctx).Model(&database.AdminSession{}).
+		Where("access_jti = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("could not revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll ends all sessions of an admin.
+func (s *SessionService) RevokeAll(ctx context.Context, adminID uint64) error {
+	if err := revokeSessions(s.db.WithContext(ctx), adminID); err != nil {
+		return err
+	}
+	s.logger.Info("all admin sessions revoked", "adminID", adminID)
+	return nil
+}
+
+// Challenge issues the token of the second login step for an admin whose password was accepted
+// but who has two-factor authentication enabled. It is valid for ChallengeTTL.
+func (s *SessionService) Challenge(admin *database.Admin) (string, error) {
+	challengeToken, err := auth.GenerateChallengeToken(admin.ID, s.secretKey, ChallengeTTL)
+	if err != nil {
+		return "", fmt.Errorf("could not sign challenge token: %w", err)
+	}
+	return challengeToken, nil
+}
+
+// VerifyChallenge returns the ID of the admin a challenge token was issued for.
+func (s *SessionService) VerifyChallenge(challengeToken string) (uint64, error) {
+	adminID, err := auth.ValidateChallengeToken(challengeToken, s.secretKey)
+	if err != nil {
+		return 0, ErrInvalidChallenge
+	}
+	return adminID, nil
+}
+
+// tokenPair signs the access token of a session.
+func (s *SessionService) tokenPair(admin *database.Admin, tokenID, refreshToken string, expiresAt time.Time) (*TokenPair, error) {
+	accessToken, err := auth.GenerateToken(admin, tokenID, s.secretKey, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign access token: %w", err)
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresIn:  s.accessTTL,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: expiresAt,
+	}, nil
+}
+
+// revokeSessions revokes all active sessions of an admin. It is shared with AdminService,
+// which revokes sessions in the same transaction as a password change or deactivation.
+func revokeSessions(db *gorm.DB, adminID uint64) error {
+	if err := db.Model(&database.AdminSession{}).
+		Where("admin_id = ? AND revoked_at IS NULL", adminID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("could not revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// newSessionTokens generates the jti of an access token and a refresh token.
+func newSessionTokens() (tokenID, refreshToken string, err error) {
+	buf := make([]byte, 16+32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("could not generate session tokens: %w", err)
+	}
+	return hex.EncodeToString(buf[:16]), base64.RawURLEncoding.EncodeToString(buf[16:]), nil
+}
+
+// hashRefreshToken returns the hex SHA-256 of a refresh token as stored in admin_sessions.
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
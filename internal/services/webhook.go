@@ -5,10 +5,8 @@ import (
 	"log/slog"
 
 	"go-bot/internal/bot"
-	"go-bot/internal/service"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"gorm.io/gorm"
 )
 
 // WebhookServiceInterface defines the contract for the webhook service.
@@ -18,19 +16,15 @@ type WebhookServiceInterface interface {
 
 // WebhookService handles business logic for Telegram updates.
 type WebhookService struct {
-	db         *gorm.DB
-	bot        *tgbotapi.BotAPI
-	logger     *slog.Logger
-	xuiService *service.XUIService
+	handler *bot.Handler
+	logger  *slog.Logger
 }
 
 // NewWebhookService creates a new WebhookService.
-func NewWebhookService(db *gorm.DB, bot *tgbotapi.BotAPI, logger *slog.Logger, xuiService *service.XUIService) WebhookServiceInterface {
+func NewWebhookService(handler *bot.Handler, logger *slog.Logger) WebhookServiceInterface {
 	return &WebhookService{
-		db:         db,
-		bot:        bot,
-		logger:     logger,
-		xuiService: xuiService,
+		handler: handler,
+		logger:  logger,
 	}
 }
 
@@ -38,6 +32,6 @@ func NewWebhookService(db *gorm.DB, bot *tgbotapi.BotAPI, logger *slog.Logger, x
 func (s *WebhookService) ProcessUpdate(ctx context.Context, update tgbotapi.Update) error {
 	// Delegate the update processing to the bot package, which contains the core logic.
 	s.logger.Info("Delegating update to bot processor", "update_id", update.UpdateID)
-	bot.ProcessUpdate(ctx, update, s.bot, s.db, s.xuiService)
+	s.handler.ProcessUpdate(ctx, update)
 	return nil // The bot package handles errors internally by logging them.
 }
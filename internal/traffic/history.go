@@ -0,0 +1,68 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go-bot/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// History читает записанную Recorder историю трафика одной панели.
+type History struct {
+	db    *gorm.DB
+	panel string
+}
+
+// PanelName возвращает имя панели 3x-ui для истории трафика - хост из XUI_URL.
+func PanelName(xuiURL string) string {
+	if u, err := url.Parse(xuiURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return xuiURL
+}
+
+// NewHistory создает History для панели panel (см. Options.Panel).
+func NewHistory(db *gorm.DB, panel string) *History {
+	return &History{db: db, panel: panel}
+}
+
+// Daily возвращает суточный трафик клиента за дни с from по to включительно (UTC).
+// Дни без трафика возвращаются с нулями, чтобы на графике не было пропусков.
+func (h *History) Daily(ctx context.Context, email string, from, to time.Time) ([]database.TrafficDaily, error) {
+	from, to = DayBucket(from), DayBucket(to)
+
+	var rows []database.TrafficDaily
+	if err := h.db.WithContext(ctx).
+		Where("panel = ? AND email = ? AND day BETWEEN ? AND ?", h.panel, email, from, to).
+		Order("day").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get daily traffic: %w", err)
+	}
+	return FillDays(rows, h.panel, email, from, to), nil
+}
+
+// FillDays раскладывает суточные агрегаты по всем дням с from по to включительно,
+// добавляя нулевые записи для дней без трафика.
+func FillDays(rows []database.TrafficDaily, panel, email string, from, to time.Time) []database.TrafficDaily {
+	from, to = DayBucket(from), DayBucket(to)
+
+	byDay := make(map[time.Time]database.TrafficDaily, len(rows))
+	for _, row := range rows {
+		byDay[DayBucket(row.Day)] = row
+	}
+
+	var days []database.TrafficDaily
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		row, ok := byDay[day]
+		if !ok {
+			row = database.TrafficDaily{Panel: panel, Email: email}
+		}
+		row.Day = day
+		days = append(days, row)
+	}
+	return days
+}
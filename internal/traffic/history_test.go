@@ -0,0 +1,32 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"go-bot/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFillDays(t *testing.T) {
+	from := time.Date(2025, 3, 1, 15, 30, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 4, 8, 0, 0, 0, time.UTC)
+	rows := []database.TrafficDaily{
+		{Panel: "panel", Email: "a@example.com", Day: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), Up: 10, Down: 100},
+		{Panel: "panel", Email: "a@example.com", Day: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC), Up: 5, Down: 50},
+	}
+
+	days := FillDays(rows, "panel", "a@example.com", from, to)
+
+	require.Len(t, days, 4)
+	for i, day := range days {
+		assert.Equal(t, time.Date(2025, 3, 1+i, 0, 0, 0, 0, time.UTC), day.Day)
+		assert.Equal(t, "a@example.com", day.Email)
+	}
+	assert.Equal(t, int64(0), days[0].Down)
+	assert.Equal(t, int64(100), days[1].Down)
+	assert.Equal(t, int64(0), days[2].Up)
+	assert.Equal(t, int64(5), days[3].Up)
+}
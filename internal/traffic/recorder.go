@@ -0,0 +1,283 @@
+// Package traffic записывает историю трафика клиентов 3x-ui.
+//
+// 3x-ui хранит только накопительные счетчики up/down, поэтому Recorder периодически
+// снимает их, вычисляет прирост с прошлого опроса (с учетом сброса счетчиков) и
+// агрегирует его по часам и суткам в UTC.
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go-bot/internal/database"
+	"go-bot/internal/xui"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Source отдает текущие счетчики трафика всех клиентов панели.
+type Source interface {
+	ListClientTraffics(ctx context.Context) ([]xui.ClientTraffic, error)
+}
+
+// Options настраивают Recorder.
+type Options struct {
+	// Panel отличает клиентов разных панелей 3x-ui с одинаковыми email.
+	Panel string
+	// Interval - период опроса панели.
+	Interval time.Duration
+	// SnapshotRetention и HourlyRetention - сколько хранить снимки и почасовые данные.
+	// Суточные агрегаты хранятся бессрочно. 0 - хранить бессрочно.
+	SnapshotRetention time.Duration
+	HourlyRetention   time.Duration
+	Logger            *slog.Logger
+}
+
+// Recorder периодически записывает счетчики трафика клиентов в Postgres.
+type Recorder struct {
+	db     *gorm.DB
+	source Source
+	opts   Options
+
+	mu sync.Mutex
+	// last - последние записанные счетчики клиентов.
+	last map[clientKey]Counters
+	// loaded - last восстановлен из базы после запуска.
+	loaded    bool
+	lastPrune time.Time
+}
+
+// clientKey отличает клиента по панели и email, как строки traffic_snapshots.
+type clientKey struct {
+	Panel, Email string
+}
+
+// Counters - накопительные счетчики и статус клиента на момент снимка.
+type Counters struct {
+	Up, Down, Total int64
+	Enable          bool
+}
+
+// New создает Recorder.
+func New(db *gorm.DB, source Source, opts Options) *Recorder {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Recorder{
+		db:     db,
+		source: source,
+		opts:   opts,
+		last:   make(map[clientKey]Counters),
+	}
+}
+
+// Run опрашивает панель с заданным интервалом до отмены ctx.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Poll(ctx); err != nil && ctx.Err() == nil {
+			r.opts.Logger.Error("failed to record traffic", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll снимает счетчики всех клиентов и записывает изменившиеся.
+func (r *Recorder) Poll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.loaded {
+		if err := r.loadLast(ctx); err != nil {
+			return err
+		}
+		r.loaded = true
+	}
+
+	traffics, err := r.source.ListClientTraffics(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get client traffics: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var snapshots []database.TrafficSnapshot
+	for _, traffic := range traffics {
+		current := Counters{Up: traffic.Up, Down: traffic.Down, Total: traffic.Total, Enable: traffic.Enable}
+		prev, known := r.last[clientKey{Panel: r.opts.Panel, Email: traffic.Email}]
+		if known && prev == current {
+			continue
+		}
+
+		var delta Delta
+		if known {
+			delta = ComputeDelta(prev, current)
+		}
+		snapshots = append(snapshots, database.TrafficSnapshot{
+			Panel:      r.opts.Panel,
+			Email:      traffic.Email,
+			InboundID:  traffic.InboundID,
+			Up:         traffic.Up,
+			Down:       traffic.Down,
+			Total:      traffic.Total,
+			Enable:     traffic.Enable,
+			UpDelta:    delta.Up,
+			DownDelta:  delta.Down,
+			Reset:      delta.Reset,
+			CapturedAt: now,
+		})
+	}
+
+	if len(snapshots) > 0 {
+		if err := r.save(ctx, snapshots, now); err != nil {
+			return err
+		}
+		for _, snapshot := range snapshots {
+			r.last[clientKey{Panel: snapshot.Panel, Email: snapshot.Email}] = Counters{Up: snapshot.Up, Down: snapshot.Down, Total: snapshot.Total, Enable: snapshot.Enable}
+		}
+	}
+
+	r.opts.Logger.Debug("traffic recorded", "clients", len(traffics), "snapshots", len(snapshots))
+
+	if now.Sub(r.lastPrune) >= 24*time.Hour {
+		if err := r.prune(ctx, now); err != nil {
+			r.opts.Logger.Error("failed to prune traffic history", "error", err)
+		} else {
+			r.lastPrune = now
+		}
+	}
+	return nil
+}
+
+// save записывает снимки и добавляет прирост трафика в почасовые и суточные агрегаты
+// в одной транзакции, чтобы агрегаты не разошлись со снимками.
+func (r *Recorder) save(ctx context.Context, snapshots []database.TrafficSnapshot, now time.Time) error {
+	var hourly []database.TrafficHourly
+	var daily []database.TrafficDaily
+	for _, snapshot := range snapshots {
+		if snapshot.UpDelta == 0 && snapshot.DownDelta == 0 {
+			continue
+		}
+		hourly = append(hourly, database.TrafficHourly{
+			Panel: snapshot.Panel, Email: snapshot.Email, Bucket: HourBucket(now),
+			Up: snapshot.UpDelta, Down: snapshot.DownDelta,
+		})
+		daily = append(daily, database.TrafficDaily{
+			Panel: snapshot.Panel, Email: snapshot.Email, Day: DayBucket(now),
+			Up: snapshot.UpDelta, Down: snapshot.DownDelta,
+		})
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(snapshots, 500).Error; err != nil {
+			return fmt.Errorf("failed to save traffic snapshots: %w", err)
+		}
+		if len(hourly) > 0 {
+			if err := tx.Clauses(incrementOnConflict("traffic_hourly", "bucket")).CreateInBatches(hourly, 500).Error; err != nil {
+				return fmt.Errorf("failed to update hourly traffic: %w", err)
+			}
+		}
+		if len(daily) > 0 {
+			if err := tx.Clauses(incrementOnConflict("traffic_daily", "day")).CreateInBatches(daily, 500).Error; err != nil {
+				return fmt.Errorf("failed to update daily traffic: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// incrementOnConflict добавляет трафик к существующей строке агрегата вместо вставки новой.
+func incrementOnConflict(table, bucketColumn string) clause.OnConflict {
+	return clause.OnConflict{
+		Columns: []clause.Column{{Name: "panel"}, {Name: "email"}, {Name: bucketColumn}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"up":   gorm.Expr(table + ".up + EXCLUDED.up"),
+			"down": gorm.Expr(table + ".down + EXCLUDED.down"),
+		}),
+	}
+}
+
+// loadLast восстанавливает последние счетчики клиентов после перезапуска, чтобы
+// первый опрос посчитал прирост относительно них, а не начинал историю заново.
+func (r *Recorder) loadLast(ctx context.Context) error {
+	var snapshots []database.TrafficSnapshot
+	if err := r.db.WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (email) * FROM traffic_snapshots
+			WHERE panel = ? ORDER BY email, captured_at DESC, id DESC`, r.opts.Panel).
+		Scan(&snapshots).Error; err != nil {
+		return fmt.Errorf("failed to load last traffic snapshots: %w", err)
+	}
+	for _, snapshot := range snapshots {
+		r.last[clientKey{Panel: snapshot.Panel, Email: snapshot.Email}] = Counters{Up: snapshot.Up, Down: snapshot.Down, Total: snapshot.Total, Enable: snapshot.Enable}
+	}
+	return nil
+}
+
+// prune удаляет снимки и почасовые данные старше сроков хранения. Последний снимок
+// каждого клиента всегда остается - от него считается прирост после перезапуска.
+func (r *Recorder) prune(ctx context.Context, now time.Time) error {
+	if r.opts.SnapshotRetention > 0 {
+		if err := r.db.WithContext(ctx).Exec(`DELETE FROM traffic_snapshots s
+			WHERE s.panel = ? AND s.captured_at < ?
+			AND EXISTS (SELECT 1 FROM traffic_snapshots n
+				WHERE n.panel = s.panel AND n.email = s.email AND n.captured_at > s.captured_at)`,
+			r.opts.Panel, now.Add(-r.opts.SnapshotRetention)).Error; err != nil {
+			return fmt.Errorf("failed to prune traffic snapshots: %w", err)
+		}
+	}
+	if r.opts.HourlyRetention > 0 {
+		if err := r.db.WithContext(ctx).
+			Where("panel = ? AND bucket < ?", r.opts.Panel, now.Add(-r.opts.HourlyRetention)).
+			Delete(&database.TrafficHourly{}).Error; err != nil {
+			return fmt.Errorf("failed to prune hourly traffic: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delta - прирост трафика между двумя снимками.
+type Delta struct {
+	Up, Down int64
+	// Reset - счетчики были сброшены (вручную, по расписанию 3x-ui или при пересоздании клиента).
+	Reset bool
+}
+
+// ComputeDelta вычисляет прирост трафика между снимками. Если счетчик уменьшился,
+// он был сброшен, и весь трафик после сброса - это текущее значение счетчика.
+func ComputeDelta(prev, current Counters) Delta {
+	var delta Delta
+	if current.Up >= prev.Up {
+		delta.Up = current.Up - prev.Up
+	} else {
+		delta.Up = current.Up
+		delta.Reset = true
+	}
+	if current.Down >= prev.Down {
+		delta.Down = current.Down - prev.Down
+	} else {
+		delta.Down = current.Down
+		delta.Reset = true
+	}
+	return delta
+}
+
+// HourBucket возвращает начало часа (UTC), к которому относится момент времени.
+func HourBucket(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+// DayBucket возвращает начало суток (UTC), к которым относится момент времени.
+func DayBucket(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
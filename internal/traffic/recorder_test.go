@@ -0,0 +1,62 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeDelta(t *testing.T) {
+	testCases := []struct {
+		name     string
+		prev     Counters
+		current  Counters
+		expected Delta
+	}{
+		{
+			name:     "Counters Grow",
+			prev:     Counters{Up: 100, Down: 1000},
+			current:  Counters{Up: 150, Down: 1800},
+			expected: Delta{Up: 50, Down: 800},
+		},
+		{
+			name:     "No Traffic",
+			prev:     Counters{Up: 100, Down: 1000, Enable: true},
+			current:  Counters{Up: 100, Down: 1000, Enable: false},
+			expected: Delta{},
+		},
+		{
+			name:     "Counters Reset",
+			prev:     Counters{Up: 100, Down: 1000},
+			current:  Counters{Up: 20, Down: 300},
+			expected: Delta{Up: 20, Down: 300, Reset: true},
+		},
+		{
+			name:     "Reset To Zero",
+			prev:     Counters{Up: 100, Down: 1000},
+			current:  Counters{},
+			expected: Delta{Reset: true},
+		},
+		{
+			name:     "Only Download Reset",
+			prev:     Counters{Up: 100, Down: 1000},
+			current:  Counters{Up: 120, Down: 10},
+			expected: Delta{Up: 20, Down: 10, Reset: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ComputeDelta(tc.prev, tc.current))
+		})
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	moment := time.Date(2025, 3, 1, 1, 45, 30, 0, moscow) // 2025-02-28 22:45:30 UTC
+
+	assert.Equal(t, time.Date(2025, 2, 28, 22, 0, 0, 0, time.UTC), HourBucket(moment))
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), DayBucket(moment))
+}
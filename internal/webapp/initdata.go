@@ -0,0 +1,119 @@
+// Package webapp - серверная часть Telegram Mini App: проверка initData
+// и встроенные статические файлы интерфейса.
+package webapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMissingHash - в initData нет подписи.
+	ErrMissingHash = errors.New("init data hash is missing")
+	// ErrInvalidSignature - подпись initData не совпадает, данные подделаны или подписаны другим ботом.
+	ErrInvalidSignature = errors.New("init data signature is invalid")
+	// ErrExpired - initData выдана слишком давно.
+	ErrExpired = errors.New("init data is expired")
+	// ErrMissingUser - в initData нет пользователя (например, Mini App открыт не из чата с ботом).
+	ErrMissingUser = errors.New("init data has no user")
+)
+
+// User - пользователь Telegram из initData.
+type User struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name,omitempty"`
+	Username     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+	IsPremium    bool   `json:"is_premium,omitempty"`
+}
+
+// InitData - проверенные данные запуска Mini App.
+type InitData struct {
+	User       User
+	AuthDate   time.Time
+	QueryID    string
+	StartParam string
+}
+
+// Validate проверяет подпись initData токеном бота и ее свежесть (maxAge, 0 - не проверять),
+// как описано в https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app.
+func Validate(initData, botToken string, maxAge time.Duration, now time.Time) (*InitData, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse init data: %w", err)
+	}
+
+	hash := values.Get("hash")
+	if hash == "" {
+		return nil, ErrMissingHash
+	}
+	expected, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal(Sign(values, botToken), expected) {
+		return nil, ErrInvalidSignature
+	}
+
+	authUnix, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth_date: %w", err)
+	}
+	authDate := time.Unix(authUnix, 0)
+	if maxAge > 0 && now.Sub(authDate) > maxAge {
+		return nil, ErrExpired
+	}
+
+	rawUser := values.Get("user")
+	if rawUser == "" {
+		return nil, ErrMissingUser
+	}
+	var user User
+	if err := json.Unmarshal([]byte(rawUser), &user); err != nil {
+		return nil, fmt.Errorf("invalid user: %w", err)
+	}
+	if user.ID == 0 {
+		return nil, ErrMissingUser
+	}
+
+	return &InitData{
+		User:       user,
+		AuthDate:   authDate,
+		QueryID:    values.Get("query_id"),
+		StartParam: values.Get("start_param"),
+	}, nil
+}
+
+// Sign вычисляет подпись initData: HMAC-SHA256 строки "key=value" всех полей, кроме hash,
+// отсортированных по ключу и разделенных \n. Ключ - HMAC-SHA256 токена бота с ключом "WebAppData".
+func Sign(values url.Values, botToken string) []byte {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+values.Get(key))
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	return mac.Sum(nil)
+}
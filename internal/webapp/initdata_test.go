@@ -0,0 +1,98 @@
+package webapp
+
+import (
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBotToken = "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+// signedInitData собирает initData так же, как Telegram, и подписывает ее токеном.
+func signedInitData(token string, authDate time.Time, user string) url.Values {
+	values := url.Values{}
+	values.Set("query_id", "AAHdF6IQAAAAAN0XohDhrOrc")
+	values.Set("user", user)
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	values.Set("hash", hex.EncodeToString(Sign(values, token)))
+	return values
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	user := `{"id":279058397,"first_name":"Vladislav","username":"vdkfrost","language_code":"ru"}`
+
+	t.Run("valid", func(t *testing.T) {
+		values := signedInitData(testBotToken, now.Add(-time.Hour), user)
+
+		data, err := Validate(values.Encode(), testBotToken, 24*time.Hour, now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(279058397), data.User.ID)
+		assert.Equal(t, "vdkfrost", data.User.Username)
+		assert.Equal(t, "AAHdF6IQAAAAAN0XohDhrOrc", data.QueryID)
+		assert.Equal(t, now.Add(-time.Hour).Unix(), data.AuthDate.Unix())
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		values := signedInitData(testBotToken, now, user)
+		values.Set("user", `{"id":1,"first_name":"Mallory"}`)
+
+		_, err := Validate(values.Encode(), testBotToken, 24*time.Hour, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("signed by another bot", func(t *testing.T) {
+		values := signedInitData("654321:other-token", now, user)
+
+		_, err := Validate(values.Encode(), testBotToken, 24*time.Hour, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		values := signedInitData(testBotToken, now.Add(-25*time.Hour), user)
+
+		_, err := Validate(values.Encode(), testBotToken, 24*time.Hour, now)
+		assert.ErrorIs(t, err, ErrExpired)
+
+		// maxAge 0 отключает проверку свежести.
+		_, err = Validate(values.Encode(), testBotToken, 0, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing hash", func(t *testing.T) {
+		values := signedInitData(testBotToken, now, user)
+		values.Del("hash")
+
+		_, err := Validate(values.Encode(), testBotToken, 24*time.Hour, now)
+		assert.ErrorIs(t, err, ErrMissingHash)
+	})
+
+	t.Run("malformed hash", func(t *testing.T) {
+		values := signedInitData(testBotToken, now, user)
+		values.Set("hash", "not-hex")
+
+		_, err := Validate(values.Encode(), testBotToken, 24*time.Hour, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		values := url.Values{}
+		values.Set("auth_date", strconv.FormatInt(now.Unix(), 10))
+		values.Set("hash", hex.EncodeToString(Sign(values, testBotToken)))
+
+		_, err := Validate(values.Encode(), testBotToken, 24*time.Hour, now)
+		assert.ErrorIs(t, err, ErrMissingUser)
+	})
+}
+
+func TestStatic(t *testing.T) {
+	for _, name := range []string{"index.html", "app.js", "style.css"} {
+		_, err := Static().Open(name)
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,19 @@
+package webapp
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Static возвращает файлы интерфейса Mini App.
+func Static() fs.FS {
+	sub, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// Каталог static встроен при сборке, ошибка возможна только при опечатке в пути.
+		panic(err)
+	}
+	return sub
+}
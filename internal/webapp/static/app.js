@@ -0,0 +1,162 @@
+// Telegram Mini App: подписки, трафик и тарифы пользователя.
+// Все запросы подписываются initData, которую сервер проверяет токеном бота.
+(function () {
+  "use strict";
+
+  var tg = window.Telegram && window.Telegram.WebApp;
+  var GB = 1024 * 1024 * 1024;
+  var selectedEmail = "";
+
+  function $(id) {
+    return document.getElementById(id);
+  }
+
+  function api(path) {
+    return fetch("/api/webapp" + path, {
+      headers: { Authorization: "tma " + (tg ? tg.initData : "") },
+    }).then(function (response) {
+      if (!response.ok) {
+        throw new Error("HTTP " + response.status);
+      }
+      return response.json();
+    });
+  }
+
+  function formatGB(bytes) {
+    return (bytes / GB).toFixed(2) + " GB";
+  }
+
+  function formatExpiry(ms) {
+    if (ms === 0) {
+      return "бессрочно";
+    }
+    if (ms < 0) {
+      return Math.round(-ms / 86400000) + " дн. с первого подключения";
+    }
+    return new Date(ms).toLocaleString("ru-RU");
+  }
+
+  function el(tag, className, text) {
+    var node = document.createElement(tag);
+    if (className) {
+      node.className = className;
+    }
+    if (text !== undefined) {
+      node.textContent = text;
+    }
+    return node;
+  }
+
+  function renderSubscriptions(subscriptions) {
+    var list = $("subscription-list");
+    list.textContent = "";
+    subscriptions.forEach(function (sub) {
+      var card = el("div", "card");
+      card.appendChild(el("div", "title", sub.email));
+
+      var used = sub.up + sub.down;
+      var limit = sub.total > 0 ? formatGB(sub.total) : "∞";
+      card.appendChild(el("div", "hint", formatGB(used) + " / " + limit + " · " + (sub.active ? "активна" : "неактивна")));
+      if (sub.total > 0) {
+        var progress = el("div", "progress");
+        var fill = el("div");
+        fill.style.width = Math.min(100, (used / sub.total) * 100) + "%";
+        progress.appendChild(fill);
+        card.appendChild(progress);
+      }
+      card.appendChild(el("div", "hint", "Действует до: " + formatExpiry(sub.expiry_time)));
+
+      if (sub.subscription_url) {
+        var copy = el("button", "", "Скопировать ссылку подписки");
+        copy.type = "button";
+        copy.addEventListener("click", function () {
+          navigator.clipboard.writeText(sub.subscription_url).then(function () {
+            if (tg) {
+              tg.showAlert("Ссылка скопирована");
+            }
+          });
+        });
+        card.appendChild(copy);
+      }
+      list.appendChild(card);
+    });
+    $("subscriptions").hidden = subscriptions.length === 0;
+  }
+
+  function renderUsage(days) {
+    var chart = $("usage-chart");
+    chart.textContent = "";
+    var max = 0;
+    var up = 0;
+    var down = 0;
+    days.forEach(function (day) {
+      max = Math.max(max, day.up + day.down);
+      up += day.up;
+      down += day.down;
+    });
+    days.forEach(function (day) {
+      var bar = el("div", "bar");
+      bar.title = day.day + ": " + formatGB(day.up + day.down);
+      var downPart = el("div", "down");
+      var upPart = el("div", "up");
+      downPart.style.height = max > 0 ? (day.down / max) * 160 + "px" : "0";
+      upPart.style.height = max > 0 ? (day.up / max) * 160 + "px" : "0";
+      bar.appendChild(downPart);
+      bar.appendChild(upPart);
+      chart.appendChild(bar);
+    });
+    $("usage-total").textContent = "↓ " + formatGB(down) + " · ↑ " + formatGB(up) + " (UTC)";
+  }
+
+  function loadUsage(days) {
+    api("/usage?days=" + days + "&email=" + encodeURIComponent(selectedEmail))
+      .then(function (data) {
+        renderUsage(data.days);
+      })
+      .catch(function () {
+        $("usage-total").textContent = "Не удалось загрузить историю трафика.";
+      });
+  }
+
+  function renderPlans(data) {
+    var list = $("plan-list");
+    list.textContent = "";
+    data.plans.forEach(function (plan) {
+      var traffic = plan.traffic_gb > 0 ? " + " + plan.traffic_gb + " GB" : "";
+      list.appendChild(el("div", "card", plan.days + " дн." + traffic + " — " + plan.price + " " + data.currency));
+    });
+    $("plans").hidden = data.plans.length === 0;
+  }
+
+  document.querySelectorAll(".tabs button").forEach(function (button) {
+    button.addEventListener("click", function () {
+      document.querySelectorAll(".tabs button").forEach(function (other) {
+        other.classList.toggle("active", other === button);
+      });
+      loadUsage(button.dataset.days);
+    });
+  });
+
+  if (tg) {
+    tg.ready();
+    tg.expand();
+  }
+
+  api("/me")
+    .then(function (me) {
+      $("status").hidden = true;
+      renderSubscriptions(me.subscriptions);
+      if (me.subscriptions.length > 0) {
+        selectedEmail = me.subscriptions[0].email;
+        $("usage").hidden = false;
+        loadUsage(7);
+      } else {
+        $("status").hidden = false;
+        $("status").textContent = "К вашему аккаунту не привязана подписка. Используйте /trial или /buy в чате с ботом.";
+      }
+      return api("/plans").then(renderPlans);
+    })
+    .catch(function () {
+      $("status").textContent = "Не удалось загрузить данные. Откройте приложение из чата с ботом.";
+    });
+})();
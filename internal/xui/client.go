@@ -1,6 +1,7 @@
 package xui
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,6 +22,7 @@ type Client struct {
 	url            string
 	username       string
 	password       string
+	mu             sync.Mutex // guards the session; the client is shared by update workers
 	sessionCookie  *http.Cookie
 	sessionExpires time.Time
 	logger         *slog.Logger
@@ -117,63 +120,87 @@ func (c *Client) login(ctx context.Context) error {
 	return errors.New("session cookie not found")
 }
 
-func (c *Client) loginIfNoCookie(ctx context.Context) error {
+// session returns a valid session cookie, logging in if there is none or it has expired.
+func (c *Client) session(ctx context.Context) (*http.Cookie, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.sessionCookie != nil && c.sessionExpires.After(time.Now()) {
-		return nil
+		return c.sessionCookie, nil
+	}
+	c.sessionCookie = nil
+	if err := c.login(ctx); err != nil {
+		return nil, err
 	}
-	return c.login(ctx)
+	return c.sessionCookie, nil
 }
 
-// GetClientTraffics fetches traffic data for a specific client by email.
-func (c *Client) GetClientTraffics(ctx context.Context, email string) ([]ClientTraffic, error) {
-	if err := c.loginIfNoCookie(ctx); err != nil {
+// apiResponse is the common envelope of 3x-ui API responses.
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Msg     string          `json:"msg"`
+	Obj     json.RawMessage `json:"obj"`
+}
+
+// call performs an authenticated request to the 3x-ui API and returns the "obj" field
+// of the response. A non-nil payload is sent as JSON.
+func (c *Client) call(ctx context.Context, method, path string, payload any) (json.RawMessage, error) {
+	cookie, err := c.session(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	apiURL, err := url.JoinPath(c.url, "/panel/api/inbounds/getClientTraffics/", email)
+	apiURL, err := url.JoinPath(c.url, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create api URL: %w", err)
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	req.AddCookie(c.sessionCookie)
+	req.AddCookie(cookie)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		c.logger.Error("Failed to execute request to X-UI", "error", err, "email", email)
+		c.logger.Error("Failed to execute request to X-UI", "error", err, "path", path)
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		c.logger.Error("Failed to read response body from X-UI", "error", err, "email", email, "status", resp.Status)
+		c.logger.Error("Failed to read response body from X-UI", "error", err, "path", path, "status", resp.Status)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	c.logger.Debug("X-UI API response", "email", email, "status", resp.Status, "body", string(body))
+	c.logger.Debug("X-UI API response", "path", path, "status", resp.Status, "body", string(respBody))
 
 	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("X-UI API returned non-OK status", "email", email, "status_code", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("bad status code: %d, body: %s", resp.StatusCode, string(body))
+		c.logger.Error("X-UI API returned non-OK status", "path", path, "status_code", resp.StatusCode, "body", string(respBody))
+		return nil, fmt.Errorf("bad status code: %d, body: %s", resp.StatusCode, string(respBody))
 	}
 
-	if len(body) == 0 {
+	if len(respBody) == 0 {
 		return nil, errors.New("API returned an empty response body, check credentials or User-Agent header")
 	}
 
-	type APIResponse struct {
-		Success bool            `json:"success"`
-		Msg     string          `json:"msg"`
-		Obj     json.RawMessage `json:"obj"`
-	}
-
-	var apiResp APIResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		c.logger.Error("Failed to unmarshal X-UI API response", "error", err, "email", email, "body", string(body))
+	var apiResp apiResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		c.logger.Error("Failed to unmarshal X-UI API response", "error", err, "path", path, "body", string(respBody))
 		return nil, fmt.Errorf("failed to unmarshal API response: %w", err)
 	}
 
@@ -181,17 +208,27 @@ func (c *Client) GetClientTraffics(ctx context.Context, email string) ([]ClientT
 		return nil, fmt.Errorf("api error: %s", apiResp.Msg)
 	}
 
+	return apiResp.Obj, nil
+}
+
+// GetClientTraffics fetches traffic data for a specific client by email.
+func (c *Client) GetClientTraffics(ctx context.Context, email string) ([]ClientTraffic, error) {
+	obj, err := c.call(ctx, http.MethodGet, "/panel/api/inbounds/getClientTraffics/"+email, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	// Handle null or empty object case
-	if string(apiResp.Obj) == "null" || len(apiResp.Obj) == 0 {
+	if string(obj) == "null" || len(obj) == 0 {
 		return []ClientTraffic{}, nil
 	}
 
 	var traffics []ClientTraffic
 	// Try to unmarshal as an array first
-	if err := json.Unmarshal(apiResp.Obj, &traffics); err != nil {
+	if err := json.Unmarshal(obj, &traffics); err != nil {
 		// If it's not an array, try to unmarshal as a single object
 		var singleTraffic ClientTraffic
-		if err2 := json.Unmarshal(apiResp.Obj, &singleTraffic); err2 != nil {
+		if err2 := json.Unmarshal(obj, &singleTraffic); err2 != nil {
 			// If both fail, return the original array unmarshal error
 			return nil, fmt.Errorf("failed to unmarshal 'obj' field from API response: %w", err)
 		}
@@ -201,3 +238,68 @@ func (c *Client) GetClientTraffics(ctx context.Context, email string) ([]ClientT
 
 	return traffics, nil
 }
+
+// GetInbound fetches an inbound with its settings by ID.
+func (c *Client) GetInbound(ctx context.Context, inboundID int) (*Inbound, error) {
+	obj, err := c.call(ctx, http.MethodGet, fmt.Sprintf("/panel/api/inbounds/get/%d", inboundID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var inbound Inbound
+	if err := json.Unmarshal(obj, &inbound); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inbound: %w", err)
+	}
+	return &inbound, nil
+}
+
+// ListInbounds fetches all inbounds together with their client traffic stats.
+func (c *Client) ListInbounds(ctx context.Context) ([]Inbound, error) {
+	obj, err := c.call(ctx, http.MethodGet, "/panel/api/inbounds/list", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var inbounds []Inbound
+	if string(obj) == "null" || len(obj) == 0 {
+		return inbounds, nil
+	}
+	if err := json.Unmarshal(obj, &inbounds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inbounds: %w", err)
+	}
+	return inbounds, nil
+}
+
+// AddClient adds a new client to an inbound.
+func (c *Client) AddClient(ctx context.Context, inboundID int, client InboundClient) error {
+	payload, err := clientPayload(inboundID, client)
+	if err != nil {
+		return err
+	}
+	_, err = c.call(ctx, http.MethodPost, "/panel/api/inbounds/addClient", payload)
+	return err
+}
+
+// UpdateClient replaces the settings of an existing client. clientKey is the value
+// 3x-ui identifies the client by, see Inbound.ClientKey.
+func (c *Client) UpdateClient(ctx context.Context, inboundID int, clientKey string, client InboundClient) error {
+	payload, err := clientPayload(inboundID, client)
+	if err != nil {
+		return err
+	}
+	_, err = c.call(ctx, http.MethodPost, "/panel/api/inbounds/updateClient/"+clientKey, payload)
+	return err
+}
+
+// clientPayload builds the request body expected by addClient and updateClient:
+// the inbound ID and the settings JSON string with a single client.
+func clientPayload(inboundID int, client InboundClient) (map[string]any, error) {
+	settings, err := json.Marshal(map[string]any{"clients": []InboundClient{client}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal client settings: %w", err)
+	}
+	return map[string]any{
+		"id":       inboundID,
+		"settings": string(settings),
+	}, nil
+}
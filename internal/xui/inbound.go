@@ -0,0 +1,134 @@
+package xui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrClientNotFound is returned when a client with the given email is not present in an inbound.
+var ErrClientNotFound = errors.New("client not found")
+
+// Inbound represents an inbound of the 3x-ui panel.
+type Inbound struct {
+	ID          int             `json:"id"`
+	Up          int64           `json:"up"`
+	Down        int64           `json:"down"`
+	Total       int64           `json:"total"`
+	Remark      string          `json:"remark"`
+	Enable      bool            `json:"enable"`
+	ExpiryTime  int64           `json:"expiryTime"`
+	Port        int             `json:"port"`
+	Protocol    string          `json:"protocol"`
+	Settings    string          `json:"settings"`
+	Stream      string          `json:"streamSettings"`
+	Tag         string          `json:"tag"`
+	ClientStats []ClientTraffic `json:"clientStats"`
+}
+
+// Clients parses the clients from the inbound settings.
+func (i *Inbound) Clients() ([]InboundClient, error) {
+	var settings struct {
+		Clients []InboundClient `json:"clients"`
+	}
+	if err := json.Unmarshal([]byte(i.Settings), &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inbound settings: %w", err)
+	}
+	return settings.Clients, nil
+}
+
+// FindClient returns the client with the given email.
+func (i *Inbound) FindClient(email string) (*InboundClient, error) {
+	clients, err := i.Clients()
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		if client.Email == email {
+			return &client, nil
+		}
+	}
+	return nil, ErrClientNotFound
+}
+
+// ClientKey returns the value 3x-ui uses to identify a client of this inbound
+// in the updateClient endpoint: the password for trojan, the email for
+// shadowsocks and the UUID for other protocols.
+func (i *Inbound) ClientKey(client InboundClient) string {
+	switch i.Protocol {
+	case "trojan":
+		return client.Password
+	case "shadowsocks":
+		return client.Email
+	default:
+		return client.ID
+	}
+}
+
+// InboundClient is a client entry from the inbound settings.
+// Fields that are not declared here are preserved as is, so a client can be
+// read, modified and written back without losing settings of newer 3x-ui versions.
+type InboundClient struct {
+	ID         string `json:"id,omitempty"`
+	Password   string `json:"password,omitempty"`
+	Flow       string `json:"flow"`
+	Email      string `json:"email"`
+	LimitIP    int    `json:"limitIp"`
+	TotalGB    int64  `json:"totalGB"` // traffic limit in bytes, 0 means unlimited
+	ExpiryTime int64  `json:"expiryTime"`
+	Enable     bool   `json:"enable"`
+	SubID      string `json:"subId"`
+	Comment    string `json:"comment,omitempty"`
+	Reset      int    `json:"reset"`
+
+	extra map[string]json.RawMessage
+}
+
+// inboundClientFields is an alias without methods to avoid recursion in (un)marshalling.
+type inboundClientFields InboundClient
+
+// UnmarshalJSON decodes the known fields and keeps the rest.
+func (c *InboundClient) UnmarshalJSON(data []byte) error {
+	var fields inboundClientFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, key := range knownClientKeys {
+		delete(all, key)
+	}
+
+	*c = InboundClient(fields)
+	c.extra = all
+	return nil
+}
+
+// MarshalJSON encodes the known fields together with the preserved ones.
+func (c InboundClient) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(inboundClientFields(c))
+	if err != nil {
+		return nil, err
+	}
+	if len(c.extra) == 0 {
+		return data, nil
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, value := range c.extra {
+		if _, known := all[key]; !known {
+			all[key] = value
+		}
+	}
+	return json.Marshal(all)
+}
+
+var knownClientKeys = []string{
+	"id", "password", "flow", "email", "limitIp", "totalGB",
+	"expiryTime", "enable", "subId", "comment", "reset",
+}
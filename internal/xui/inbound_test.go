@@ -0,0 +1,47 @@
+package xui
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboundClient_PreservesUnknownFields(t *testing.T) {
+	inbound := Inbound{
+		ID:       3,
+		Protocol: "vless",
+		Settings: `{"clients":[{"id":"b831381d-6324-4d53-ad4f-8cda48b30811","email":"user@example.com","flow":"xtls-rprx-vision",` +
+			`"limitIp":2,"totalGB":10737418240,"expiryTime":1735689600000,"enable":false,"tgId":123456,"subId":"abc123","reset":0,"created_at":1700000000000}],"decryption":"none"}`,
+	}
+
+	client, err := inbound.FindClient("user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "b831381d-6324-4d53-ad4f-8cda48b30811", inbound.ClientKey(*client))
+	assert.Equal(t, int64(10737418240), client.TotalGB)
+
+	client.Enable = true
+	client.ExpiryTime = 1738368000000
+
+	data, err := json.Marshal(client)
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, true, fields["enable"])
+	assert.Equal(t, float64(1738368000000), fields["expiryTime"])
+	assert.Equal(t, float64(123456), fields["tgId"], "unknown fields must survive a round trip")
+	assert.Equal(t, float64(1700000000000), fields["created_at"], "unknown fields must survive a round trip")
+
+	_, err = inbound.FindClient("missing@example.com")
+	assert.ErrorIs(t, err, ErrClientNotFound)
+}
+
+func TestInbound_ClientKeyByProtocol(t *testing.T) {
+	client := InboundClient{ID: "uuid", Password: "secret", Email: "user@example.com"}
+
+	assert.Equal(t, "secret", (&Inbound{Protocol: "trojan"}).ClientKey(client))
+	assert.Equal(t, "user@example.com", (&Inbound{Protocol: "shadowsocks"}).ClientKey(client))
+	assert.Equal(t, "uuid", (&Inbound{Protocol: "vmess"}).ClientKey(client))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
@@ -15,31 +16,37 @@ import (
 )
 
 func main() {
+	role := flag.String("role", services.SuperadminRole, "роль администратора: superadmin, admin, support или созданная через API")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run scripts/create_admin.go [-role superadmin] <login> <password>")
+	}
+	flag.Parse()
+
 	// Загружаем .env файл из корня проекта для локального запуска.
 	// В Docker-окружении переменные будут переданы напрямую.
 	if err := godotenv.Load(); err != nil {
 		log.Println("Info: .env file not found, relying on environment variables")
 	}
 
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
 	cfg := config.Get()
 
 	db := database.Init(cfg)
 
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: go run scripts/create_admin.go <login> <password>")
-		os.Exit(1)
-	}
-
-	login := os.Args[1]
-	password := os.Args[2]
+	login := flag.Arg(0)
+	password := flag.Arg(1)
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	adminService := services.NewAdminService(db, logger)
 
-	admin, err := adminService.CreateAdmin(context.Background(), login, password)
+	admin, err := adminService.CreateAdmin(context.Background(), login, password, *role)
 	if err != nil {
 		log.Fatalf("Error creating admin: %v", err)
 	}
 
-	fmt.Printf("Admin '%s' created successfully with ID: %d\n", admin.Login, admin.ID)
+	fmt.Printf("Admin '%s' created successfully with ID: %d (role: %s)\n", admin.Login, admin.ID, admin.Role.Name)
 }